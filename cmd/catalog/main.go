@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
 
 	"kong/pkg/catalog"
 	"kong/pkg/config"
@@ -48,6 +50,34 @@ func main() {
 		}
 	}()
 
+	// Optional Consul-compatible listener
+	var consulSrv *http.Server
+	if cfg.ConsulAddr != "" {
+		consulSrv = &http.Server{Addr: cfg.ConsulAddr, Handler: app.ConsulHandler()}
+		go func() {
+			log.Info().Str("addr", cfg.ConsulAddr).Msg("Consul-compatible API listening")
+			if err := consulSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatal().Err(err).Msg("Consul API server error")
+			}
+		}()
+	}
+
+	// Optional gRPC listener
+	var grpcSrv *grpc.Server
+	if cfg.GRPCAddr != "" {
+		lis, err := net.Listen("tcp", cfg.GRPCAddr)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to listen for gRPC")
+		}
+		grpcSrv = app.GRPCServer()
+		go func() {
+			log.Info().Str("addr", cfg.GRPCAddr).Msg("gRPC API listening")
+			if err := grpcSrv.Serve(lis); err != nil {
+				log.Fatal().Err(err).Msg("gRPC server error")
+			}
+		}()
+	}
+
 	// graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -56,5 +86,21 @@ func main() {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 	_ = srv.Shutdown(ctx)
+	if consulSrv != nil {
+		_ = consulSrv.Shutdown(ctx)
+	}
+	if grpcSrv != nil {
+		// Watch streams never finish on their own, so stop them once the timeout expires
+		stopped := make(chan struct{})
+		go func() {
+			grpcSrv.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcSrv.Stop()
+		}
+	}
 	log.Info().Msg("Server stopped")
 }
@@ -42,7 +42,7 @@ PGPASSWORD=catalog_password psql -h "$DB_HOST" -p "$DB_PORT" -U catalog_user -d
 
 echo "Database schema setup complete!"
 echo "Tables created: services, service_versions"
-echo "Indexes created: services_name_lower_idx, service_versions_by_service_and_created_at"
+echo "Indexes created: services_name_trgm_idx, services_search_idx, service_versions_by_service_and_created_at"
 
 # Start the application
 echo "Starting application..."
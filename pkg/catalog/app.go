@@ -2,29 +2,57 @@ package catalog
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
 
 	"kong/pkg/catalog/middleware"
 	"kong/pkg/catalog/routes"
+	"kong/pkg/catalog/writes"
 	"kong/pkg/config"
+	"kong/pkg/consul"
+	"kong/pkg/cursor"
+	"kong/pkg/graphqlapi"
+	"kong/pkg/grpcapi"
+	"kong/pkg/health"
 	"kong/pkg/models"
+	"kong/pkg/netguard"
+	"kong/pkg/osv"
+	"kong/pkg/publish"
+	"kong/pkg/reports"
+	"kong/pkg/scorecard"
+	"kong/pkg/watch"
+	"kong/pkg/webhooks"
 )
 
 // App is the main application struct
 type App struct {
-	cfg   *config.AppConfig
-	pool  *pgxpool.Pool
-	store *models.Store
-	r     *chi.Mux
+	cfg    *config.AppConfig
+	pool   *pgxpool.Pool
+	store  *models.Store
+	r      *chi.Mux
+	cancel context.CancelFunc // stops background workers
+	nats   *publish.NATS
+	grpc   *grpc.Server
+	audits *watch.Hub // wakes Consul blocking queries
 }
 
+// placeholderCursorSecret is the example cursor secret of the README
+const placeholderCursorSecret = "change-me"
+
 // New creates a new App instance
 func New(ctx context.Context, cfg *config.AppConfig) (*App, error) {
+	// Cursors signed with a missing or published key could be forged, and a per-process key
+	// wouldn't work across replicas
+	if cfg.CursorSecret == "" || cfg.CursorSecret == placeholderCursorSecret {
+		return nil, errors.New("cursor_secret (CURSOR_SECRET) must be set to a secret shared by all replicas")
+	}
+
 	// Configure database connection pool
 	poolConfig, err := pgxpool.ParseConfig(cfg.DatabaseURL)
 	if err != nil {
@@ -57,6 +85,93 @@ func New(ctx context.Context, cfg *config.AppConfig) (*App, error) {
 
 	store := models.NewStore(pool, cfg.MaxPageSize)
 
+	checks := scorecard.DefaultChecks()
+	if cfg.ScorecardChecks != "" {
+		if checks, err = scorecard.Load(cfg.ScorecardChecks); err != nil {
+			pool.Close()
+			return nil, err
+		}
+	}
+	scorecards := scorecard.NewEvaluator(store, checks)
+
+	cursors, err := cursor.NewCodec([]byte(cfg.CursorSecret))
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	graphQL, err := graphqlapi.NewHandler(store, cfg.MaxPageSize, graphqlapi.Limits{
+		MaxDepth:      cfg.GraphQLMaxDepth,
+		MaxComplexity: cfg.GraphQLMaxComplexity,
+	})
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to build GraphQL schema: %w", err)
+	}
+
+	var natsPublisher *publish.NATS
+	if cfg.NATSURL != "" {
+		if natsPublisher, err = publish.NewNATS(cfg.NATSURL, cfg.NATSSubjectPrefix, cfg.EventSource); err != nil {
+			pool.Close()
+			return nil, err
+		}
+	}
+
+	// Background workers run until Close
+	bgCtx, cancel := context.WithCancel(context.Background())
+
+	// Load the OSV advisory mirror and keep it refreshed
+	var vulnDB *osv.DB
+	if cfg.OSVDir != "" {
+		vulnDB = osv.NewDB()
+		go vulnDB.Run(bgCtx, cfg.OSVDir, cfg.OSVRefreshInterval)
+	}
+
+	// Re-evaluate scorecards periodically so time-based checks stay current
+	go scorecards.Run(bgCtx, cfg.ScorecardInterval)
+
+	// Health probes and webhook deliveries refuse internal addresses URLs resolve or redirect to
+	var outboundClient *http.Client
+	if !cfg.AllowPrivateTargets {
+		outboundClient = netguard.NewClient()
+	}
+
+	// Probe registered health-check URLs
+	if cfg.HealthProbeConcurrency > 0 {
+		go health.NewScheduler(store, outboundClient, cfg.HealthProbeConcurrency).Run(bgCtx)
+	}
+
+	// Label stale services so they stand out in listings
+	if cfg.StaleLabelInterval > 0 {
+		go reports.RunStaleLabeler(bgCtx, store, cfg.StaleAfter, cfg.StaleLabelInterval)
+	}
+
+	// Deliver change events from the outbox to webhook subscriptions
+	if cfg.WebhookConcurrency > 0 {
+		go webhooks.NewDispatcher(store, outboundClient, cfg.WebhookConcurrency, max(cfg.WebhookMaxAttempts, 1), cfg.EventSource).Run(bgCtx)
+	}
+
+	// Delete change events every consumer is done with
+	if cfg.OutboxRetention > 0 {
+		go webhooks.RunPruner(bgCtx, store, cfg.OutboxRetention)
+	}
+
+	// Publish change events from the outbox to NATS
+	if natsPublisher != nil {
+		go publish.NewRelay(store, natsPublisher).Run(bgCtx)
+	}
+
+	// Wake change stream watchers on writes from any replica
+	changes := watch.NewHub(pool, models.ChangesChannel)
+	go changes.Run(bgCtx)
+
+	// Wake Consul blocking queries on any audited write
+	audits := watch.NewHub(pool, models.AuditChannel)
+	go audits.Run(bgCtx)
+
+	// Signature verification and rescoring are shared by the REST and gRPC write paths
+	writeHooks := writes.NewHooks(store, scorecards)
+
 	// Create a new router
 	r := chi.NewRouter()
 
@@ -64,17 +179,50 @@ func New(ctx context.Context, cfg *config.AppConfig) (*App, error) {
 	middleware.SetupGlobalMiddleware(r, cfg.ValidAPIKeys)
 
 	// Use the new routes system with middleware
-	routes.SetupRoutes(store, r)
-
-	app := &App{cfg: cfg, pool: pool, store: store, r: r}
+	routes.SetupRoutes(routes.Dependencies{
+		Store:               store,
+		VulnDB:              vulnDB,
+		Scorecards:          scorecards,
+		Writes:              writeHooks,
+		Cursors:             cursors,
+		CountEstimateAbove:  cfg.CountEstimateThreshold,
+		StaleAfter:          cfg.StaleAfter,
+		AllowPrivateTargets: cfg.AllowPrivateTargets,
+		StatsTTL:            cfg.StatsCacheTTL,
+		SuggestTimeout:      cfg.SuggestTimeout,
+		EventSource:         cfg.EventSource,
+		Changes:             changes,
+		WatchHeartbeat:      cfg.WatchHeartbeat,
+		GraphQL:             graphQL,
+		GraphiQL:            cfg.GraphiQL,
+	}, r)
+
+	// The gRPC API shares the store, write hooks and change notifications with the REST API
+	grpcSrv := grpcapi.NewServer(store, writeHooks, changes, cfg.ValidAPIKeys, cfg.WatchHeartbeat)
+
+	app := &App{cfg: cfg, pool: pool, store: store, r: r, cancel: cancel, nats: natsPublisher, grpc: grpcSrv, audits: audits}
 	return app, nil
 }
 
 // Router returns the router for the app
 func (a *App) Router() http.Handler { return a.r }
 
+// ConsulHandler returns the Consul-compatible read-only API handler
+func (a *App) ConsulHandler() http.Handler {
+	return consul.NewHandler(a.store, a.audits, a.cfg.ValidAPIKeys)
+}
+
+// GRPCServer returns the gRPC API server
+func (a *App) GRPCServer() *grpc.Server { return a.grpc }
+
 // Pool returns the database pool
 func (a *App) Pool() *pgxpool.Pool { return a.pool }
 
-// Close closes the app
-func (a *App) Close() { a.pool.Close() }
+// Close stops background workers and closes the app
+func (a *App) Close() {
+	a.cancel()
+	if a.nats != nil {
+		a.nats.Close()
+	}
+	a.pool.Close()
+}
@@ -0,0 +1,234 @@
+package catalog
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	catalogv1 "kong/pkg/api/catalog/v1"
+	"kong/pkg/config"
+	"kong/pkg/models"
+)
+
+// testGRPCClient serves the app's gRPC API in memory and returns a connection to it
+func testGRPCClient(t *testing.T, app *App) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = app.GRPCServer().Serve(lis) }()
+	t.Cleanup(app.GRPCServer().Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// withAPIKey returns a context sending the test API key
+func withAPIKey(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "x-api-key", "test-api-key-1")
+}
+
+func TestGRPC_CatalogService(t *testing.T) {
+	app, cleanup := testHTTPApp(t)
+	defer cleanup()
+
+	conn := testGRPCClient(t, app)
+	client := catalogv1.NewCatalogServiceClient(conn)
+	ctx := withAPIKey(context.Background())
+
+	t.Run("Authentication", func(t *testing.T) {
+		_, err := client.ListServices(context.Background(), &catalogv1.ListServicesRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		badKey := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "wrong")
+		_, err = client.ListServices(badKey, &catalogv1.ListServicesRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("Health and reflection", func(t *testing.T) {
+		resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{
+			Service: catalogv1.CatalogService_ServiceDesc.ServiceName,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+		stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+		}))
+		reply, err := stream.Recv()
+		require.NoError(t, err)
+		var names []string
+		for _, s := range reply.GetListServicesResponse().Service {
+			names = append(names, s.Name)
+		}
+		assert.Contains(t, names, "catalog.v1.CatalogService")
+	})
+
+	var service *catalogv1.Service
+	t.Run("CreateService", func(t *testing.T) {
+		resp, err := client.CreateService(ctx, &catalogv1.CreateServiceRequest{
+			Name:   "payments",
+			Team:   "billing",
+			Labels: map[string]string{"env": "prod"},
+		})
+		require.NoError(t, err)
+		service = resp.Service
+		assert.Equal(t, "payments", service.Name)
+		assert.Equal(t, int64(1), service.Revision)
+		assert.Equal(t, "prod", service.Labels["env"])
+
+		_, err = client.CreateService(ctx, &catalogv1.CreateServiceRequest{Name: "payments"})
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+		// The REST validation rules apply
+		_, err = client.CreateService(ctx, &catalogv1.CreateServiceRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, err = client.CreateService(ctx, &catalogv1.CreateServiceRequest{Name: "x", Labels: map[string]string{"Bad Key": "v"}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		// Writes are audited as the caller
+		events, err := app.store.ListAuditEvents(context.Background(), models.AuditFilter{})
+		require.NoError(t, err)
+		require.NotEmpty(t, events)
+		assert.Contains(t, events[0].Actor, "apikey:")
+	})
+
+	t.Run("CreateServiceVersion", func(t *testing.T) {
+		resp, err := client.CreateServiceVersion(ctx, &catalogv1.CreateServiceVersionRequest{
+			ServiceId: service.Id,
+			Version:   "1.0.0",
+			Commit:    "abc123",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "1.0.0", resp.Version.Version)
+		assert.False(t, resp.Version.Verified)
+
+		_, err = client.CreateServiceVersion(ctx, &catalogv1.CreateServiceVersionRequest{ServiceId: service.Id, Version: "1.0.0"})
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+		_, err = client.CreateServiceVersion(ctx, &catalogv1.CreateServiceVersionRequest{ServiceId: uuid.NewString(), Version: "1.0.0"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		_, err = client.CreateServiceVersion(ctx, &catalogv1.CreateServiceVersionRequest{
+			ServiceId:       service.Id,
+			Version:         "1.0.1",
+			ArtifactDigests: []string{"md5:nope"},
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("GetService", func(t *testing.T) {
+		resp, err := client.GetService(ctx, &catalogv1.GetServiceRequest{Id: service.Id, IncludeVersions: true})
+		require.NoError(t, err)
+		assert.Equal(t, "payments", resp.Service.Name)
+		require.Len(t, resp.Service.Versions, 1)
+		assert.Equal(t, "1.0.0", resp.Service.Versions[0].Version)
+
+		_, err = client.GetService(ctx, &catalogv1.GetServiceRequest{Id: uuid.NewString()})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		_, err = client.GetService(ctx, &catalogv1.GetServiceRequest{Id: "nope"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("ListServices and ListVersions", func(t *testing.T) {
+		_, err := client.CreateService(ctx, &catalogv1.CreateServiceRequest{Name: "ledger"})
+		require.NoError(t, err)
+
+		resp, err := client.ListServices(ctx, &catalogv1.ListServicesRequest{Sort: "name", Order: "asc"})
+		require.NoError(t, err)
+		require.Len(t, resp.Services, 2)
+		assert.Equal(t, "ledger", resp.Services[0].Name)
+
+		resp, err = client.ListServices(ctx, &catalogv1.ListServicesRequest{Q: "pay"})
+		require.NoError(t, err)
+		require.Len(t, resp.Services, 1)
+
+		_, err = client.ListServices(ctx, &catalogv1.ListServicesRequest{Sort: "team"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, err = client.ListServices(ctx, &catalogv1.ListServicesRequest{Limit: 5000})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		versions, err := client.ListVersions(ctx, &catalogv1.ListVersionsRequest{ServiceId: service.Id})
+		require.NoError(t, err)
+		require.Len(t, versions.Versions, 1)
+		assert.Equal(t, "abc123", versions.Versions[0].Commit)
+	})
+}
+
+func TestGRPC_WatchServices(t *testing.T) {
+	app, cleanup := testHTTPAppWithConfig(t, func(cfg *config.AppConfig) {
+		cfg.WatchHeartbeat = 100 * time.Millisecond
+	})
+	defer cleanup()
+
+	conn := testGRPCClient(t, app)
+	client := catalogv1.NewCatalogServiceClient(conn)
+	server := httptest.NewServer(app.Router())
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(withAPIKey(context.Background()), 10*time.Second)
+	defer cancel()
+
+	before := createTestService(t, server, map[string]any{"name": "ledger"})
+
+	stream, err := client.WatchServices(ctx, &catalogv1.WatchServicesRequest{Labels: map[string]string{"env": "prod"}})
+	require.NoError(t, err)
+	// The stream is established once the server sent its headers
+	_, err = stream.Header()
+	require.NoError(t, err)
+
+	// Changes made through the REST API are streamed
+	payments := createTestService(t, server, map[string]any{"name": "payments", "labels": map[string]string{"env": "prod"}})
+	createTestService(t, server, map[string]any{"name": "billing"})
+	resp := apiRequest(t, server, "POST", "/v1/services/"+payments+"/versions", map[string]any{"version": "2.0.0"})
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	created, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, models.EventServiceCreated, created.Event.Type)
+	assert.Equal(t, payments, created.Event.ServiceId)
+	assert.Equal(t, "payments", created.Event.GetService().Name)
+
+	versioned, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, models.EventVersionCreated, versioned.Event.Type)
+	assert.Equal(t, "2.0.0", versioned.Event.GetVersion().Version)
+	assert.Greater(t, versioned.Event.Id, created.Event.Id)
+
+	t.Run("Resume after an event", func(t *testing.T) {
+		after := int64(0)
+		stream, err := client.WatchServices(ctx, &catalogv1.WatchServicesRequest{ServiceIds: []string{before}, AfterEventId: &after})
+		require.NoError(t, err)
+		first, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, before, first.Event.ServiceId)
+		assert.Equal(t, "ledger", first.Event.GetService().Name)
+	})
+
+	t.Run("Invalid filters", func(t *testing.T) {
+		stream, err := client.WatchServices(ctx, &catalogv1.WatchServicesRequest{ServiceIds: []string{"nope"}})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"kong/pkg/models"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AuditHandler serves the audit log of writes
+type AuditHandler struct {
+	store *models.Store
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(store *models.Store) *AuditHandler {
+	return &AuditHandler{store: store}
+}
+
+// ListEvents returns audit events, newest first, filtered by actor, action, resource and time range
+func (h *AuditHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := models.AuditFilter{
+		Actor:        q.Get("actor"),
+		Action:       q.Get("action"),
+		ResourceType: q.Get("resource_type"),
+		ResourceID:   q.Get("resource_id"),
+	}
+	if s := q.Get("from"); s != "" {
+		f.From, _ = time.Parse(time.RFC3339, s)
+	}
+	if s := q.Get("to"); s != "" {
+		f.To, _ = time.Parse(time.RFC3339, s)
+	}
+	if s := q.Get("before_id"); s != "" {
+		f.BeforeID, _ = strconv.ParseInt(s, 10, 64)
+	}
+	if s := q.Get("limit"); s != "" {
+		f.Limit, _ = strconv.Atoi(s)
+	}
+
+	events, err := h.store.ListAuditEvents(r.Context(), f)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list audit events", err)
+		return
+	}
+
+	respond(w, map[string]any{"events": events})
+}
+
+// Verify recomputes the audit hash chain and reports the first event that doesn't match
+func (h *AuditHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	v, err := h.store.VerifyAuditChain(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to verify audit log", err)
+		return
+	}
+	respond(w, v)
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"kong/pkg/models"
+	"kong/pkg/sbom"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// maxSBOMSize bounds the size of uploaded SBOM documents
+const maxSBOMSize = 20 << 20
+
+// ComponentsHandler handles SBOM upload and component query endpoints
+type ComponentsHandler struct {
+	store *models.Store
+}
+
+// NewComponentsHandler creates a new components handler
+func NewComponentsHandler(store *models.Store) *ComponentsHandler {
+	return &ComponentsHandler{store: store}
+}
+
+// UploadSBOM attaches a CycloneDX or SPDX JSON SBOM to a service version, replacing any previous one
+func (h *ComponentsHandler) UploadSBOM(w http.ResponseWriter, r *http.Request) {
+	if !isJSONMediaType(r.Header.Get("Content-Type")) {
+		respondError(w, http.StatusUnsupportedMediaType, "Unsupported Content-Type (expected application/json, application/vnd.cyclonedx+json or application/spdx+json)", nil)
+		return
+	}
+
+	version, ok := resolveServiceVersion(h.store, w, r)
+	if !ok {
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSBOMSize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			respondError(w, http.StatusRequestEntityTooLarge, "SBOM too large (max 20MB)", nil)
+			return
+		}
+		respondError(w, http.StatusBadRequest, "Failed to read request body", err)
+		return
+	}
+
+	if !json.Valid(data) {
+		respondError(w, http.StatusBadRequest, "Invalid JSON format", nil)
+		return
+	}
+	doc, err := sbom.Parse(data)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid SBOM", err)
+		return
+	}
+
+	components := make([]models.Component, 0, len(doc.Components))
+	for _, c := range doc.Components {
+		components = append(components, models.Component{
+			PURL:    c.PURL,
+			Package: sbom.PackageOf(c.PURL),
+			Name:    c.Name,
+			Version: c.Version,
+			License: c.License,
+		})
+	}
+
+	record := &models.SBOM{
+		ServiceVersionID: version.ID,
+		Format:           doc.Format,
+		SpecVersion:      doc.SpecVersion,
+		Document:         data,
+	}
+	if err := h.store.ReplaceSBOM(r.Context(), record, components); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to store SBOM", err)
+		return
+	}
+
+	respond(w, record)
+}
+
+// isJSONMediaType reports whether a Content-Type is application/json or a +json type
+func isJSONMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || (strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
+
+// ListVersionComponents lists the components of a service version
+func (h *ComponentsHandler) ListVersionComponents(w http.ResponseWriter, r *http.Request) {
+	version, ok := resolveServiceVersion(h.store, w, r)
+	if !ok {
+		return
+	}
+
+	components, err := h.store.ListVersionComponents(r.Context(), version.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list components", err)
+		return
+	}
+
+	respond(w, map[string]any{"components": components})
+}
+
+// FindComponents lists every service version shipping the component given by ?purl=
+func (h *ComponentsHandler) FindComponents(w http.ResponseWriter, r *http.Request) {
+	purl := sbom.NormalizePURL(r.URL.Query().Get("purl"))
+	// A version separator after the last slash means the purl pins a version
+	exact := strings.Contains(purl[strings.LastIndex(purl, "/")+1:], "@")
+
+	usages, err := h.store.FindComponentUsage(r.Context(), purl, exact)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to find components", err)
+		return
+	}
+
+	respond(w, map[string]any{"items": usages})
+}
+
+// resolveServiceVersion looks up the service version addressed by the {id} and {version} URL parameters,
+// writing an error response and returning false if it can't be found
+func resolveServiceVersion(store *models.Store, w http.ResponseWriter, r *http.Request) (*models.ServiceVersion, bool) {
+	idStr := r.Context().Value("id").(string)
+	serviceID, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid service ID format", err)
+		return nil, false
+	}
+
+	versionStr := chi.URLParam(r, "version")
+	if versionStr == "" || len(versionStr) > 50 {
+		respondError(w, http.StatusBadRequest, "Invalid version", nil)
+		return nil, false
+	}
+
+	version, err := store.GetServiceVersion(r.Context(), serviceID, versionStr)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get service version", err)
+		return nil, false
+	}
+	if version == nil {
+		respondError(w, http.StatusNotFound, "Service version not found", nil)
+		return nil, false
+	}
+	return version, true
+}
+
+// UploadPackagesRequest is a plain package list for a service version
+type UploadPackagesRequest struct {
+	Packages []string `json:"packages"`
+}
+
+// UploadPackages replaces the component list of a service version with a list of package URLs
+func (h *ComponentsHandler) UploadPackages(w http.ResponseWriter, r *http.Request) {
+	version, ok := resolveServiceVersion(h.store, w, r)
+	if !ok {
+		return
+	}
+
+	var req UploadPackagesRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSBOMSize)).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON format", err)
+		return
+	}
+
+	seen := map[string]bool{}
+	components := make([]models.Component, 0, len(req.Packages))
+	for _, p := range req.Packages {
+		purl := sbom.NormalizePURL(p)
+		name, pkgVersion, _ := strings.Cut(purl[strings.LastIndex(purl, "/")+1:], "@")
+		if !strings.HasPrefix(purl, "pkg:") || name == "" || pkgVersion == "" {
+			respondError(w, http.StatusBadRequest, "Invalid package URL "+p+" (expected pkg:type/namespace/name@version)", nil)
+			return
+		}
+		if seen[purl] {
+			continue
+		}
+		seen[purl] = true
+		components = append(components, models.Component{
+			PURL:    purl,
+			Package: sbom.PackageOf(purl),
+			Name:    name,
+			Version: pkgVersion,
+		})
+	}
+
+	if err := h.store.ReplaceVersionPackages(r.Context(), version.ID, components); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to store packages", err)
+		return
+	}
+
+	respond(w, map[string]any{"components": components})
+}
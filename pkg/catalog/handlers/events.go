@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"kong/pkg/cloudevents"
+	"kong/pkg/models"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// EventType describes a published CloudEvents type
+type EventType struct {
+	Type       string `json:"type"`
+	DataSchema string `json:"dataschema"`
+}
+
+// EventsHandler serves catalog change events as CloudEvents and the schemas of their data
+type EventsHandler struct {
+	store  *models.Store
+	source string
+}
+
+// NewEventsHandler creates a new events handler. source is the CloudEvents source of the events.
+func NewEventsHandler(store *models.Store, source string) *EventsHandler {
+	return &EventsHandler{store: store, source: source}
+}
+
+// ListEvents returns the change events after an event ID, oldest first, in batch content mode
+func (h *EventsHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	var after int64
+	if s := r.URL.Query().Get("after"); s != "" {
+		after, _ = strconv.ParseInt(s, 10, 64)
+	}
+	var limit int
+	if s := r.URL.Query().Get("limit"); s != "" {
+		limit, _ = strconv.Atoi(s)
+	}
+
+	outbox, _, err := h.store.ListOutboxEvents(r.Context(), models.ChangeFilter{AfterID: after, Limit: limit})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list events", err)
+		return
+	}
+
+	events := make([]cloudevents.Event, len(outbox))
+	for i, e := range outbox {
+		events[i] = cloudevents.FromOutbox(e, h.source)
+	}
+	body, err := cloudevents.MarshalBatch(events)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to encode events", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", cloudevents.ContentTypeBatch)
+	_, _ = w.Write(body)
+}
+
+// GetEvent returns a change event in structured content mode, or in binary content mode when
+// the client accepts application/json
+func (h *EventsHandler) GetEvent(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "eventID"), 10, 64)
+	if err != nil || id < 1 {
+		respondError(w, http.StatusBadRequest, "Invalid event ID format", err)
+		return
+	}
+
+	outbox, err := h.store.GetOutboxEvent(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get event", err)
+		return
+	}
+	if outbox == nil {
+		respondError(w, http.StatusNotFound, "Event not found", nil)
+		return
+	}
+
+	event := cloudevents.FromOutbox(*outbox, h.source)
+	if accept := r.Header.Get("Accept"); strings.Contains(accept, "application/json") && !strings.Contains(accept, cloudevents.ContentTypeStructured) {
+		_, _ = w.Write(cloudevents.WriteBinary(w.Header(), event))
+		return
+	}
+
+	body, err := cloudevents.MarshalStructured(event)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to encode event", err)
+		return
+	}
+	w.Header().Set("Content-Type", cloudevents.ContentTypeStructured)
+	_, _ = w.Write(body)
+}
+
+// ListTypes lists the published event types and where the schema of their data is
+func (h *EventsHandler) ListTypes(w http.ResponseWriter, r *http.Request) {
+	types := []EventType{}
+	for _, t := range cloudevents.Types() {
+		types = append(types, EventType{Type: t, DataSchema: cloudevents.SchemaPath + t})
+	}
+
+	respond(w, map[string]any{"types": types})
+}
+
+// GetSchema returns the JSON Schema of the data of an event type
+func (h *EventsHandler) GetSchema(w http.ResponseWriter, r *http.Request) {
+	schema := cloudevents.Schema(chi.URLParam(r, "type"))
+	if schema == nil {
+		respondError(w, http.StatusNotFound, "Event type not found", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", cloudevents.ContentTypeSchema)
+	_, _ = w.Write(schema)
+}
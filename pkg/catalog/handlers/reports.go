@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"bytes"
+	"kong/pkg/models"
+	"kong/pkg/reports"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ReportsHandler handles catalog hygiene reports
+type ReportsHandler struct {
+	store      *models.Store
+	staleAfter time.Duration
+}
+
+// NewReportsHandler creates a new reports handler. staleAfter is the default age after
+// which a service's newest version makes it stale.
+func NewReportsHandler(store *models.Store, staleAfter time.Duration) *ReportsHandler {
+	return &ReportsHandler{store: store, staleAfter: staleAfter}
+}
+
+// Stale lists services without versions, without a recent version or without a description,
+// as JSON or as CSV when format=csv or the client accepts text/csv
+func (h *ReportsHandler) Stale(w http.ResponseWriter, r *http.Request) {
+	olderThan := h.staleAfter
+	if olderThan <= 0 {
+		olderThan = reports.DefaultStaleAfter
+	}
+	if days := r.URL.Query().Get("older_than_days"); days != "" {
+		n, _ := strconv.Atoi(days)
+		olderThan = time.Duration(n) * 24 * time.Hour
+	}
+
+	now := time.Now()
+	items, err := reports.FindStale(r.Context(), h.store, olderThan, now)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to build stale report", err)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		format = "csv"
+	}
+	if format == "csv" {
+		// Render before writing, so a failure can still be reported with an error status
+		var buf bytes.Buffer
+		if err := reports.WriteCSV(&buf, items); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to write stale report", err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="stale-services.csv"`)
+		if _, err := buf.WriteTo(w); err != nil {
+			log.Warn().Err(err).Msg("Failed to send stale report")
+		}
+		return
+	}
+
+	respond(w, map[string]any{
+		"cutoff": now.Add(-olderThan).UTC().Format(time.RFC3339),
+		"items":  items,
+	})
+}
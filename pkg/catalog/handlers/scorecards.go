@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"kong/pkg/models"
+	"kong/pkg/scorecard"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// ScorecardsHandler handles service maturity scorecard endpoints
+type ScorecardsHandler struct {
+	store     *models.Store
+	evaluator *scorecard.Evaluator
+}
+
+// NewScorecardsHandler creates a new scorecards handler
+func NewScorecardsHandler(store *models.Store, evaluator *scorecard.Evaluator) *ScorecardsHandler {
+	return &ScorecardsHandler{store: store, evaluator: evaluator}
+}
+
+// GetScorecard returns the latest scorecard of a service, evaluating it if it never was
+func (h *ScorecardsHandler) GetScorecard(w http.ResponseWriter, r *http.Request) {
+	idStr := r.Context().Value("id").(string)
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID format", err)
+		return
+	}
+
+	sc, err := h.store.GetScorecard(r.Context(), id)
+	if err == nil && sc == nil {
+		sc, err = h.evaluator.EvaluateService(r.Context(), id)
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get scorecard", err)
+		return
+	}
+	if sc == nil {
+		respondError(w, http.StatusNotFound, "Service not found", nil)
+		return
+	}
+
+	respond(w, sc)
+}
+
+// Leaderboard ranks teams by the average scorecard score of their services
+func (h *ScorecardsHandler) Leaderboard(w http.ResponseWriter, r *http.Request) {
+	teams, err := h.store.ScorecardLeaderboard(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to build scorecard leaderboard", err)
+		return
+	}
+
+	respond(w, map[string]any{"checks": h.evaluator.Checks(), "teams": teams})
+}
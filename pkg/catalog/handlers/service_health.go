@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kong/pkg/health"
+	"kong/pkg/models"
+	"kong/pkg/netguard"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// CreateHealthCheckRequest represents the data needed to register a health-check URL
+type CreateHealthCheckRequest struct {
+	Environment      string `json:"environment"`
+	URL              string `json:"url"`
+	IntervalSeconds  int    `json:"interval_seconds"`
+	TimeoutMS        int    `json:"timeout_ms"`
+	SuccessThreshold int    `json:"success_threshold"`
+	FailureThreshold int    `json:"failure_threshold"`
+}
+
+// ServiceHealthHandler handles the actively probed health of services
+type ServiceHealthHandler struct {
+	store        *models.Store
+	allowPrivate bool
+}
+
+// NewServiceHealthHandler creates a new service health handler. Unless allowPrivate is set,
+// health-check URLs on loopback, link-local and private addresses are rejected.
+func NewServiceHealthHandler(store *models.Store, allowPrivate bool) *ServiceHealthHandler {
+	return &ServiceHealthHandler{store: store, allowPrivate: allowPrivate}
+}
+
+// GetHealth returns the current status of every health check of a service plus the recent transitions
+func (h *ServiceHealthHandler) GetHealth(w http.ResponseWriter, r *http.Request) {
+	idStr := r.Context().Value("id").(string)
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID format", err)
+		return
+	}
+	environment := r.URL.Query().Get("environment")
+	limit := 50
+	if l := r.URL.Query().Get("history_limit"); l != "" {
+		limit, _ = strconv.Atoi(l)
+	}
+
+	checks, err := h.store.ListHealthChecks(r.Context(), id, environment)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list health checks", err)
+		return
+	}
+	history, err := h.store.ListHealthTransitions(r.Context(), id, environment, limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list health transitions", err)
+		return
+	}
+
+	respond(w, map[string]any{
+		"status":  health.Summarize(checks),
+		"checks":  checks,
+		"history": history,
+	})
+}
+
+// CreateHealthCheck registers a health-check URL for a service
+func (h *ServiceHealthHandler) CreateHealthCheck(w http.ResponseWriter, r *http.Request) {
+	idStr := r.Context().Value("id").(string)
+	serviceID, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid service ID format", err)
+		return
+	}
+
+	var req CreateHealthCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON format", err)
+		return
+	}
+
+	if msg := validateHealthCheck(&req, h.allowPrivate); msg != "" {
+		respondError(w, http.StatusBadRequest, msg, nil)
+		return
+	}
+
+	check := &models.HealthCheck{
+		ServiceID:        serviceID,
+		Environment:      req.Environment,
+		URL:              req.URL,
+		IntervalSeconds:  req.IntervalSeconds,
+		TimeoutMS:        req.TimeoutMS,
+		SuccessThreshold: req.SuccessThreshold,
+		FailureThreshold: req.FailureThreshold,
+	}
+
+	if err := h.store.CreateHealthCheck(r.Context(), check); err != nil {
+		// Check for specific database errors
+		if strings.Contains(err.Error(), "duplicate key") {
+			respondError(w, http.StatusConflict, "Health check already exists for this service", err)
+		} else if strings.Contains(err.Error(), "foreign key") {
+			respondError(w, http.StatusNotFound, "Service not found", nil)
+		} else {
+			respondError(w, http.StatusInternalServerError, "Failed to create health check", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(check)
+}
+
+// DeleteHealthCheck removes a health check and its history
+func (h *ServiceHealthHandler) DeleteHealthCheck(w http.ResponseWriter, r *http.Request) {
+	idStr := r.Context().Value("id").(string)
+	serviceID, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid service ID format", err)
+		return
+	}
+	checkID, err := uuid.Parse(chi.URLParam(r, "checkID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid check ID format", err)
+		return
+	}
+
+	deleted, err := h.store.DeleteHealthCheck(r.Context(), serviceID, checkID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete health check", err)
+		return
+	}
+	if !deleted {
+		respondError(w, http.StatusNotFound, "Health check not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateHealthCheck validates a health check request and applies defaults, returning an error message or ""
+func validateHealthCheck(req *CreateHealthCheckRequest, allowPrivate bool) string {
+	if req.Environment == "" {
+		return "Environment is required"
+	}
+	if len(req.Environment) > 50 {
+		return "Environment too long (max 50 characters)"
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "URL must be an absolute http or https URL"
+	}
+	if len(req.URL) > 2000 {
+		return "URL too long (max 2000 characters)"
+	}
+	if !allowPrivate && netguard.CheckURL(u) != nil {
+		return "URL must not point to a loopback, link-local or private address"
+	}
+
+	if req.IntervalSeconds == 0 {
+		req.IntervalSeconds = 30
+	}
+	if req.TimeoutMS == 0 {
+		req.TimeoutMS = min(5000, req.IntervalSeconds*1000)
+	}
+	if req.SuccessThreshold == 0 {
+		req.SuccessThreshold = 1
+	}
+	if req.FailureThreshold == 0 {
+		req.FailureThreshold = 3
+	}
+
+	if req.IntervalSeconds < 1 || req.IntervalSeconds > 3600 {
+		return "Interval must be between 1 and 3600 seconds"
+	}
+	// A probe must finish before the check is due again
+	if req.TimeoutMS < 1 || req.TimeoutMS > req.IntervalSeconds*1000 {
+		return "Timeout must be positive and not exceed the interval"
+	}
+	if req.SuccessThreshold < 1 || req.SuccessThreshold > 10 {
+		return "Success threshold must be between 1 and 10"
+	}
+	if req.FailureThreshold < 1 || req.FailureThreshold > 10 {
+		return "Failure threshold must be between 1 and 10"
+	}
+	return ""
+}
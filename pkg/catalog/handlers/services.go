@@ -2,52 +2,178 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
+	"kong/pkg/catalog/validation"
+	"kong/pkg/catalog/writes"
+	"kong/pkg/cursor"
 	"kong/pkg/models"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
-// CreateServiceRequest represents the data needed to create a service
-type CreateServiceRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-}
-
-// CreateServiceVersionRequest represents the data needed to create a service version
-type CreateServiceVersionRequest struct {
-	Version string `json:"version"`
-}
-
 // ServicesHandler handles service-related API endpoints
 type ServicesHandler struct {
-	store *models.Store
+	store         *models.Store
+	writes        *writes.Hooks
+	cursors       *cursor.Codec
+	estimateAbove int64
 }
 
-// NewServicesHandler creates a new services handler
-func NewServicesHandler(store *models.Store) *ServicesHandler {
-	return &ServicesHandler{store: store}
+// NewServicesHandler creates a new services handler. Signatures are verified and scorecards
+// re-evaluated by writes, pagination cursors are signed by cursors, and list totals past
+// estimateAbove services are estimated (see models.Store.CountServices).
+func NewServicesHandler(store *models.Store, writes *writes.Hooks, cursors *cursor.Codec, estimateAbove int64) *ServicesHandler {
+	return &ServicesHandler{store: store, writes: writes, cursors: cursors, estimateAbove: estimateAbove}
 }
 
-// ListServices lists services with validation
+// ListServices lists services with validation. Besides the page, the response has the total
+// number of matching services, the effective limit and offset, and the cursors of the adjacent
+// pages, which are also linked in a Link header.
 func (h *ServicesHandler) ListServices(w http.ResponseWriter, r *http.Request) {
-	q := r.URL.Query().Get("q")
-	sort := r.URL.Query().Get("sort")
-	order := r.URL.Query().Get("order")
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
-	includeVersions := r.URL.Query().Get("include_versions") == "true"
+	query, ok := h.serviceQuery(w, r)
+	if !ok {
+		return
+	}
 
-	items, err := h.store.ListServices(r.Context(), q, sort, order, limit, offset, includeVersions)
+	page, err := h.store.QueryServicesPage(r.Context(), query)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to list services", err)
 		return
 	}
+	total, exact, err := h.store.CountServices(r.Context(), query, h.estimateAbove)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to count services", err)
+		return
+	}
+	limit := h.store.PageSize(query.Limit)
+	next, prev := h.encodeCursor(page.Next), h.encodeCursor(page.Prev)
+	response := map[string]any{
+		"items":       page.Items,
+		"total":       total,
+		"total_exact": exact,
+		"limit":       limit,
+		"next_cursor": next,
+		"prev_cursor": prev,
+	}
+	if query.Cursor == nil {
+		response["offset"] = query.Offset
+	}
+
+	// Facet counts of all matching services, not only of the page
+	if r.URL.Query().Has("facets") {
+		facets, err := h.store.ServiceFacets(r.Context(), query, strings.Split(r.URL.Query().Get("facets"), ","))
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to count facets", err)
+			return
+		}
+		response["facets"] = facets
+	}
+
+	// Offset pages link to offsets, cursor pages to cursors
+	links := pageLinks{r: r, limit: limit}
+	links.add("first", nil)
+	if query.Cursor != nil {
+		if next != nil {
+			links.add("next", url.Values{"cursor": {*next}})
+		}
+		if prev != nil {
+			links.add("prev", url.Values{"cursor": {*prev}})
+		}
+	} else {
+		if (exact && int64(query.Offset+len(page.Items)) < total) || (!exact && page.Next != nil) {
+			links.add("next", url.Values{"offset": {strconv.Itoa(query.Offset + limit)}})
+		}
+		if query.Offset > 0 {
+			links.add("prev", url.Values{"offset": {strconv.Itoa(max(query.Offset-limit, 0))}})
+		}
+	}
+	if exact {
+		links.add("last", url.Values{"offset": {strconv.FormatInt(max(total-1, 0)/int64(limit)*int64(limit), 10)}})
+	}
+	w.Header().Set("Link", strings.Join(links.links, ", "))
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+
+	respond(w, response)
+}
 
-	respond(w, map[string]any{"items": items})
+// CountServices answers HEAD requests of the service list with the number of matching services
+// in X-Total-Count
+func (h *ServicesHandler) CountServices(w http.ResponseWriter, r *http.Request) {
+	query, ok := h.serviceQuery(w, r)
+	if !ok {
+		return
+	}
+	total, _, err := h.store.CountServices(r.Context(), query, h.estimateAbove)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to count services", err)
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// serviceQuery builds the query of a service list request, with the filter parsed by its
+// validation, responding with an error when the cursor is invalid
+func (h *ServicesHandler) serviceQuery(w http.ResponseWriter, r *http.Request) (models.ServiceQuery, bool) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	query := models.ServiceQuery{
+		Q:               r.URL.Query().Get("q"),
+		Search:          r.URL.Query().Get("search"),
+		Fuzzy:           r.URL.Query().Get("fuzzy"),
+		Filter:          validation.ServiceFilter(r.Context()),
+		Sort:            r.URL.Query().Get("sort"),
+		Order:           r.URL.Query().Get("order"),
+		Limit:           limit,
+		Offset:          offset,
+		IncludeVersions: r.URL.Query().Get("include_versions") == "true",
+	}
+
+	// A cursor continues the list in its own order
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		at, err := h.decodeCursor(token, "name", "created_at", "updated_at")
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid cursor", err)
+			return models.ServiceQuery{}, false
+		}
+		order := "asc"
+		if at.Desc {
+			order = "desc"
+		}
+		if (query.Sort != "" && query.Sort != at.Sort) || (query.Order != "" && query.Order != order) {
+			respondError(w, http.StatusBadRequest, "Invalid cursor", fmt.Errorf("cursor pages by %s %s", at.Sort, order))
+			return models.ServiceQuery{}, false
+		}
+		query.Sort, query.Order, query.Cursor = at.Sort, order, at
+	}
+	return query, true
+}
+
+// pageLinks builds an RFC 8288 Link header of the pages of a list
+type pageLinks struct {
+	r     *http.Request
+	limit int
+	links []string
+}
+
+// add links a page of the list: the request's parameters with the effective limit, without
+// offset and cursor, and with params
+func (l *pageLinks) add(rel string, params url.Values) {
+	q := l.r.URL.Query()
+	q.Del("offset")
+	q.Del("cursor")
+	q.Set("limit", strconv.Itoa(l.limit))
+	for k, v := range params {
+		q[k] = v
+	}
+	l.links = append(l.links, fmt.Sprintf(`<%s?%s>; rel="%s"`, l.r.URL.Path, q.Encode(), rel))
 }
 
 // GetService gets a service by ID with validation
@@ -61,7 +187,17 @@ func (h *ServicesHandler) GetService(w http.ResponseWriter, r *http.Request) {
 
 	includeVersions := r.URL.Query().Get("include_versions") == "true"
 
-	it, err := h.store.GetService(r.Context(), id, includeVersions)
+	var it *models.Service
+	if asOf := r.URL.Query().Get("as_of"); asOf != "" {
+		t, parseErr := time.Parse(time.RFC3339, asOf)
+		if parseErr != nil {
+			respondError(w, http.StatusBadRequest, "Invalid as_of, must be an RFC 3339 timestamp", parseErr)
+			return
+		}
+		it, err = h.store.GetServiceAsOf(r.Context(), id, t, includeVersions)
+	} else {
+		it, err = h.store.GetService(r.Context(), id, includeVersions)
+	}
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to get service", err)
 		return
@@ -86,36 +222,103 @@ func (h *ServicesHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	versions, err := h.store.ListVersions(r.Context(), id)
+	verifiedOnly := r.URL.Query().Get("verified") == "true"
+
+	// Without limit or cursor, all versions are listed
+	if !r.URL.Query().Has("limit") && !r.URL.Query().Has("cursor") {
+		versions, err := h.store.ListVersions(r.Context(), id, verifiedOnly)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to list service versions", err)
+			return
+		}
+		respond(w, map[string]any{"versions": versions})
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	var at *models.Cursor
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		if at, err = h.decodeCursor(token, "created_at"); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid cursor", err)
+			return
+		}
+	}
+	page, err := h.store.ListVersionsPage(r.Context(), id, verifiedOnly, limit, at)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to list service versions", err)
 		return
 	}
 
-	respond(w, map[string]any{"versions": versions})
+	respond(w, map[string]any{
+		"versions":    page.Items,
+		"next_cursor": h.encodeCursor(page.Next),
+		"prev_cursor": h.encodeCursor(page.Prev),
+	})
 }
 
-// CreateService creates a new service
-func (h *ServicesHandler) CreateService(w http.ResponseWriter, r *http.Request) {
-	var req CreateServiceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid JSON format", err)
+// decodeCursor verifies a cursor token and that it pages by one of sorts
+func (h *ServicesHandler) decodeCursor(token string, sorts ...string) (*models.Cursor, error) {
+	return decodeCursor(h.cursors, token, sorts...)
+}
+
+// encodeCursor returns the token of a cursor, nil when there is none
+func (h *ServicesHandler) encodeCursor(at *models.Cursor) *string {
+	return encodeCursor(h.cursors, at)
+}
+
+// decodeCursor verifies a cursor token signed by codec and that it pages by one of sorts
+func decodeCursor(codec *cursor.Codec, token string, sorts ...string) (*models.Cursor, error) {
+	var at models.Cursor
+	if err := codec.Decode(token, &at); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(sorts, at.Sort) {
+		return nil, cursor.ErrInvalid
+	}
+	return &at, nil
+}
+
+// encodeCursor returns the token of a cursor signed by codec, nil when there is none
+func encodeCursor(codec *cursor.Codec, at *models.Cursor) *string {
+	if at == nil {
+		return nil
+	}
+	token := codec.Encode(at)
+	return &token
+}
+
+// ListDependencies lists the services a service depends on
+func (h *ServicesHandler) ListDependencies(w http.ResponseWriter, r *http.Request) {
+	idStr := r.Context().Value("id").(string)
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID format", err)
 		return
 	}
 
-	// Validate required fields
-	if req.Name == "" {
-		respondError(w, http.StatusBadRequest, "Name is required", nil)
+	deps, err := h.store.ListDependencies(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list service dependencies", err)
+		return
+	}
+	if deps == nil {
+		respondError(w, http.StatusNotFound, "Service not found", nil)
 		return
 	}
 
-	if len(req.Name) > 100 {
-		respondError(w, http.StatusBadRequest, "Name too long (max 100 characters)", nil)
+	respond(w, map[string]any{"dependencies": deps})
+}
+
+// CreateService creates a new service
+func (h *ServicesHandler) CreateService(w http.ResponseWriter, r *http.Request) {
+	var req validation.CreateServiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON format", err)
 		return
 	}
 
-	if len(req.Description) > 1000 {
-		respondError(w, http.StatusBadRequest, "Description too long (max 1000 characters)", nil)
+	if msg := validation.ValidateCreateService(&req); msg != "" {
+		respondError(w, http.StatusBadRequest, msg, nil)
 		return
 	}
 
@@ -124,6 +327,8 @@ func (h *ServicesHandler) CreateService(w http.ResponseWriter, r *http.Request)
 		ID:          models.GenerateUUID(),
 		Name:        req.Name,
 		Description: req.Description,
+		Team:        req.Team,
+		Labels:      req.Labels,
 		CreatedAt:   time.Now().UTC(),
 		UpdatedAt:   time.Now().UTC(),
 		Versions:    []models.ServiceVersion{}, // Empty array for new service
@@ -138,12 +343,110 @@ func (h *ServicesHandler) CreateService(w http.ResponseWriter, r *http.Request)
 		}
 		return
 	}
+	h.writes.Rescore(r.Context(), service.ID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(service)
 }
 
+// UpdateService changes fields of a service, recording the result as a new revision
+func (h *ServicesHandler) UpdateService(w http.ResponseWriter, r *http.Request) {
+	idStr := r.Context().Value("id").(string)
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID format", err)
+		return
+	}
+
+	var req validation.UpdateServiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON format", err)
+		return
+	}
+
+	if msg := validation.ValidateServiceUpdate(&req); msg != "" {
+		respondError(w, http.StatusBadRequest, msg, nil)
+		return
+	}
+
+	service, err := h.store.UpdateService(r.Context(), id, models.ServicePatch{
+		Name:        req.Name,
+		Description: req.Description,
+		Team:        req.Team,
+		Labels:      req.Labels,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			respondError(w, http.StatusConflict, "Service with this name already exists", err)
+		} else {
+			respondError(w, http.StatusInternalServerError, "Failed to update service", err)
+		}
+		return
+	}
+	if service == nil {
+		respondError(w, http.StatusNotFound, "Service not found", nil)
+		return
+	}
+	h.writes.Rescore(r.Context(), id)
+
+	respond(w, service)
+}
+
+// ListRevisions lists the revisions of a service, newest first
+func (h *ServicesHandler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	idStr := r.Context().Value("id").(string)
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID format", err)
+		return
+	}
+
+	revisions, err := h.store.ListServiceRevisions(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list service revisions", err)
+		return
+	}
+	if len(revisions) == 0 {
+		respondError(w, http.StatusNotFound, "Service not found", nil)
+		return
+	}
+
+	respond(w, map[string]any{"revisions": revisions})
+}
+
+// RevertService restores a service to the content of an earlier revision as a new revision
+func (h *ServicesHandler) RevertService(w http.ResponseWriter, r *http.Request) {
+	idStr := r.Context().Value("id").(string)
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID format", err)
+		return
+	}
+	revision, err := strconv.Atoi(chi.URLParam(r, "revision"))
+	if err != nil || revision < 1 {
+		respondError(w, http.StatusBadRequest, "Invalid revision", err)
+		return
+	}
+
+	service, err := h.store.RevertService(r.Context(), id, revision)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			respondError(w, http.StatusConflict, "Another service now has this revision's name", err)
+		} else {
+			respondError(w, http.StatusInternalServerError, "Failed to revert service", err)
+		}
+		return
+	}
+	if service == nil {
+		respondError(w, http.StatusNotFound, "Revision not found", nil)
+		return
+	}
+	h.writes.Rescore(r.Context(), id)
+
+	respond(w, service)
+}
+
 // CreateServiceVersion creates a new service version
 func (h *ServicesHandler) CreateServiceVersion(w http.ResponseWriter, r *http.Request) {
 	// Get service ID from context (set by validation middleware)
@@ -154,40 +457,48 @@ func (h *ServicesHandler) CreateServiceVersion(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	var req CreateServiceVersionRequest
+	var req validation.CreateServiceVersionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid JSON format", err)
 		return
 	}
 
-	// Validate required fields
-	if req.Version == "" {
-		respondError(w, http.StatusBadRequest, "Version is required", nil)
-		return
-	}
-
-	if len(req.Version) > 50 {
-		respondError(w, http.StatusBadRequest, "Version too long (max 50 characters)", nil)
+	if msg := validation.ValidateCreateServiceVersion(&req); msg != "" {
+		respondError(w, http.StatusBadRequest, msg, nil)
 		return
 	}
 
 	// Create the service version with generated values
 	serviceVersion := &models.ServiceVersion{
-		ID:        models.GenerateUUID(),
-		ServiceID: serviceID,
-		Version:   req.Version,
-		CreatedAt: time.Now().UTC(),
+		ID:              models.GenerateUUID(),
+		ServiceID:       serviceID,
+		Version:         req.Version,
+		Commit:          req.Commit,
+		ArtifactDigests: req.ArtifactDigests,
+		Signature:       req.Signature,
+		CreatedAt:       time.Now().UTC(),
+	}
+
+	// Unsigned and unverifiable registrations are accepted but stored as unverified
+	if req.Signature != "" {
+		if err := h.writes.VerifySignature(r.Context(), serviceVersion); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to verify signature", err)
+			return
+		}
 	}
 
 	if err := h.store.CreateServiceVersion(r.Context(), serviceVersion); err != nil {
 		// Check for specific database errors
 		if strings.Contains(err.Error(), "duplicate key") {
 			respondError(w, http.StatusConflict, "Version already exists for this service", err)
+		} else if strings.Contains(err.Error(), "foreign key") {
+			respondError(w, http.StatusNotFound, "Service not found", nil)
 		} else {
 			respondError(w, http.StatusInternalServerError, "Failed to create service version", err)
 		}
 		return
 	}
+	h.writes.Rescore(r.Context(), serviceID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
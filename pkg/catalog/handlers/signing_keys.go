@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kong/pkg/models"
+	"kong/pkg/signing"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// CreateSigningKeyRequest represents the data needed to trust a signing key
+type CreateSigningKeyRequest struct {
+	Name      string `json:"name"`
+	PublicKey string `json:"public_key"`
+}
+
+// SigningKeysHandler handles the trusted signing keys of services
+type SigningKeysHandler struct {
+	store *models.Store
+}
+
+// NewSigningKeysHandler creates a new signing keys handler
+func NewSigningKeysHandler(store *models.Store) *SigningKeysHandler {
+	return &SigningKeysHandler{store: store}
+}
+
+// ListSigningKeys lists the trusted signing keys of a service
+func (h *SigningKeysHandler) ListSigningKeys(w http.ResponseWriter, r *http.Request) {
+	idStr := r.Context().Value("id").(string)
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID format", err)
+		return
+	}
+
+	keys, err := h.store.ListSigningKeys(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list signing keys", err)
+		return
+	}
+
+	respond(w, map[string]any{"keys": keys})
+}
+
+// CreateSigningKey adds a trusted PEM public key to a service
+func (h *SigningKeysHandler) CreateSigningKey(w http.ResponseWriter, r *http.Request) {
+	idStr := r.Context().Value("id").(string)
+	serviceID, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid service ID format", err)
+		return
+	}
+
+	var req CreateSigningKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON format", err)
+		return
+	}
+
+	if len(req.Name) > 100 {
+		respondError(w, http.StatusBadRequest, "Name too long (max 100 characters)", nil)
+		return
+	}
+	if req.PublicKey == "" {
+		respondError(w, http.StatusBadRequest, "Public key is required", nil)
+		return
+	}
+	pub, err := signing.ParsePublicKey(req.PublicKey)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid public key", err)
+		return
+	}
+
+	key := &models.SigningKey{
+		ServiceID:   serviceID,
+		Name:        req.Name,
+		Algorithm:   pub.Algorithm,
+		PublicKey:   req.PublicKey,
+		Fingerprint: pub.Fingerprint,
+	}
+
+	if err := h.store.CreateSigningKey(r.Context(), key); err != nil {
+		// Check for specific database errors
+		if strings.Contains(err.Error(), "duplicate key") {
+			respondError(w, http.StatusConflict, "Signing key already trusted for this service", err)
+		} else if strings.Contains(err.Error(), "foreign key") {
+			respondError(w, http.StatusNotFound, "Service not found", nil)
+		} else {
+			respondError(w, http.StatusInternalServerError, "Failed to create signing key", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(key)
+}
+
+// DeleteSigningKey stops trusting a signing key
+func (h *SigningKeysHandler) DeleteSigningKey(w http.ResponseWriter, r *http.Request) {
+	idStr := r.Context().Value("id").(string)
+	serviceID, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid service ID format", err)
+		return
+	}
+	keyID, err := uuid.Parse(chi.URLParam(r, "keyID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid key ID format", err)
+		return
+	}
+
+	deleted, err := h.store.DeleteSigningKey(r.Context(), serviceID, keyID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete signing key", err)
+		return
+	}
+	if !deleted {
+		respondError(w, http.StatusNotFound, "Signing key not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"kong/pkg/models"
+	"kong/pkg/slo"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	yaml "sigs.k8s.io/yaml/goyaml.v2"
+)
+
+// sloNameRe matches valid SLO names; they end up in Prometheus label values and rule group names
+var sloNameRe = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// metricNameRe matches valid Prometheus metric names
+var metricNameRe = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// CreateSLORequest represents the data needed to declare an SLO
+type CreateSLORequest struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	SLI         string  `json:"sli"`
+	Objective   float64 `json:"objective"`
+	Window      string  `json:"window"`
+	ThresholdMS int     `json:"threshold_ms"`
+	Metric      string  `json:"metric"`
+}
+
+// ErrorBudgetRequest carries the event counts observed over a period
+type ErrorBudgetRequest struct {
+	Good   *float64 `json:"good"`
+	Total  *float64 `json:"total"`
+	Period string   `json:"period"`
+}
+
+// SLOsHandler handles service level objective endpoints
+type SLOsHandler struct {
+	store *models.Store
+}
+
+// NewSLOsHandler creates a new SLOs handler
+func NewSLOsHandler(store *models.Store) *SLOsHandler {
+	return &SLOsHandler{store: store}
+}
+
+// ListSLOs lists the SLOs of a service
+func (h *SLOsHandler) ListSLOs(w http.ResponseWriter, r *http.Request) {
+	idStr := r.Context().Value("id").(string)
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID format", err)
+		return
+	}
+
+	slos, err := h.store.ListSLOs(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list SLOs", err)
+		return
+	}
+
+	respond(w, map[string]any{"slos": slos})
+}
+
+// GetSLO gets an SLO of a service
+func (h *SLOsHandler) GetSLO(w http.ResponseWriter, r *http.Request) {
+	o, ok := h.resolveSLO(w, r)
+	if !ok {
+		return
+	}
+	respond(w, o)
+}
+
+// CreateSLO declares an SLO on a service
+func (h *SLOsHandler) CreateSLO(w http.ResponseWriter, r *http.Request) {
+	idStr := r.Context().Value("id").(string)
+	serviceID, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid service ID format", err)
+		return
+	}
+
+	var req CreateSLORequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON format", err)
+		return
+	}
+
+	if msg := validateSLO(&req); msg != "" {
+		respondError(w, http.StatusBadRequest, msg, nil)
+		return
+	}
+
+	o := &models.SLO{
+		ServiceID:   serviceID,
+		Name:        req.Name,
+		Description: req.Description,
+		SLI:         req.SLI,
+		Objective:   req.Objective,
+		Window:      req.Window,
+		ThresholdMS: req.ThresholdMS,
+		Metric:      req.Metric,
+	}
+
+	if err := h.store.CreateSLO(r.Context(), o); err != nil {
+		// Check for specific database errors
+		if strings.Contains(err.Error(), "duplicate key") {
+			respondError(w, http.StatusConflict, "SLO with this name already exists for this service", err)
+		} else if strings.Contains(err.Error(), "foreign key") {
+			respondError(w, http.StatusNotFound, "Service not found", nil)
+		} else {
+			respondError(w, http.StatusInternalServerError, "Failed to create SLO", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(o)
+}
+
+// DeleteSLO removes an SLO from a service
+func (h *SLOsHandler) DeleteSLO(w http.ResponseWriter, r *http.Request) {
+	idStr := r.Context().Value("id").(string)
+	serviceID, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid service ID format", err)
+		return
+	}
+	sloID, err := uuid.Parse(chi.URLParam(r, "sloID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid SLO ID format", err)
+		return
+	}
+
+	deleted, err := h.store.DeleteSLO(r.Context(), serviceID, sloID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete SLO", err)
+		return
+	}
+	if !deleted {
+		respondError(w, http.StatusNotFound, "SLO not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ErrorBudget computes the burn rate and remaining error budget of an SLO from good/total event counts
+func (h *SLOsHandler) ErrorBudget(w http.ResponseWriter, r *http.Request) {
+	o, ok := h.resolveSLO(w, r)
+	if !ok {
+		return
+	}
+
+	var req ErrorBudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON format", err)
+		return
+	}
+
+	if req.Good == nil || req.Total == nil {
+		respondError(w, http.StatusBadRequest, "Good and total are required", nil)
+		return
+	}
+	good, total := *req.Good, *req.Total
+	if good < 0 || total < 0 || good > total {
+		respondError(w, http.StatusBadRequest, "Counts must satisfy 0 <= good <= total", nil)
+		return
+	}
+
+	window, err := slo.ParseWindow(o.Window)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Stored SLO window is invalid", err)
+		return
+	}
+	period := window
+	if req.Period != "" {
+		if period, err = slo.ParseWindow(req.Period); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid period", err)
+			return
+		}
+		if period > window {
+			respondError(w, http.StatusBadRequest, "Period must not exceed the SLO window", nil)
+			return
+		}
+	}
+
+	respond(w, map[string]any{
+		"slo":    o,
+		"budget": slo.Budget(o.Objective, window, period, good, total),
+	})
+}
+
+// PrometheusRules exports every SLO of the catalog as a Prometheus rule file
+func (h *SLOsHandler) PrometheusRules(w http.ResponseWriter, r *http.Request) {
+	slos, err := h.store.ListAllSLOs(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list SLOs", err)
+		return
+	}
+
+	specs := make([]slo.Spec, 0, len(slos))
+	for _, o := range slos {
+		specs = append(specs, slo.Spec{
+			Service:     o.ServiceName,
+			Name:        o.Name,
+			SLI:         o.SLI,
+			Objective:   o.Objective,
+			Window:      o.Window,
+			ThresholdMS: o.ThresholdMS,
+			Metric:      o.Metric,
+		})
+	}
+
+	out, err := yaml.Marshal(slo.Rules(specs))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to render rules", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(out)
+}
+
+// resolveSLO loads the {sloID} SLO of the {id} service, writing an error response when it can't
+func (h *SLOsHandler) resolveSLO(w http.ResponseWriter, r *http.Request) (*models.SLO, bool) {
+	idStr := r.Context().Value("id").(string)
+	serviceID, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid service ID format", err)
+		return nil, false
+	}
+	sloID, err := uuid.Parse(chi.URLParam(r, "sloID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid SLO ID format", err)
+		return nil, false
+	}
+
+	o, err := h.store.GetSLO(r.Context(), serviceID, sloID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get SLO", err)
+		return nil, false
+	}
+	if o == nil {
+		respondError(w, http.StatusNotFound, "SLO not found", nil)
+		return nil, false
+	}
+	return o, true
+}
+
+// validateSLO validates an SLO request, returning an error message or ""
+func validateSLO(req *CreateSLORequest) string {
+	if !sloNameRe.MatchString(req.Name) {
+		return "Name must be lowercase alphanumerics and '-' (max 63 characters)"
+	}
+	if len(req.Description) > 1000 {
+		return "Description too long (max 1000 characters)"
+	}
+	if req.Objective <= 0 || req.Objective >= 100 {
+		return "Objective must be a percentage between 0 and 100 (exclusive)"
+	}
+
+	window, err := slo.ParseWindow(req.Window)
+	if err != nil {
+		return "Window must be a duration such as 7d, 28d or 4w"
+	}
+	if window < time.Hour || window > 90*24*time.Hour {
+		return "Window must be between 1h and 90d"
+	}
+
+	switch req.SLI {
+	case slo.SLIAvailability:
+		if req.ThresholdMS != 0 {
+			return "Threshold only applies to latency SLIs"
+		}
+	case slo.SLILatency:
+		if req.ThresholdMS <= 0 {
+			return "Latency SLIs require a positive threshold_ms"
+		}
+	default:
+		return fmt.Sprintf("SLI must be one of: %s, %s", slo.SLIAvailability, slo.SLILatency)
+	}
+
+	if req.Metric != "" && !metricNameRe.MatchString(req.Metric) {
+		return "Metric must be a valid Prometheus metric name"
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"kong/pkg/models"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StatsHandler serves catalog statistics for dashboards. Responses are cached briefly
+// per query so frequent polling doesn't rerun the aggregates.
+type StatsHandler struct {
+	store *models.Store
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	cache map[string]statsEntry
+}
+
+type statsEntry struct {
+	body    map[string]any
+	expires time.Time
+}
+
+// NewStatsHandler creates a new stats handler caching responses for ttl, caching is disabled when ttl is 0
+func NewStatsHandler(store *models.Store, ttl time.Duration) *StatsHandler {
+	return &StatsHandler{store: store, ttl: ttl, cache: map[string]statsEntry{}}
+}
+
+// GetStats returns service and version totals, versions created per day or week over a range,
+// the services releasing most often in that range and the services created per month
+func (h *StatsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Encode()
+	now := time.Now()
+	if body, ok := h.cached(key, now); ok {
+		w.Header().Set("X-Cache", "HIT")
+		respond(w, body)
+		return
+	}
+
+	q := r.URL.Query()
+	to := now
+	if s := q.Get("to"); s != "" {
+		to, _ = parseStatsTime(s)
+	}
+	from := to.AddDate(0, 0, -30)
+	if s := q.Get("from"); s != "" {
+		from, _ = parseStatsTime(s)
+	}
+	interval := q.Get("interval")
+	if interval == "" {
+		interval = "day"
+	}
+	top := 10
+	if s := q.Get("top"); s != "" {
+		top, _ = strconv.Atoi(s)
+	}
+
+	ctx := r.Context()
+	totals, err := h.store.CatalogTotals(ctx)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to count services", err)
+		return
+	}
+	versions, err := h.store.VersionsPerBucket(ctx, interval, from, to)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to count versions", err)
+		return
+	}
+	cadence, err := h.store.TopServicesByCadence(ctx, from, to, top)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to rank services", err)
+		return
+	}
+	// The last twelve calendar months up to the end of the range
+	monthsFrom := time.Date(to.UTC().Year(), to.UTC().Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -11, 0)
+	services, err := h.store.ServicesPerBucket(ctx, "month", monthsFrom, to)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to count services", err)
+		return
+	}
+
+	body := map[string]any{
+		"generated_at":               now.UTC().Format(time.RFC3339),
+		"totals":                     totals,
+		"range":                      map[string]any{"from": from.UTC(), "to": to.UTC(), "interval": interval},
+		"versions_created":           versions,
+		"top_services_by_cadence":    cadence,
+		"services_created_per_month": services,
+	}
+	h.remember(key, body, now)
+	w.Header().Set("X-Cache", "MISS")
+	respond(w, body)
+}
+
+func (h *StatsHandler) cached(key string, now time.Time) (map[string]any, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	e, ok := h.cache[key]
+	if !ok || !now.Before(e.expires) {
+		return nil, false
+	}
+	return e.body, true
+}
+
+func (h *StatsHandler) remember(key string, body map[string]any, now time.Time) {
+	if h.ttl <= 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// Drop expired entries so distinct queries can't grow the cache without bound
+	for k, e := range h.cache {
+		if !now.Before(e.expires) {
+			delete(h.cache, k)
+		}
+	}
+	h.cache[key] = statsEntry{body: body, expires: now.Add(h.ttl)}
+}
+
+// parseStatsTime accepts an RFC 3339 timestamp or a YYYY-MM-DD date (midnight UTC)
+func parseStatsTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
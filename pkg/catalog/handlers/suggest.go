@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"kong/pkg/models"
+)
+
+// SuggestHandler serves service name autocompletion
+type SuggestHandler struct {
+	store   *models.Store
+	timeout time.Duration
+}
+
+// NewSuggestHandler creates a new suggest handler. Lookups taking longer than timeout are abandoned,
+// no timeout is applied when it is 0.
+func NewSuggestHandler(store *models.Store, timeout time.Duration) *SuggestHandler {
+	return &SuggestHandler{store: store, timeout: timeout}
+}
+
+// Suggest returns the service names best completing a prefix, tolerating typos
+func (h *SuggestHandler) Suggest(w http.ResponseWriter, r *http.Request) {
+	limit := 10
+	if s := r.URL.Query().Get("limit"); s != "" {
+		limit, _ = strconv.Atoi(s)
+	}
+
+	ctx := r.Context()
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	suggestions, err := h.store.SuggestServices(ctx, r.URL.Query().Get("prefix"), limit)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			respondError(w, http.StatusServiceUnavailable, "Suggestions took too long", err)
+		} else {
+			respondError(w, http.StatusInternalServerError, "Failed to suggest services", err)
+		}
+		return
+	}
+
+	respond(w, map[string]any{"suggestions": suggestions})
+}
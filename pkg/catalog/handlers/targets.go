@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"kong/pkg/models"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// labelNameRe matches valid Prometheus label names
+var labelNameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// CreateScrapeTargetRequest represents the data needed to register a scrape target
+type CreateScrapeTargetRequest struct {
+	Environment string            `json:"environment"`
+	Address     string            `json:"address"`
+	MetricsPath string            `json:"metrics_path"`
+	Labels      map[string]string `json:"labels"`
+}
+
+// PrometheusTargetGroup is a single entry of Prometheus' http_sd_config response
+type PrometheusTargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+// TargetsHandler handles scrape target and service discovery endpoints
+type TargetsHandler struct {
+	store *models.Store
+}
+
+// NewTargetsHandler creates a new targets handler
+func NewTargetsHandler(store *models.Store) *TargetsHandler {
+	return &TargetsHandler{store: store}
+}
+
+// ListTargets lists the scrape targets of a service
+func (h *TargetsHandler) ListTargets(w http.ResponseWriter, r *http.Request) {
+	idStr := r.Context().Value("id").(string)
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID format", err)
+		return
+	}
+
+	targets, err := h.store.ListScrapeTargets(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list scrape targets", err)
+		return
+	}
+
+	respond(w, map[string]any{"targets": targets})
+}
+
+// CreateTarget registers a scrape target for a service
+func (h *TargetsHandler) CreateTarget(w http.ResponseWriter, r *http.Request) {
+	idStr := r.Context().Value("id").(string)
+	serviceID, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid service ID format", err)
+		return
+	}
+
+	var req CreateScrapeTargetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON format", err)
+		return
+	}
+
+	if msg := validateScrapeTarget(&req); msg != "" {
+		respondError(w, http.StatusBadRequest, msg, nil)
+		return
+	}
+
+	target := &models.ScrapeTarget{
+		ServiceID:   serviceID,
+		Environment: req.Environment,
+		Address:     req.Address,
+		MetricsPath: req.MetricsPath,
+		Labels:      req.Labels,
+	}
+
+	if err := h.store.CreateScrapeTarget(r.Context(), target); err != nil {
+		// Check for specific database errors
+		if strings.Contains(err.Error(), "duplicate key") {
+			respondError(w, http.StatusConflict, "Scrape target already exists for this service", err)
+		} else if strings.Contains(err.Error(), "foreign key") {
+			respondError(w, http.StatusNotFound, "Service not found", nil)
+		} else {
+			respondError(w, http.StatusInternalServerError, "Failed to create scrape target", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(target)
+}
+
+// DeleteTarget removes a scrape target from a service
+func (h *TargetsHandler) DeleteTarget(w http.ResponseWriter, r *http.Request) {
+	idStr := r.Context().Value("id").(string)
+	serviceID, err := uuid.Parse(idStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid service ID format", err)
+		return
+	}
+	targetID, err := uuid.Parse(chi.URLParam(r, "targetID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid target ID format", err)
+		return
+	}
+
+	deleted, err := h.store.DeleteScrapeTarget(r.Context(), serviceID, targetID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete scrape target", err)
+		return
+	}
+	if !deleted {
+		respondError(w, http.StatusNotFound, "Scrape target not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PrometheusSD serves every registered scrape target in Prometheus' http_sd_config format
+func (h *TargetsHandler) PrometheusSD(w http.ResponseWriter, r *http.Request) {
+	targets, err := h.store.ListDiscoveryTargets(r.Context(), r.URL.Query().Get("environment"), "")
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list scrape targets", err)
+		return
+	}
+
+	respond(w, prometheusTargetGroups(targets))
+}
+
+// prometheusTargetGroups groups targets that share the same label set into one target group
+func prometheusTargetGroups(targets []models.DiscoveryTarget) []PrometheusTargetGroup {
+	groups := []PrometheusTargetGroup{}
+	index := map[string]int{}
+	for _, t := range targets {
+		labels := make(map[string]string, len(t.Labels)+5)
+		for k, v := range t.Labels {
+			labels[k] = v
+		}
+		labels["__metrics_path__"] = t.MetricsPath
+		labels["catalog_service"] = t.ServiceName
+		labels["catalog_version"] = t.LatestVersion
+		labels["catalog_team"] = t.Team
+		labels["catalog_environment"] = t.Environment
+
+		key := labelSetKey(labels)
+		if i, ok := index[key]; ok {
+			groups[i].Targets = append(groups[i].Targets, t.Address)
+			continue
+		}
+		index[key] = len(groups)
+		groups = append(groups, PrometheusTargetGroup{Targets: []string{t.Address}, Labels: labels})
+	}
+	return groups
+}
+
+// labelSetKey returns a canonical string for a label set
+func labelSetKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s=%q,", k, labels[k])
+	}
+	return b.String()
+}
+
+// validateScrapeTarget validates a scrape target request, returning an error message or ""
+func validateScrapeTarget(req *CreateScrapeTargetRequest) string {
+	if req.Environment == "" {
+		return "Environment is required"
+	}
+	if len(req.Environment) > 50 {
+		return "Environment too long (max 50 characters)"
+	}
+
+	host, port, err := net.SplitHostPort(req.Address)
+	if err != nil || host == "" {
+		return "Address must be in host:port format"
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return "Address port must be between 1 and 65535"
+	}
+
+	if req.MetricsPath != "" && !strings.HasPrefix(req.MetricsPath, "/") {
+		return "Metrics path must start with /"
+	}
+
+	for name := range req.Labels {
+		if !labelNameRe.MatchString(name) {
+			return fmt.Sprintf("Invalid label name %q", name)
+		}
+		if strings.HasPrefix(name, "__") || strings.HasPrefix(name, "catalog_") {
+			return fmt.Sprintf("Label name %q is reserved", name)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"kong/pkg/cursor"
+	"kong/pkg/models"
+	"kong/pkg/osv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// VulnerabilityReportItem is a single finding of the catalog-wide vulnerability report
+type VulnerabilityReportItem struct {
+	osv.Vulnerability
+	ServiceID   uuid.UUID `json:"service_id"`
+	ServiceName string    `json:"service_name"`
+	VersionID   uuid.UUID `json:"version_id"`
+	Version     string    `json:"version"`
+}
+
+// VulnerabilitiesHandler handles vulnerability endpoints backed by the offline OSV database
+type VulnerabilitiesHandler struct {
+	store   *models.Store
+	db      *osv.DB
+	cursors *cursor.Codec
+}
+
+// NewVulnerabilitiesHandler creates a new vulnerabilities handler. db may be nil when
+// no advisory directory is configured; report cursors are signed by cursors.
+func NewVulnerabilitiesHandler(store *models.Store, db *osv.DB, cursors *cursor.Codec) *VulnerabilitiesHandler {
+	return &VulnerabilitiesHandler{store: store, db: db, cursors: cursors}
+}
+
+// VersionVulnerabilities lists the advisories affecting the components of a service version
+func (h *VulnerabilitiesHandler) VersionVulnerabilities(w http.ResponseWriter, r *http.Request) {
+	if !h.available(w) {
+		return
+	}
+	version, ok := resolveServiceVersion(h.store, w, r)
+	if !ok {
+		return
+	}
+
+	components, err := h.store.ListVersionComponents(r.Context(), version.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list components", err)
+		return
+	}
+
+	vulns := []osv.Vulnerability{}
+	for _, c := range components {
+		vulns = append(vulns, h.db.Match(c.PURL)...)
+	}
+	osv.SortVulnerabilities(vulns)
+
+	respond(w, map[string]any{
+		"service_id":      version.ServiceID,
+		"version":         version.Version,
+		"components":      len(components),
+		"vulnerabilities": vulns,
+	})
+}
+
+// Report lists the vulnerable components of a page of services, most severe first
+func (h *VulnerabilitiesHandler) Report(w http.ResponseWriter, r *http.Request) {
+	if !h.available(w) {
+		return
+	}
+	latestOnly := r.URL.Query().Get("latest_only") == "true"
+	minRank := osv.SeverityRank(strings.ToUpper(r.URL.Query().Get("min_severity")))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	var at *models.Cursor
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		var err error
+		if at, err = decodeCursor(h.cursors, token, "name"); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid cursor", err)
+			return
+		}
+	}
+
+	page, err := h.store.ListCatalogComponentsPage(r.Context(), latestOnly, limit, at)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list components", err)
+		return
+	}
+
+	items := []VulnerabilityReportItem{}
+	for _, u := range page.Items {
+		for _, v := range h.db.Match(u.Component.PURL) {
+			if osv.SeverityRank(v.Severity) < minRank {
+				continue
+			}
+			items = append(items, VulnerabilityReportItem{
+				Vulnerability: v,
+				ServiceID:     u.ServiceID,
+				ServiceName:   u.ServiceName,
+				VersionID:     u.VersionID,
+				Version:       u.Version,
+			})
+		}
+	}
+	sortReport(items)
+
+	count, loadedAt := h.db.Stats()
+	respond(w, map[string]any{
+		"advisories":           count,
+		"advisories_loaded_at": loadedAt.UTC().Format(time.RFC3339),
+		"items":                items,
+		"next_cursor":          encodeCursor(h.cursors, page.Next),
+		"prev_cursor":          encodeCursor(h.cursors, page.Prev),
+	})
+}
+
+// sortReport orders findings by severity, then score, then service, version and advisory
+func sortReport(items []VulnerabilityReportItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		ra, rb := osv.SeverityRank(a.Severity), osv.SeverityRank(b.Severity)
+		if ra != rb {
+			return ra > rb
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.ServiceName != b.ServiceName {
+			return a.ServiceName < b.ServiceName
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		return a.ID < b.ID
+	})
+}
+
+// available writes 503 when no advisory database is configured
+func (h *VulnerabilitiesHandler) available(w http.ResponseWriter) bool {
+	if h.db == nil {
+		respondError(w, http.StatusServiceUnavailable, "Vulnerability database not configured", nil)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"fmt"
+	"kong/pkg/models"
+	"kong/pkg/watch"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WatchHandler streams catalog changes as Server-Sent Events
+type WatchHandler struct {
+	store     *models.Store
+	hub       *watch.Hub
+	heartbeat time.Duration
+}
+
+// NewWatchHandler creates a new watch handler sending a heartbeat comment every heartbeat interval
+func NewWatchHandler(store *models.Store, hub *watch.Hub, heartbeat time.Duration) *WatchHandler {
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	return &WatchHandler{store: store, hub: hub, heartbeat: heartbeat}
+}
+
+// Watch streams change events after the Last-Event-ID header (or last_event_id parameter), or
+// only new changes when neither is given, filtered by service_id and label=key=value
+func (h *WatchHandler) Watch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	var f models.ChangeFilter
+	for _, s := range q["service_id"] {
+		id, _ := uuid.Parse(s)
+		f.ServiceIDs = append(f.ServiceIDs, id)
+	}
+	for _, s := range q["label"] {
+		if f.Labels == nil {
+			f.Labels = map[string]string{}
+		}
+		k, v, _ := strings.Cut(s, "=")
+		f.Labels[k] = v
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = q.Get("last_event_id")
+	}
+	if lastEventID != "" {
+		f.AfterID, _ = strconv.ParseInt(lastEventID, 10, 64)
+	} else {
+		latest, err := h.store.LatestOutboxEventID(ctx)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to read change log", err)
+			return
+		}
+		f.AfterID = latest
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", time.Second.Milliseconds())
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	send := func(events []models.OutboxEvent) error {
+		for _, e := range events {
+			data, err := models.MarshalEvent(e)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+		}
+		return rc.Flush()
+	}
+	heartbeat := func() error {
+		fmt.Fprint(w, ": heartbeat\n\n")
+		return rc.Flush()
+	}
+	// The stream ends on errors; the client reconnects and resumes from the last event it received
+	_ = h.hub.Follow(ctx, h.store, f, h.heartbeat, send, heartbeat)
+}
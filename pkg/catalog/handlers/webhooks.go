@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"kong/pkg/models"
+	"kong/pkg/netguard"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// CreateWebhookRequest represents the data needed to subscribe to change events
+type CreateWebhookRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+	Format     string   `json:"format"`
+}
+
+// CreateWebhookResponse is a new subscription including its secret, which is only returned once
+type CreateWebhookResponse struct {
+	models.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// WebhooksHandler handles webhook subscriptions and their deliveries
+type WebhooksHandler struct {
+	store        *models.Store
+	allowPrivate bool
+}
+
+// NewWebhooksHandler creates a new webhooks handler. Unless allowPrivate is set, webhook URLs on
+// loopback, link-local and private addresses are rejected.
+func NewWebhooksHandler(store *models.Store, allowPrivate bool) *WebhooksHandler {
+	return &WebhooksHandler{store: store, allowPrivate: allowPrivate}
+}
+
+// ListWebhooks lists the webhook subscriptions
+func (h *WebhooksHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.store.ListWebhookSubscriptions(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list webhooks", err)
+		return
+	}
+
+	respond(w, map[string]any{"webhooks": subs})
+}
+
+// CreateWebhook subscribes a URL to change events. A secret is generated when none is given.
+func (h *WebhooksHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid JSON format", err)
+		return
+	}
+
+	if msg := validateWebhook(&req, h.allowPrivate); msg != "" {
+		respondError(w, http.StatusBadRequest, msg, nil)
+		return
+	}
+	if req.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to generate secret", err)
+			return
+		}
+		req.Secret = hex.EncodeToString(b)
+	}
+
+	sub := &models.WebhookSubscription{
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: req.EventTypes,
+		Format:     req.Format,
+	}
+
+	if err := h.store.CreateWebhookSubscription(r.Context(), sub); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create webhook", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateWebhookResponse{WebhookSubscription: *sub, Secret: sub.Secret})
+}
+
+// DeleteWebhook removes a webhook subscription and its deliveries
+func (h *WebhooksHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid webhook ID format", err)
+		return
+	}
+
+	deleted, err := h.store.DeleteWebhookSubscription(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete webhook", err)
+		return
+	}
+	if !deleted {
+		respondError(w, http.StatusNotFound, "Webhook not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries lists the most recent deliveries of a webhook, optionally filtered by status
+func (h *WebhooksHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid webhook ID format", err)
+		return
+	}
+	var limit int
+	if s := r.URL.Query().Get("limit"); s != "" {
+		limit, _ = strconv.Atoi(s)
+	}
+
+	deliveries, err := h.store.ListWebhookDeliveries(r.Context(), id, r.URL.Query().Get("status"), limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list webhook deliveries", err)
+		return
+	}
+
+	respond(w, map[string]any{"deliveries": deliveries})
+}
+
+// Redeliver queues a delivery to be sent again with a fresh retry budget, typically after it went dead
+func (h *WebhooksHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid webhook ID format", err)
+		return
+	}
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid delivery ID format", err)
+		return
+	}
+
+	delivery, err := h.store.RedeliverWebhook(r.Context(), id, deliveryID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to redeliver webhook", err)
+		return
+	}
+	if delivery == nil {
+		respondError(w, http.StatusNotFound, "Delivery not found", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// validateWebhook validates a webhook subscription request, returning an error message or ""
+func validateWebhook(req *CreateWebhookRequest, allowPrivate bool) string {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "URL must be an absolute http or https URL"
+	}
+	if len(req.URL) > 2000 {
+		return "URL too long (max 2000 characters)"
+	}
+	if !allowPrivate && netguard.CheckURL(u) != nil {
+		return "URL must not point to a loopback, link-local or private address"
+	}
+	if req.Secret != "" && (len(req.Secret) < 16 || len(req.Secret) > 200) {
+		return "Secret must be between 16 and 200 characters"
+	}
+	for _, t := range req.EventTypes {
+		if !slices.Contains(models.EventTypes, t) {
+			return "Unknown event type: " + t
+		}
+	}
+	if req.Format != "" && !slices.Contains(models.WebhookFormats, req.Format) {
+		return "Format must be one of: " + strings.Join(models.WebhookFormats, ", ")
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+
+	"kong/pkg/models"
+)
+
+// AuditMiddleware attaches the caller's identity, request ID and client IP to the request
+// context, so writes made while handling the request are audited with them
+func AuditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := models.AuditInfo{
+			Actor:     APIKeyIdentity(r.Header.Get("X-API-Key")),
+			RequestID: GetRequestID(r.Context()),
+			ClientIP:  clientIP(r),
+		}
+		next.ServeHTTP(w, r.WithContext(models.WithAuditInfo(r.Context(), info)))
+	})
+}
+
+// APIKeyIdentity returns a stable identifier for an API key that doesn't reveal the key
+func APIKeyIdentity(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return "apikey:" + hex.EncodeToString(sum[:6])
+}
+
+// clientIP returns the address of the peer. Forwarding headers are ignored since they can be forged.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
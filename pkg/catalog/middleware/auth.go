@@ -8,8 +8,9 @@ import (
 func APIKeyMiddleware(validAPIKeys []string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Skip authentication for health check endpoints
-			if r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+			// Skip authentication for health check endpoints and the GraphiQL page, which sends
+			// the key itself with every query
+			if r.URL.Path == "/healthz" || r.URL.Path == "/readyz" || r.URL.Path == "/graphiql" {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -125,6 +125,11 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to flush event streams
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // GetLogger extracts logger from context
 func GetLogger(ctx context.Context) RequestLogger {
 	if logger, ok := ctx.Value(LoggerKey{}).(RequestLogger); ok {
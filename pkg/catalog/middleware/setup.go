@@ -16,6 +16,9 @@ func SetupGlobalMiddleware(r *chi.Mux, validAPIKeys []string) {
 
 	// 3. Authentication middleware - validates API keys (skips health checks)
 	r.Use(APIKeyMiddleware(validAPIKeys))
+
+	// 4. Audit middleware - identifies the caller for the audit log of writes
+	r.Use(AuditMiddleware)
 }
 
 // SetupRouteSpecificMiddleware applies middleware to specific routes
@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"fmt"
+	"encoding/json"
 	"net/http"
 
 	"kong/pkg/catalog/validation"
@@ -24,36 +24,21 @@ func ValidationMiddleware(validator func(*http.Request) error) func(http.Handler
 
 // handleValidationError handles validation errors and returns appropriate HTTP response
 func handleValidationError(w http.ResponseWriter, err error) {
-	if validationErr, ok := err.(validation.ValidationErrors); ok {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		// Convert validation errors to our format
-		errors := make([]map[string]string, len(validationErr.Errors))
-		for i, ve := range validationErr.Errors {
-			errors[i] = map[string]string{
-				"field":   ve.Field,
-				"message": ve.Message,
-			}
-		}
-		// Simple JSON encoding for now
-		fmt.Fprintf(w, `{"error":"Validation failed","errors":[`)
-		for i, err := range errors {
-			if i > 0 {
-				fmt.Fprint(w, ",")
-			}
-			fmt.Fprintf(w, `{"field":"%s","message":"%s"}`, err["field"], err["message"])
-		}
-		fmt.Fprint(w, "]}")
+	var errors []validation.ValidationError
+	switch ve := err.(type) {
+	case validation.ValidationErrors:
+		errors = ve.Errors
+	case validation.ValidationError:
+		errors = []validation.ValidationError{ve}
+	default:
+		// Fallback for other errors
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	if validationErr, ok := err.(validation.ValidationError); ok {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, `{"error":"Validation failed","errors":[{"field":"%s","message":"%s"}]}`,
-			validationErr.Field, validationErr.Message)
-		return
-	}
-
-	// Fallback for other errors
-	http.Error(w, err.Error(), http.StatusBadRequest)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(struct {
+		Error  string                       `json:"error"`
+		Errors []validation.ValidationError `json:"errors"`
+	}{"Validation failed", errors})
 }
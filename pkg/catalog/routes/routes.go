@@ -5,26 +5,88 @@ import (
 	"kong/pkg/catalog/handlers"
 	"kong/pkg/catalog/middleware"
 	"kong/pkg/catalog/validation"
+	"kong/pkg/catalog/writes"
+	"kong/pkg/cursor"
+	"kong/pkg/graphqlapi"
 	"kong/pkg/models"
+	"kong/pkg/osv"
+	"kong/pkg/scorecard"
+	"kong/pkg/watch"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
 
+// Dependencies holds the components route handlers are built from
+type Dependencies struct {
+	Store      *models.Store
+	VulnDB     *osv.DB // nil when no advisory directory is configured
+	Scorecards *scorecard.Evaluator
+	// Writes verifies version signatures and rescores services after writes
+	Writes *writes.Hooks
+	// Cursors signs pagination cursors, list totals past CountEstimateAbove services are estimated
+	Cursors            *cursor.Codec
+	CountEstimateAbove int64
+	StaleAfter         time.Duration
+	StatsTTL           time.Duration
+	// AllowPrivateTargets lets health-check and webhook URLs point to loopback, link-local and private addresses
+	AllowPrivateTargets bool
+	// SuggestTimeout is the latency budget of service name suggestions
+	SuggestTimeout time.Duration
+	// EventSource is the CloudEvents source of change events
+	EventSource string
+	// Changes wakes change stream watchers, WatchHeartbeat is the interval of their heartbeats
+	Changes        *watch.Hub
+	WatchHeartbeat time.Duration
+	// GraphQL serves /graphql, GraphiQL additionally serves the GraphiQL IDE at /graphiql
+	GraphQL  *graphqlapi.Handler
+	GraphiQL bool
+}
+
 // SetupRoutes configures all the routes with middleware
-func SetupRoutes(store *models.Store, r *chi.Mux) {
+func SetupRoutes(deps Dependencies, r *chi.Mux) {
+	store := deps.Store
+
 	// Health checks (no validation needed)
 	healthHandler := handlers.NewHealthHandler(store)
 	r.Get("/healthz", healthHandler.HealthCheck)
 	r.Get("/readyz", healthHandler.ReadinessCheck)
 
+	// GraphQL API over services and versions
+	r.Get("/graphql", deps.GraphQL.ServeHTTP)
+	r.Post("/graphql", deps.GraphQL.ServeHTTP)
+	if deps.GraphiQL {
+		r.Get("/graphiql", graphqlapi.GraphiQL)
+	}
+
 	// API routes with validation middleware
-	servicesHandler := handlers.NewServicesHandler(store)
+	servicesHandler := handlers.NewServicesHandler(store, deps.Writes, deps.Cursors, deps.CountEstimateAbove)
+	targetsHandler := handlers.NewTargetsHandler(store)
+	signingKeysHandler := handlers.NewSigningKeysHandler(store)
+	componentsHandler := handlers.NewComponentsHandler(store)
+	vulnerabilitiesHandler := handlers.NewVulnerabilitiesHandler(store, deps.VulnDB, deps.Cursors)
+	scorecardsHandler := handlers.NewScorecardsHandler(store, deps.Scorecards)
+	slosHandler := handlers.NewSLOsHandler(store)
+	serviceHealthHandler := handlers.NewServiceHealthHandler(store, deps.AllowPrivateTargets)
+	reportsHandler := handlers.NewReportsHandler(store, deps.StaleAfter)
+	statsHandler := handlers.NewStatsHandler(store, deps.StatsTTL)
+	auditHandler := handlers.NewAuditHandler(store)
+	webhooksHandler := handlers.NewWebhooksHandler(store, deps.AllowPrivateTargets)
+	eventsHandler := handlers.NewEventsHandler(store, deps.EventSource)
+	watchHandler := handlers.NewWatchHandler(store, deps.Changes, deps.WatchHeartbeat)
+	suggestHandler := handlers.NewSuggestHandler(store, deps.SuggestTimeout)
 
 	r.Route("/v1", func(r chi.Router) {
 		// List services with validation
-		r.With(middleware.ValidationMiddleware(validation.ValidateListServicesParams)).
+		r.With(middleware.ValidationMiddleware(validation.ValidateListServicesParams(store.MaxPageSize()))).
 			Get("/services", servicesHandler.ListServices)
+		r.With(middleware.ValidationMiddleware(validation.ValidateListServicesParams(store.MaxPageSize()))).
+			Head("/services", servicesHandler.CountServices)
+
+		// Typo-tolerant service name autocompletion
+		r.With(middleware.ValidationMiddleware(validation.ValidateSuggestParams)).
+			Get("/services/suggest", suggestHandler.Suggest)
 
 		// Get service by ID with validation
 		r.With(middleware.ValidationMiddleware(func(r *http.Request) error {
@@ -42,6 +104,15 @@ func SetupRoutes(store *models.Store, r *chi.Mux) {
 			return validation.ValidateGetServiceParams(r)
 		})).Get("/services/{id}", servicesHandler.GetService)
 
+		// Update service and its revision history
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			With(middleware.ValidationMiddleware(validation.ValidateJSONContentType)).
+			Patch("/services/{id}", servicesHandler.UpdateService)
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			Get("/services/{id}/revisions", servicesHandler.ListRevisions)
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			Post("/services/{id}/revisions/{revision}/revert", servicesHandler.RevertService)
+
 		// List versions with ID validation
 		r.With(middleware.ValidationMiddleware(func(r *http.Request) error {
 			// Extract ID from URL parameter and validate
@@ -53,7 +124,12 @@ func SetupRoutes(store *models.Store, r *chi.Mux) {
 			ctx := context.WithValue(r.Context(), "id", id)
 			*r = *r.WithContext(ctx)
 			return nil
-		})).Get("/services/{id}/versions", servicesHandler.ListVersions)
+		})).With(middleware.ValidationMiddleware(validation.ValidateListVersionsParams(store.MaxPageSize()))).
+			Get("/services/{id}/versions", servicesHandler.ListVersions)
+
+		// List dependencies with ID validation
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			Get("/services/{id}/dependencies", servicesHandler.ListDependencies)
 
 		// Create service with validation
 		r.With(middleware.ValidationMiddleware(validation.ValidateCreateServiceParams)).
@@ -72,5 +148,116 @@ func SetupRoutes(store *models.Store, r *chi.Mux) {
 			return nil
 		})).With(middleware.ValidationMiddleware(validation.ValidateCreateServiceVersionParams)).
 			Post("/services/{id}/versions", servicesHandler.CreateServiceVersion)
+
+		// Trusted keys for version signatures
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			Get("/services/{id}/signing-keys", signingKeysHandler.ListSigningKeys)
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			With(middleware.ValidationMiddleware(validation.ValidateJSONContentType)).
+			Post("/services/{id}/signing-keys", signingKeysHandler.CreateSigningKey)
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			Delete("/services/{id}/signing-keys/{keyID}", signingKeysHandler.DeleteSigningKey)
+
+		// Scrape targets with ID validation
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			Get("/services/{id}/targets", targetsHandler.ListTargets)
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			With(middleware.ValidationMiddleware(validation.ValidateJSONContentType)).
+			Post("/services/{id}/targets", targetsHandler.CreateTarget)
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			Delete("/services/{id}/targets/{targetID}", targetsHandler.DeleteTarget)
+
+		// SBOMs and components per version
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			Put("/services/{id}/versions/{version}/sbom", componentsHandler.UploadSBOM)
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			Get("/services/{id}/versions/{version}/components", componentsHandler.ListVersionComponents)
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			With(middleware.ValidationMiddleware(validation.ValidateJSONContentType)).
+			Put("/services/{id}/versions/{version}/packages", componentsHandler.UploadPackages)
+		r.With(middleware.ValidationMiddleware(validation.ValidateFindComponentsParams)).
+			Get("/components", componentsHandler.FindComponents)
+
+		// Vulnerabilities from the offline OSV advisory mirror
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			Get("/services/{id}/versions/{version}/vulnerabilities", vulnerabilitiesHandler.VersionVulnerabilities)
+		r.With(middleware.ValidationMiddleware(validation.ValidateVulnerabilityReportParams(store.MaxPageSize()))).
+			Get("/reports/vulnerabilities", vulnerabilitiesHandler.Report)
+		r.With(middleware.ValidationMiddleware(validation.ValidateStaleReportParams)).
+			Get("/reports/stale", reportsHandler.Stale)
+
+		// Maturity scorecards
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			Get("/services/{id}/scorecard", scorecardsHandler.GetScorecard)
+		r.Get("/scorecards/leaderboard", scorecardsHandler.Leaderboard)
+
+		// Actively probed health
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			With(middleware.ValidationMiddleware(validation.ValidateServiceHealthParams)).
+			Get("/services/{id}/health", serviceHealthHandler.GetHealth)
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			With(middleware.ValidationMiddleware(validation.ValidateJSONContentType)).
+			Post("/services/{id}/health/checks", serviceHealthHandler.CreateHealthCheck)
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			Delete("/services/{id}/health/checks/{checkID}", serviceHealthHandler.DeleteHealthCheck)
+
+		// Service level objectives
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			Get("/services/{id}/slos", slosHandler.ListSLOs)
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			With(middleware.ValidationMiddleware(validation.ValidateJSONContentType)).
+			Post("/services/{id}/slos", slosHandler.CreateSLO)
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			Get("/services/{id}/slos/{sloID}", slosHandler.GetSLO)
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			Delete("/services/{id}/slos/{sloID}", slosHandler.DeleteSLO)
+		r.With(middleware.ValidationMiddleware(validateServiceID)).
+			With(middleware.ValidationMiddleware(validation.ValidateJSONContentType)).
+			Post("/services/{id}/slos/{sloID}/budget", slosHandler.ErrorBudget)
+		r.Get("/slos/prometheus-rules", slosHandler.PrometheusRules)
+
+		// Catalog statistics for dashboards
+		r.With(middleware.ValidationMiddleware(validation.ValidateStatsParams)).
+			Get("/stats", statsHandler.GetStats)
+
+		// Audit log of writes
+		r.With(middleware.ValidationMiddleware(validation.ValidateAuditParams(store.MaxPageSize()))).
+			Get("/audit", auditHandler.ListEvents)
+		r.Get("/audit/verify", auditHandler.Verify)
+
+		// Webhook subscriptions to change events
+		r.Get("/webhooks", webhooksHandler.ListWebhooks)
+		r.With(middleware.ValidationMiddleware(validation.ValidateJSONContentType)).
+			Post("/webhooks", webhooksHandler.CreateWebhook)
+		r.Delete("/webhooks/{webhookID}", webhooksHandler.DeleteWebhook)
+		r.With(middleware.ValidationMiddleware(validation.ValidateWebhookDeliveriesParams(store.MaxPageSize()))).
+			Get("/webhooks/{webhookID}/deliveries", webhooksHandler.ListDeliveries)
+		r.Post("/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver", webhooksHandler.Redeliver)
+
+		// Change events as CloudEvents, and the schemas of their data
+		r.With(middleware.ValidationMiddleware(validation.ValidateListEventsParams(store.MaxPageSize()))).
+			Get("/events", eventsHandler.ListEvents)
+		r.Get("/events/types", eventsHandler.ListTypes)
+		r.Get("/events/schemas/{type}", eventsHandler.GetSchema)
+		r.Get("/events/{eventID}", eventsHandler.GetEvent)
+
+		// Live change stream (Server-Sent Events)
+		r.With(middleware.ValidationMiddleware(validation.ValidateWatchParams)).
+			Get("/watch", watchHandler.Watch)
+
+		// Prometheus HTTP service discovery
+		r.With(middleware.ValidationMiddleware(validation.ValidateServiceDiscoveryParams)).
+			Get("/sd/prometheus", targetsHandler.PrometheusSD)
 	})
 }
+
+// validateServiceID validates the {id} URL parameter and stores it in the context for handlers
+func validateServiceID(r *http.Request) error {
+	id := chi.URLParam(r, "id")
+	if err := validation.ValidateID(id); err != nil {
+		return err
+	}
+	ctx := context.WithValue(r.Context(), "id", id)
+	*r = *r.WithContext(ctx)
+	return nil
+}
@@ -0,0 +1,120 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+
+	"kong/pkg/signing"
+)
+
+// CreateServiceRequest represents the data needed to create a service
+type CreateServiceRequest struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Team        string            `json:"team"`
+	Labels      map[string]string `json:"labels"`
+}
+
+// UpdateServiceRequest represents a partial update of a service; omitted fields are left unchanged
+// and labels, when present, replace all labels
+type UpdateServiceRequest struct {
+	Name        *string           `json:"name"`
+	Description *string           `json:"description"`
+	Team        *string           `json:"team"`
+	Labels      map[string]string `json:"labels"`
+}
+
+// CreateServiceVersionRequest represents the data needed to create a service version
+type CreateServiceVersionRequest struct {
+	Version         string   `json:"version"`
+	Commit          string   `json:"commit"`
+	ArtifactDigests []string `json:"artifact_digests"`
+	// Signature is a base64 detached signature over the canonical signing.Payload
+	Signature string `json:"signature"`
+}
+
+// labelKeyRe matches valid service label keys
+var labelKeyRe = regexp.MustCompile(`^[a-z0-9]([a-z0-9._/-]{0,61}[a-z0-9])?$`)
+
+// ValidateCreateService validates a new service, returning an error message or ""
+func ValidateCreateService(req *CreateServiceRequest) string {
+	if req.Name == "" {
+		return "Name is required"
+	}
+	if len(req.Name) > 100 {
+		return "Name too long (max 100 characters)"
+	}
+	if len(req.Description) > 1000 {
+		return "Description too long (max 1000 characters)"
+	}
+	if len(req.Team) > 100 {
+		return "Team too long (max 100 characters)"
+	}
+	return validateLabels(req.Labels)
+}
+
+// ValidateCreateServiceVersion validates a new service version, returning an error message or ""
+func ValidateCreateServiceVersion(req *CreateServiceVersionRequest) string {
+	if req.Version == "" {
+		return "Version is required"
+	}
+	if len(req.Version) > 50 {
+		return "Version too long (max 50 characters)"
+	}
+	return validateSignedFields(req)
+}
+
+// validateLabels validates service labels, returning an error message or ""
+func validateLabels(labels map[string]string) string {
+	if len(labels) > 50 {
+		return "Too many labels (max 50)"
+	}
+	for k, v := range labels {
+		if !labelKeyRe.MatchString(k) {
+			return fmt.Sprintf("Invalid label key %q (lowercase alphanumerics, '.', '_', '-' and '/', max 63 characters)", k)
+		}
+		if len(v) > 255 {
+			return fmt.Sprintf("Label %q value too long (max 255 characters)", k)
+		}
+	}
+	return ""
+}
+
+// ValidateServiceUpdate validates a service update, returning an error message or ""
+func ValidateServiceUpdate(req *UpdateServiceRequest) string {
+	if req.Name == nil && req.Description == nil && req.Team == nil && req.Labels == nil {
+		return "No fields to update"
+	}
+	if req.Name != nil && *req.Name == "" {
+		return "Name must not be empty"
+	}
+	if req.Name != nil && len(*req.Name) > 100 {
+		return "Name too long (max 100 characters)"
+	}
+	if req.Description != nil && len(*req.Description) > 1000 {
+		return "Description too long (max 1000 characters)"
+	}
+	if req.Team != nil && len(*req.Team) > 100 {
+		return "Team too long (max 100 characters)"
+	}
+	return validateLabels(req.Labels)
+}
+
+// validateSignedFields validates the signed fields of a version request, returning an error message or ""
+func validateSignedFields(req *CreateServiceVersionRequest) string {
+	if len(req.Commit) > 100 {
+		return "Commit too long (max 100 characters)"
+	}
+	if len(req.ArtifactDigests) > 100 {
+		return "Too many artifact digests (max 100)"
+	}
+	for _, d := range req.ArtifactDigests {
+		if !signing.ValidDigest(d) {
+			return fmt.Sprintf("Invalid artifact digest %q (expected sha256:<hex> or sha512:<hex>)", d)
+		}
+	}
+	if len(req.Signature) > 1024 {
+		return "Signature too long (max 1024 characters)"
+	}
+	return ""
+}
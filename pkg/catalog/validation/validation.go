@@ -1,18 +1,29 @@
 package validation
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+
+	"kong/pkg/cursor"
+	"kong/pkg/filter"
+	"kong/pkg/models"
 )
 
 // Validation errors
 type ValidationError struct {
 	Field   string `json:"field"`
 	Message string `json:"message"`
+	// Column is the position (1-based) of the error in a filter expression
+	Column int `json:"column,omitempty"`
 }
 
 type ValidationErrors struct {
@@ -31,17 +42,49 @@ func (ve ValidationError) Error() string {
 	return fmt.Sprintf("%s: %s", ve.Field, ve.Message)
 }
 
-// ValidateListServicesParams validates parameters for listServices endpoint
-func ValidateListServicesParams(r *http.Request) error {
+// filterKey is the context key of the filter expression of a validated request
+type filterKey struct{}
+
+// ServiceFilter returns the filter expression ValidateListServicesParams parsed, nil when the
+// request has no filter
+func ServiceFilter(ctx context.Context) filter.Expr {
+	expr, _ := ctx.Value(filterKey{}).(filter.Expr)
+	return expr
+}
+
+// ValidateListServicesParams returns the validator of listServices parameters, allowing limits
+// up to the maximum page size maxPage. The parsed filter is stored in the context for handlers
+// to use (ServiceFilter).
+func ValidateListServicesParams(maxPage int) func(*http.Request) error {
+	return func(r *http.Request) error {
+		expr, err := validateListServicesQuery(r.URL.Query(), maxPage)
+		if err != nil {
+			return err
+		}
+		if expr != nil {
+			*r = *r.WithContext(context.WithValue(r.Context(), filterKey{}, expr))
+		}
+		return nil
+	}
+}
+
+// ValidateListServicesQuery validates service listing parameters, also used by the gRPC API
+func ValidateListServicesQuery(query url.Values, maxPage int) error {
+	_, err := validateListServicesQuery(query, maxPage)
+	return err
+}
+
+// validateListServicesQuery validates service listing parameters, returning the parsed filter
+func validateListServicesQuery(query url.Values, maxPage int) (filter.Expr, error) {
 	var errors []ValidationError
 
 	// Validate limit
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+	if limitStr := query.Get("limit"); limitStr != "" {
 		limit, err := strconv.Atoi(limitStr)
-		if err != nil || limit <= 0 || limit > 1000 {
+		if err != nil || limit <= 0 || limit > maxPage {
 			errors = append(errors, ValidationError{
 				Field:   "limit",
-				Message: "must be a positive integer between 1 and 1000",
+				Message: fmt.Sprintf("must be a positive integer between 1 and %d", maxPage),
 			})
 		}
 		// Additional validation for limit parameter
@@ -54,7 +97,7 @@ func ValidateListServicesParams(r *http.Request) error {
 	}
 
 	// Validate offset
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+	if offsetStr := query.Get("offset"); offsetStr != "" {
 		offset, err := strconv.Atoi(offsetStr)
 		if err != nil || offset < 0 {
 			errors = append(errors, ValidationError{
@@ -64,9 +107,29 @@ func ValidateListServicesParams(r *http.Request) error {
 		}
 	}
 
+	// Validate cursor
+	if token := query.Get("cursor"); token != "" {
+		errors = append(errors, validateCursor(token)...)
+		if query.Has("offset") {
+			errors = append(errors, ValidationError{
+				Field:   "cursor",
+				Message: "can't be combined with offset",
+			})
+		}
+		if query.Get("sort") == "relevance" || (query.Get("sort") == "" && (query.Get("search") != "" || query.Get("fuzzy") != "")) {
+			errors = append(errors, ValidationError{
+				Field:   "cursor",
+				Message: "can't page results sorted by relevance",
+			})
+		}
+	}
+
 	// Validate sort
-	if sort := r.URL.Query().Get("sort"); sort != "" {
+	if sort := query.Get("sort"); sort != "" {
 		allowedSorts := []string{"name", "created_at", "updated_at"}
+		if query.Get("search") != "" || query.Get("fuzzy") != "" {
+			allowedSorts = append(allowedSorts, "relevance")
+		}
 		validSort := false
 		for _, allowed := range allowedSorts {
 			if sort == allowed {
@@ -83,7 +146,7 @@ func ValidateListServicesParams(r *http.Request) error {
 	}
 
 	// Validate order
-	if order := r.URL.Query().Get("order"); order != "" {
+	if order := query.Get("order"); order != "" {
 		if order != "asc" && order != "desc" {
 			errors = append(errors, ValidationError{
 				Field:   "order",
@@ -93,7 +156,7 @@ func ValidateListServicesParams(r *http.Request) error {
 	}
 
 	// Validate include_versions (boolean parameter)
-	if includeVersions := r.URL.Query().Get("include_versions"); includeVersions != "" {
+	if includeVersions := query.Get("include_versions"); includeVersions != "" {
 		if includeVersions != "true" && includeVersions != "false" {
 			errors = append(errors, ValidationError{
 				Field:   "include_versions",
@@ -103,7 +166,7 @@ func ValidateListServicesParams(r *http.Request) error {
 	}
 
 	// Validate query length and content
-	if q := r.URL.Query().Get("q"); q != "" {
+	if q := query.Get("q"); q != "" {
 		if len(q) < 1 {
 			errors = append(errors, ValidationError{
 				Field:   "q",
@@ -118,6 +181,92 @@ func ValidateListServicesParams(r *http.Request) error {
 		}
 	}
 
+	// Validate full-text search
+	if search := query.Get("search"); len(search) > 200 {
+		errors = append(errors, ValidationError{
+			Field:   "search",
+			Message: "search must be 200 characters or less",
+		})
+	}
+
+	// Validate fuzzy name search
+	if fuzzy := query.Get("fuzzy"); fuzzy != "" {
+		if len(fuzzy) > 100 {
+			errors = append(errors, ValidationError{
+				Field:   "fuzzy",
+				Message: "fuzzy search must be 100 characters or less",
+			})
+		}
+		if query.Get("search") != "" {
+			errors = append(errors, ValidationError{
+				Field:   "fuzzy",
+				Message: "can't be combined with search",
+			})
+		}
+	}
+
+	// Validate filter expression
+	var where filter.Expr
+	if query.Has("filter") {
+		var err error
+		if where, err = models.ParseServiceFilter(query.Get("filter")); err != nil {
+			errors = append(errors, filterError(err))
+		}
+	}
+
+	// Validate facets
+	if query.Has("facets") {
+		facets := strings.Split(query.Get("facets"), ",")
+		if len(facets) > 10 {
+			errors = append(errors, ValidationError{
+				Field:   "facets",
+				Message: "must list at most 10 facets",
+			})
+		}
+		for _, facet := range facets {
+			if !models.IsServiceFacet(facet) {
+				errors = append(errors, ValidationError{
+					Field:   "facets",
+					Message: fmt.Sprintf("unknown facet %q, must be one of: team, name_prefix, created_year, created_month, updated, versions, labels.<key>", facet),
+				})
+				break
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return nil, ValidationErrors{Errors: errors}
+	}
+	return where, nil
+}
+
+// filterError is the validation error of an invalid filter expression, with its position
+func filterError(err error) ValidationError {
+	verr := ValidationError{Field: "filter", Message: err.Error()}
+	var ferr *filter.Error
+	if errors.As(err, &ferr) {
+		verr.Message, verr.Column = ferr.Message, ferr.Column
+	}
+	return verr
+}
+
+// ValidateSuggestParams validates parameters for the service name suggestions endpoint
+func ValidateSuggestParams(r *http.Request) error {
+	var errors []ValidationError
+	q := r.URL.Query()
+
+	if prefix := q.Get("prefix"); prefix == "" {
+		errors = append(errors, ValidationError{Field: "prefix", Message: "is required"})
+	} else if len(prefix) > 100 {
+		errors = append(errors, ValidationError{Field: "prefix", Message: "must be 100 characters or less"})
+	}
+	if limitStr := q.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 25 {
+			errors = append(errors, ValidationError{Field: "limit", Message: "must be an integer between 1 and 25"})
+		}
+	}
+
 	if len(errors) > 0 {
 		return ValidationErrors{Errors: errors}
 	}
@@ -158,12 +307,56 @@ func ValidateGetServiceParams(r *http.Request) error {
 		}
 	}
 
+	// Validate as_of (point-in-time read)
+	if asOf := r.URL.Query().Get("as_of"); asOf != "" {
+		if _, err := time.Parse(time.RFC3339, asOf); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "as_of",
+				Message: "must be an RFC 3339 timestamp",
+			})
+		}
+	}
+
 	if len(errors) > 0 {
 		return ValidationErrors{Errors: errors}
 	}
 	return nil
 }
 
+// ValidateListVersionsParams returns the validator of listVersions parameters, allowing limits
+// up to the maximum page size maxPage
+func ValidateListVersionsParams(maxPage int) func(*http.Request) error {
+	return func(r *http.Request) error {
+		query := r.URL.Query()
+		var errors []ValidationError
+		if verified := query.Get("verified"); verified != "" {
+			if verified != "true" && verified != "false" {
+				errors = append(errors, ValidationError{Field: "verified", Message: "must be either 'true' or 'false'"})
+			}
+		}
+		if limitStr := query.Get("limit"); limitStr != "" {
+			if limit, err := strconv.Atoi(limitStr); err != nil || limit <= 0 || limit > maxPage {
+				errors = append(errors, ValidationError{Field: "limit", Message: fmt.Sprintf("must be a positive integer between 1 and %d", maxPage)})
+			}
+		}
+		if token := query.Get("cursor"); token != "" {
+			errors = append(errors, validateCursor(token)...)
+		}
+		if len(errors) > 0 {
+			return ValidationErrors{Errors: errors}
+		}
+		return nil
+	}
+}
+
+// validateCursor checks the shape of a cursor token; its signature is verified by the handler
+func validateCursor(token string) []ValidationError {
+	if len(token) > cursor.MaxLength {
+		return []ValidationError{{Field: "cursor", Message: fmt.Sprintf("must be %d characters or less", cursor.MaxLength)}}
+	}
+	return nil
+}
+
 // ValidateCreateServiceParams validates parameters for createService endpoint
 func ValidateCreateServiceParams(r *http.Request) error {
 	// For POST requests, we mainly validate Content-Type header
@@ -189,3 +382,326 @@ func ValidateCreateServiceVersionParams(r *http.Request) error {
 	}
 	return nil
 }
+
+// ValidateJSONContentType validates that request bodies, when typed, are JSON
+func ValidateJSONContentType(r *http.Request) error {
+	contentType := r.Header.Get("Content-Type")
+	if contentType != "" && !strings.Contains(contentType, "application/json") {
+		return ValidationError{
+			Field:   "Content-Type",
+			Message: "must be application/json",
+		}
+	}
+	return nil
+}
+
+// ValidateServiceDiscoveryParams validates parameters for the service discovery endpoints
+func ValidateServiceDiscoveryParams(r *http.Request) error {
+	if env := r.URL.Query().Get("environment"); len(env) > 50 {
+		return ValidationError{Field: "environment", Message: "must be 50 characters or less"}
+	}
+	return nil
+}
+
+// ValidateServiceHealthParams validates parameters for the service health endpoint
+func ValidateServiceHealthParams(r *http.Request) error {
+	var errors []ValidationError
+
+	if env := r.URL.Query().Get("environment"); len(env) > 50 {
+		errors = append(errors, ValidationError{Field: "environment", Message: "must be 50 characters or less"})
+	}
+	if limitStr := r.URL.Query().Get("history_limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 0 || limit > 1000 {
+			errors = append(errors, ValidationError{Field: "history_limit", Message: "must be an integer between 0 and 1000"})
+		}
+	}
+
+	if len(errors) > 0 {
+		return ValidationErrors{Errors: errors}
+	}
+	return nil
+}
+
+// ValidateStaleReportParams validates parameters for the stale service report endpoint
+func ValidateStaleReportParams(r *http.Request) error {
+	var errors []ValidationError
+
+	if daysStr := r.URL.Query().Get("older_than_days"); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil || days < 1 || days > 3650 {
+			errors = append(errors, ValidationError{Field: "older_than_days", Message: "must be an integer between 1 and 3650"})
+		}
+	}
+	if format := r.URL.Query().Get("format"); format != "" && format != "json" && format != "csv" {
+		errors = append(errors, ValidationError{Field: "format", Message: "must be either 'json' or 'csv'"})
+	}
+
+	if len(errors) > 0 {
+		return ValidationErrors{Errors: errors}
+	}
+	return nil
+}
+
+// ValidateStatsParams validates parameters for the catalog statistics endpoint
+func ValidateStatsParams(r *http.Request) error {
+	var errors []ValidationError
+	q := r.URL.Query()
+
+	parse := func(field string) (time.Time, bool) {
+		s := q.Get(field)
+		if s == "" {
+			return time.Time{}, false
+		}
+		t, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			if t, err = time.Parse(time.RFC3339, s); err != nil {
+				errors = append(errors, ValidationError{Field: field, Message: "must be a date (YYYY-MM-DD) or an RFC 3339 timestamp"})
+				return time.Time{}, false
+			}
+		}
+		return t, true
+	}
+	to, hasTo := parse("to")
+	if !hasTo {
+		to = time.Now()
+	}
+	from, hasFrom := parse("from")
+	if !hasFrom {
+		from = to.AddDate(0, 0, -30)
+	}
+
+	interval := q.Get("interval")
+	if interval != "" && interval != "day" && interval != "week" {
+		errors = append(errors, ValidationError{Field: "interval", Message: "must be either 'day' or 'week'"})
+	}
+	if !from.Before(to) {
+		errors = append(errors, ValidationError{Field: "from", Message: "must be before to"})
+	} else if maxRange := 366 * 24 * time.Hour; interval == "week" && to.Sub(from) > 10*maxRange {
+		errors = append(errors, ValidationError{Field: "from", Message: "range must be at most 10 years for weekly buckets"})
+	} else if interval != "week" && to.Sub(from) > maxRange {
+		errors = append(errors, ValidationError{Field: "from", Message: "range must be at most 366 days for daily buckets"})
+	}
+
+	if topStr := q.Get("top"); topStr != "" {
+		top, err := strconv.Atoi(topStr)
+		if err != nil || top < 1 || top > 100 {
+			errors = append(errors, ValidationError{Field: "top", Message: "must be an integer between 1 and 100"})
+		}
+	}
+
+	if len(errors) > 0 {
+		return ValidationErrors{Errors: errors}
+	}
+	return nil
+}
+
+// ValidateAuditParams validates parameters for the audit log endpoint; limit is at most maxPage
+func ValidateAuditParams(maxPage int) func(*http.Request) error {
+	return func(r *http.Request) error {
+		var errors []ValidationError
+		q := r.URL.Query()
+
+		if action := q.Get("action"); action != "" {
+			allowed := []string{"create", "update", "delete", "replace", "revert"}
+			if !slices.Contains(allowed, action) {
+				errors = append(errors, ValidationError{Field: "action", Message: fmt.Sprintf("must be one of: %s", strings.Join(allowed, ", "))})
+			}
+		}
+		for _, field := range []string{"actor", "resource_type", "resource_id"} {
+			if len(q.Get(field)) > 200 {
+				errors = append(errors, ValidationError{Field: field, Message: "must be 200 characters or less"})
+			}
+		}
+		var from, to time.Time
+		for _, field := range []string{"from", "to"} {
+			s := q.Get(field)
+			if s == "" {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				errors = append(errors, ValidationError{Field: field, Message: "must be an RFC 3339 timestamp"})
+				continue
+			}
+			if field == "from" {
+				from = t
+			} else {
+				to = t
+			}
+		}
+		if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+			errors = append(errors, ValidationError{Field: "from", Message: "must be before to"})
+		}
+		if s := q.Get("before_id"); s != "" {
+			if id, err := strconv.ParseInt(s, 10, 64); err != nil || id < 1 {
+				errors = append(errors, ValidationError{Field: "before_id", Message: "must be a positive integer"})
+			}
+		}
+		if s := q.Get("limit"); s != "" {
+			if limit, err := strconv.Atoi(s); err != nil || limit < 1 || limit > maxPage {
+				errors = append(errors, ValidationError{Field: "limit", Message: fmt.Sprintf("must be a positive integer between 1 and %d", maxPage)})
+			}
+		}
+
+		if len(errors) > 0 {
+			return ValidationErrors{Errors: errors}
+		}
+		return nil
+	}
+}
+
+// ValidateWebhookDeliveriesParams validates parameters for the webhook deliveries endpoint; limit is
+// at most maxPage
+func ValidateWebhookDeliveriesParams(maxPage int) func(*http.Request) error {
+	return func(r *http.Request) error {
+		var errors []ValidationError
+		q := r.URL.Query()
+
+		if status := q.Get("status"); status != "" {
+			allowed := []string{"pending", "delivered", "dead"}
+			if !slices.Contains(allowed, status) {
+				errors = append(errors, ValidationError{Field: "status", Message: fmt.Sprintf("must be one of: %s", strings.Join(allowed, ", "))})
+			}
+		}
+		if s := q.Get("limit"); s != "" {
+			if limit, err := strconv.Atoi(s); err != nil || limit < 1 || limit > maxPage {
+				errors = append(errors, ValidationError{Field: "limit", Message: fmt.Sprintf("must be a positive integer between 1 and %d", maxPage)})
+			}
+		}
+
+		if len(errors) > 0 {
+			return ValidationErrors{Errors: errors}
+		}
+		return nil
+	}
+}
+
+// ValidateListEventsParams validates parameters for the change events endpoint; limit is at most maxPage
+func ValidateListEventsParams(maxPage int) func(*http.Request) error {
+	return func(r *http.Request) error {
+		var errors []ValidationError
+		q := r.URL.Query()
+
+		if s := q.Get("after"); s != "" {
+			if id, err := strconv.ParseInt(s, 10, 64); err != nil || id < 0 {
+				errors = append(errors, ValidationError{Field: "after", Message: "must be a non-negative integer"})
+			}
+		}
+		if s := q.Get("limit"); s != "" {
+			if limit, err := strconv.Atoi(s); err != nil || limit < 1 || limit > maxPage {
+				errors = append(errors, ValidationError{Field: "limit", Message: fmt.Sprintf("must be a positive integer between 1 and %d", maxPage)})
+			}
+		}
+
+		if len(errors) > 0 {
+			return ValidationErrors{Errors: errors}
+		}
+		return nil
+	}
+}
+
+// ValidateWatchParams validates parameters and the Last-Event-ID header of the change stream endpoint
+func ValidateWatchParams(r *http.Request) error {
+	return ValidateWatchQuery(r.URL.Query(), r.Header.Get("Last-Event-ID"))
+}
+
+// ValidateWatchQuery validates change stream filters and resume points, also used by the gRPC API
+func ValidateWatchQuery(q url.Values, lastEventID string) error {
+	var errors []ValidationError
+
+	if len(q["service_id"]) > 50 {
+		errors = append(errors, ValidationError{Field: "service_id", Message: "must be given at most 50 times"})
+	}
+	for _, id := range q["service_id"] {
+		if _, err := uuid.Parse(id); err != nil {
+			errors = append(errors, ValidationError{Field: "service_id", Message: "must be a valid UUID"})
+			break
+		}
+	}
+	if len(q["label"]) > 20 {
+		errors = append(errors, ValidationError{Field: "label", Message: "must be given at most 20 times"})
+	}
+	for _, label := range q["label"] {
+		if k, _, ok := strings.Cut(label, "="); !ok || k == "" || len(label) > 200 {
+			errors = append(errors, ValidationError{Field: "label", Message: "must be key=value, 200 characters or less"})
+			break
+		}
+	}
+	for _, param := range [][2]string{{"Last-Event-ID", lastEventID}, {"last_event_id", q.Get("last_event_id")}} {
+		if param[1] == "" {
+			continue
+		}
+		if id, err := strconv.ParseInt(param[1], 10, 64); err != nil || id < 0 {
+			errors = append(errors, ValidationError{Field: param[0], Message: "must be a non-negative integer"})
+		}
+	}
+
+	if len(errors) > 0 {
+		return ValidationErrors{Errors: errors}
+	}
+	return nil
+}
+
+// ValidateFindComponentsParams validates parameters for the component search endpoint
+func ValidateFindComponentsParams(r *http.Request) error {
+	purl := r.URL.Query().Get("purl")
+	if purl == "" {
+		return ValidationError{Field: "purl", Message: "purl is required"}
+	}
+	if !strings.HasPrefix(purl, "pkg:") || !strings.Contains(purl, "/") {
+		return ValidationError{Field: "purl", Message: "must be a package URL (pkg:type/namespace/name@version)"}
+	}
+	if len(purl) > 500 {
+		return ValidationError{Field: "purl", Message: "must be 500 characters or less"}
+	}
+	return nil
+}
+
+// ValidateVulnerabilityReportParams returns the validator of vulnerability report parameters,
+// allowing limits up to the maximum page size maxPage
+func ValidateVulnerabilityReportParams(maxPage int) func(*http.Request) error {
+	return func(r *http.Request) error {
+		var errors []ValidationError
+
+		if latestOnly := r.URL.Query().Get("latest_only"); latestOnly != "" {
+			if latestOnly != "true" && latestOnly != "false" {
+				errors = append(errors, ValidationError{
+					Field:   "latest_only",
+					Message: "must be either 'true' or 'false'",
+				})
+			}
+		}
+
+		if minSeverity := r.URL.Query().Get("min_severity"); minSeverity != "" {
+			allowed := []string{"low", "medium", "high", "critical"}
+			valid := false
+			for _, a := range allowed {
+				if strings.EqualFold(minSeverity, a) {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				errors = append(errors, ValidationError{
+					Field:   "min_severity",
+					Message: fmt.Sprintf("must be one of: %s", strings.Join(allowed, ", ")),
+				})
+			}
+		}
+
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			if limit, err := strconv.Atoi(limitStr); err != nil || limit <= 0 || limit > maxPage {
+				errors = append(errors, ValidationError{Field: "limit", Message: fmt.Sprintf("must be a positive integer between 1 and %d", maxPage)})
+			}
+		}
+		if token := r.URL.Query().Get("cursor"); token != "" {
+			errors = append(errors, validateCursor(token)...)
+		}
+
+		if len(errors) > 0 {
+			return ValidationErrors{Errors: errors}
+		}
+		return nil
+	}
+}
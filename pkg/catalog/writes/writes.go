@@ -0,0 +1,66 @@
+// Package writes holds the steps around catalog writes that the REST and gRPC APIs share:
+// verifying version signatures before a version is stored and re-evaluating scorecards after
+// a service changes.
+package writes
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"kong/pkg/models"
+	"kong/pkg/scorecard"
+	"kong/pkg/signing"
+)
+
+// Hooks runs the shared write steps
+type Hooks struct {
+	store      *models.Store
+	scorecards *scorecard.Evaluator
+}
+
+// NewHooks creates write hooks. Scorecards aren't re-evaluated when scorecards is nil.
+func NewHooks(store *models.Store, scorecards *scorecard.Evaluator) *Hooks {
+	return &Hooks{store: store, scorecards: scorecards}
+}
+
+// Rescore re-evaluates the scorecard of a service after a write. Failures are logged
+// and picked up by the next scheduled evaluation.
+func (h *Hooks) Rescore(ctx context.Context, id uuid.UUID) {
+	if h.scorecards == nil {
+		return
+	}
+	if _, err := h.scorecards.EvaluateService(ctx, id); err != nil {
+		log.Warn().Err(err).Str("service_id", id.String()).Msg("Failed to evaluate scorecard")
+	}
+}
+
+// VerifySignature checks the version signature against the service's trusted keys and
+// records the result. An error is only returned when the keys can't be loaded.
+func (h *Hooks) VerifySignature(ctx context.Context, v *models.ServiceVersion) error {
+	keys, err := h.store.ListSigningKeys(ctx, v.ServiceID)
+	if err != nil {
+		return err
+	}
+
+	payload := signing.Payload{
+		ArtifactDigests: v.ArtifactDigests,
+		Commit:          v.Commit,
+		ServiceID:       v.ServiceID.String(),
+		Version:         v.Version,
+	}.Canonical()
+
+	for _, k := range keys {
+		pub, err := signing.ParsePublicKey(k.PublicKey)
+		if err != nil {
+			continue
+		}
+		if pub.Verify(payload, v.Signature) == nil {
+			v.Verified = true
+			v.KeyFingerprint = pub.Fingerprint
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+// Package cloudevents represents catalog change events as CloudEvents 1.0 and encodes them in
+// the structured, binary and batch HTTP content modes.
+package cloudevents
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"kong/pkg/models"
+)
+
+// SpecVersion is the CloudEvents specification version events conform to
+const SpecVersion = "1.0"
+
+// Content types of the HTTP content modes
+const (
+	ContentTypeStructured = "application/cloudevents+json"
+	ContentTypeBatch      = "application/cloudevents-batch+json"
+	ContentTypeJSON       = "application/json"
+	ContentTypeSchema     = "application/schema+json"
+)
+
+// typePrefix and typeSuffix wrap catalog event types into CloudEvents types. The suffix is
+// the payload version and only changes with an incompatible change to the data schema.
+const (
+	typePrefix = "com.kong.catalog."
+	typeSuffix = ".v1"
+)
+
+// SchemaPath is where the data schemas are published, followed by the CloudEvents type
+const SchemaPath = "/v1/events/schemas/"
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// schemaFileByEventType maps catalog event types to the schema of their data
+var schemaFileByEventType = map[string]string{
+	models.EventServiceCreated: "schemas/service.json",
+	models.EventServiceUpdated: "schemas/service.json",
+	models.EventVersionCreated: "schemas/version.json",
+}
+
+// Event is a CloudEvent with a JSON data payload
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// Type returns the CloudEvents type of a catalog event type, e.g. com.kong.catalog.service.created.v1
+func Type(eventType string) string {
+	return typePrefix + eventType + typeSuffix
+}
+
+// Types returns the CloudEvents types of every catalog event type
+func Types() []string {
+	types := make([]string, len(models.EventTypes))
+	for i, t := range models.EventTypes {
+		types[i] = Type(t)
+	}
+	return types
+}
+
+// Schema returns the JSON Schema of the data of a CloudEvents type, or nil if the type is unknown
+func Schema(ceType string) []byte {
+	eventType, ok := strings.CutPrefix(ceType, typePrefix)
+	if !ok {
+		return nil
+	}
+	eventType, ok = strings.CutSuffix(eventType, typeSuffix)
+	if !ok {
+		return nil
+	}
+	file, ok := schemaFileByEventType[eventType]
+	if !ok {
+		return nil
+	}
+	b, err := schemaFiles.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// FromOutbox converts an outbox event. The outbox ID is unique per catalog, so together with
+// source it identifies the event. When source is an http(s) URL, dataschema points at the
+// schema published under it.
+func FromOutbox(e models.OutboxEvent, source string) Event {
+	ev := Event{
+		SpecVersion:     SpecVersion,
+		ID:              strconv.FormatInt(e.ID, 10),
+		Source:          source,
+		Type:            Type(e.Type),
+		Subject:         e.ServiceID.String(),
+		Time:            e.CreatedAt.UTC(),
+		DataContentType: ContentTypeJSON,
+		Data:            e.Payload,
+	}
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		ev.DataSchema = strings.TrimSuffix(source, "/") + SchemaPath + ev.Type
+	}
+	return ev
+}
+
+// Validate checks the attributes required by the specification
+func (e Event) Validate() error {
+	switch {
+	case e.SpecVersion != SpecVersion:
+		return fmt.Errorf("unsupported specversion %q", e.SpecVersion)
+	case e.ID == "":
+		return errors.New("id is required")
+	case e.Source == "":
+		return errors.New("source is required")
+	case e.Type == "":
+		return errors.New("type is required")
+	}
+	return nil
+}
+
+// Binary headers carrying the event attributes in binary content mode
+const (
+	headerSpecVersion = "ce-specversion"
+	headerID          = "ce-id"
+	headerSource      = "ce-source"
+	headerType        = "ce-type"
+	headerSubject     = "ce-subject"
+	headerTime        = "ce-time"
+	headerDataSchema  = "ce-dataschema"
+)
+
+// WriteBinary sets the event attributes as ce-* headers and the data content type as Content-Type,
+// and returns the data as the request or response body
+func WriteBinary(h http.Header, e Event) []byte {
+	h.Set(headerSpecVersion, e.SpecVersion)
+	h.Set(headerID, e.ID)
+	h.Set(headerSource, e.Source)
+	h.Set(headerType, e.Type)
+	if e.Subject != "" {
+		h.Set(headerSubject, e.Subject)
+	}
+	if !e.Time.IsZero() {
+		h.Set(headerTime, e.Time.Format(time.RFC3339Nano))
+	}
+	if e.DataSchema != "" {
+		h.Set(headerDataSchema, e.DataSchema)
+	}
+	if e.DataContentType != "" {
+		h.Set("Content-Type", e.DataContentType)
+	}
+	return e.Data
+}
+
+// MarshalStructured encodes the event in structured content mode
+func MarshalStructured(e Event) ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// MarshalBatch encodes events in batch content mode
+func MarshalBatch(events []Event) ([]byte, error) {
+	if events == nil {
+		events = []Event{}
+	}
+	return json.Marshal(events)
+}
+
+// Parse decodes the events of a request in any content mode, chosen by its Content-Type
+func Parse(h http.Header, body io.Reader) ([]Event, error) {
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil && h.Get("Content-Type") != "" {
+		return nil, err
+	}
+
+	var events []Event
+	switch mediaType {
+	case ContentTypeStructured:
+		var e Event
+		if err := json.NewDecoder(body).Decode(&e); err != nil {
+			return nil, err
+		}
+		events = []Event{e}
+	case ContentTypeBatch:
+		if err := json.NewDecoder(body).Decode(&events); err != nil {
+			return nil, err
+		}
+	default:
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		e := Event{
+			SpecVersion:     h.Get(headerSpecVersion),
+			ID:              h.Get(headerID),
+			Source:          h.Get(headerSource),
+			Type:            h.Get(headerType),
+			Subject:         h.Get(headerSubject),
+			DataContentType: h.Get("Content-Type"),
+			DataSchema:      h.Get(headerDataSchema),
+		}
+		if len(bytes.TrimSpace(data)) > 0 {
+			e.Data = data
+		}
+		if s := h.Get(headerTime); s != "" {
+			if e.Time, err = time.Parse(time.RFC3339Nano, s); err != nil {
+				return nil, fmt.Errorf("invalid ce-time: %w", err)
+			}
+		}
+		events = []Event{e}
+	}
+
+	for _, e := range events {
+		if err := e.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
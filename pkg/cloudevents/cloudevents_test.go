@@ -0,0 +1,151 @@
+package cloudevents
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kong/pkg/models"
+)
+
+func testOutboxEvent(t *testing.T) models.OutboxEvent {
+	service := models.Service{ID: uuid.New(), Name: "billing", Labels: map[string]string{}, Revision: 1}
+	payload, err := json.Marshal(service)
+	require.NoError(t, err)
+	return models.OutboxEvent{
+		ID:        7,
+		Type:      models.EventServiceCreated,
+		ServiceID: service.ID,
+		Payload:   payload,
+		CreatedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600)),
+	}
+}
+
+func TestFromOutbox(t *testing.T) {
+	o := testOutboxEvent(t)
+
+	e := FromOutbox(o, "https://catalog.example.com/")
+	require.NoError(t, e.Validate())
+	assert.Equal(t, "1.0", e.SpecVersion)
+	assert.Equal(t, "7", e.ID)
+	assert.Equal(t, "com.kong.catalog.service.created.v1", e.Type)
+	assert.Equal(t, o.ServiceID.String(), e.Subject)
+	assert.Equal(t, time.UTC, e.Time.Location())
+	assert.Equal(t, "https://catalog.example.com/v1/events/schemas/com.kong.catalog.service.created.v1", e.DataSchema)
+	assert.JSONEq(t, string(o.Payload), string(e.Data))
+
+	// Without a resolvable source there is nowhere to point dataschema at
+	assert.Empty(t, FromOutbox(o, "urn:kong:catalog").DataSchema)
+}
+
+func TestTypesAndSchemas(t *testing.T) {
+	assert.Equal(t, []string{
+		"com.kong.catalog.service.created.v1",
+		"com.kong.catalog.service.updated.v1",
+		"com.kong.catalog.version.created.v1",
+	}, Types())
+
+	for _, ceType := range Types() {
+		var schema map[string]any
+		require.NoError(t, json.Unmarshal(Schema(ceType), &schema), ceType)
+		assert.Equal(t, "object", schema["type"])
+	}
+	assert.Nil(t, Schema("com.kong.catalog.service.deleted.v1"))
+	assert.Nil(t, Schema("com.kong.catalog.service.created.v2"))
+	assert.Nil(t, Schema("service.created"))
+}
+
+// TestSchemasMatchPayloads keeps the published schemas in step with the JSON the catalog writes
+func TestSchemasMatchPayloads(t *testing.T) {
+	service := models.Service{ID: uuid.New(), Name: "billing", Labels: map[string]string{}}
+	version := models.ServiceVersion{ID: uuid.New(), ServiceID: service.ID, Version: "1.0.0", Commit: "abc",
+		ArtifactDigests: []string{"sha256:00"}, Signature: "sig", KeyFingerprint: "fp"}
+
+	for ceType, payload := range map[string]any{
+		Type(models.EventServiceCreated): service,
+		Type(models.EventVersionCreated): version,
+	} {
+		var schema struct {
+			Required   []string       `json:"required"`
+			Properties map[string]any `json:"properties"`
+		}
+		require.NoError(t, json.Unmarshal(Schema(ceType), &schema))
+
+		b, err := json.Marshal(payload)
+		require.NoError(t, err)
+		var fields map[string]any
+		require.NoError(t, json.Unmarshal(b, &fields))
+
+		assert.ElementsMatch(t, slices.Collect(maps.Keys(schema.Properties)), slices.Collect(maps.Keys(fields)), ceType)
+		for _, name := range schema.Required {
+			assert.Contains(t, schema.Properties, name, ceType)
+		}
+	}
+}
+
+func TestContentModes(t *testing.T) {
+	e := FromOutbox(testOutboxEvent(t), "https://catalog.example.com")
+
+	t.Run("Structured", func(t *testing.T) {
+		body, err := MarshalStructured(e)
+		require.NoError(t, err)
+		var raw map[string]any
+		require.NoError(t, json.Unmarshal(body, &raw))
+		assert.Equal(t, "1.0", raw["specversion"])
+		assert.Equal(t, "billing", raw["data"].(map[string]any)["name"])
+
+		h := http.Header{"Content-Type": {ContentTypeStructured + "; charset=utf-8"}}
+		events, err := Parse(h, bytes.NewReader(body))
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, e.ID, events[0].ID)
+		assert.True(t, e.Time.Equal(events[0].Time))
+	})
+
+	t.Run("Binary", func(t *testing.T) {
+		h := http.Header{}
+		body := WriteBinary(h, e)
+		assert.Equal(t, "application/json", h.Get("Content-Type"))
+		assert.Equal(t, "1.0", h.Get("ce-specversion"))
+		assert.Equal(t, e.Subject, h.Get("ce-subject"))
+		assert.JSONEq(t, string(e.Data), string(body))
+
+		events, err := Parse(h, bytes.NewReader(body))
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, e.Type, events[0].Type)
+		assert.Equal(t, e.DataSchema, events[0].DataSchema)
+		assert.True(t, e.Time.Equal(events[0].Time))
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		second := e
+		second.ID = "8"
+		body, err := MarshalBatch([]Event{e, second})
+		require.NoError(t, err)
+
+		events, err := Parse(http.Header{"Content-Type": {ContentTypeBatch}}, bytes.NewReader(body))
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, "8", events[1].ID)
+
+		empty, err := MarshalBatch(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "[]", string(empty))
+	})
+
+	t.Run("Invalid events are rejected", func(t *testing.T) {
+		_, err := Parse(http.Header{"Content-Type": {"application/json"}}, bytes.NewReader([]byte(`{}`)))
+		assert.Error(t, err)
+		_, err = Parse(http.Header{"Content-Type": {ContentTypeStructured}}, bytes.NewReader([]byte(`{"specversion":"0.3","id":"1","source":"x","type":"t"}`)))
+		assert.Error(t, err)
+	})
+}
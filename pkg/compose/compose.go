@@ -0,0 +1,146 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	yaml "sigs.k8s.io/yaml/goyaml.v2"
+)
+
+// File is the subset of a compose file the importer cares about
+type File struct {
+	Services map[string]Service `yaml:"services"`
+}
+
+// Service is a single compose service definition
+type Service struct {
+	Image     string    `yaml:"image"`
+	DependsOn DependsOn `yaml:"depends_on"`
+	Labels    Labels    `yaml:"labels"`
+}
+
+// DependsOn accepts both the short (list) and long (map) depends_on syntax
+type DependsOn []string
+
+func (d *DependsOn) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		*d = list
+		return nil
+	}
+
+	var long map[string]interface{}
+	if err := unmarshal(&long); err != nil {
+		return fmt.Errorf("depends_on must be a list or a map: %w", err)
+	}
+	names := make([]string, 0, len(long))
+	for name := range long {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	*d = names
+	return nil
+}
+
+// Labels accepts both the map and the "key=value" list label syntax
+type Labels map[string]string
+
+func (l *Labels) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var m map[string]string
+	if err := unmarshal(&m); err == nil {
+		*l = m
+		return nil
+	}
+
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return fmt.Errorf("labels must be a list or a map: %w", err)
+	}
+	m = make(map[string]string, len(list))
+	for _, item := range list {
+		k, v, _ := strings.Cut(item, "=")
+		m[k] = v
+	}
+	*l = m
+	return nil
+}
+
+// ParseFile reads and parses a compose file from disk
+func ParseFile(filename string) (*File, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("encountered a problem reading file (%s): %w", filename, err)
+	}
+	f, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse compose file (%s): %w", filename, err)
+	}
+	return f, nil
+}
+
+// Parse parses the contents of a compose file
+func Parse(data []byte) (*File, error) {
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	if f.Services == nil {
+		f.Services = map[string]Service{}
+	}
+	return &f, nil
+}
+
+// Merge combines several compose files the way `docker compose -f a -f b` does for
+// the fields we read: later files override the image and extend depends_on and labels.
+func Merge(files ...*File) *File {
+	merged := &File{Services: map[string]Service{}}
+	for _, f := range files {
+		for name, svc := range f.Services {
+			cur, ok := merged.Services[name]
+			if !ok {
+				cur = Service{Labels: Labels{}}
+			}
+			if svc.Image != "" {
+				cur.Image = svc.Image
+			}
+			for _, dep := range svc.DependsOn {
+				if !contains(cur.DependsOn, dep) {
+					cur.DependsOn = append(cur.DependsOn, dep)
+				}
+			}
+			for k, v := range svc.Labels {
+				cur.Labels[k] = v
+			}
+			merged.Services[name] = cur
+		}
+	}
+	return merged
+}
+
+// ImageTag extracts the tag (or digest) from an image reference.
+// An image without a tag resolves to "latest"; an empty image returns "".
+func ImageTag(image string) string {
+	if image == "" {
+		return ""
+	}
+	if _, digest, ok := strings.Cut(image, "@"); ok {
+		return digest
+	}
+	// Only a colon after the last slash separates the tag; earlier ones belong to a registry port
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return "latest"
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package compose
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	data := []byte(`
+services:
+  db:
+    image: postgres:17-alpine
+  cache:
+    image: redis
+    labels:
+      - org.opencontainers.image.description=Session cache
+  catalog-api:
+    build:
+      context: .
+    depends_on:
+      db:
+        condition: service_healthy
+      cache:
+        condition: service_started
+  worker:
+    image: registry.local:5000/team/worker:1.4.2
+    depends_on: [db]
+`)
+
+	f, err := Parse(data)
+	require.NoError(t, err)
+	require.Len(t, f.Services, 4)
+
+	assert.Equal(t, "postgres:17-alpine", f.Services["db"].Image)
+	assert.Equal(t, "Session cache", f.Services["cache"].Labels[DescriptionLabel])
+	assert.Equal(t, DependsOn{"cache", "db"}, f.Services["catalog-api"].DependsOn)
+	assert.Equal(t, DependsOn{"db"}, f.Services["worker"].DependsOn)
+}
+
+func TestImageTag(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"", ""},
+		{"redis", "latest"},
+		{"postgres:17-alpine", "17-alpine"},
+		{"registry.local:5000/team/worker", "latest"},
+		{"registry.local:5000/team/worker:1.4.2", "1.4.2"},
+		{"nginx@sha256:abc123", "sha256:abc123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			assert.Equal(t, tt.want, ImageTag(tt.image))
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base, err := Parse([]byte(`
+services:
+  api:
+    image: api:1.0.0
+    depends_on: [db]
+  db:
+    image: postgres:16
+`))
+	require.NoError(t, err)
+
+	override, err := Parse([]byte(`
+services:
+  api:
+    image: api:1.1.0
+    depends_on: [cache]
+  cache:
+    image: redis:7
+`))
+	require.NoError(t, err)
+
+	merged := Merge(base, override)
+	require.Len(t, merged.Services, 3)
+	assert.Equal(t, "api:1.1.0", merged.Services["api"].Image)
+	assert.Equal(t, DependsOn{"db", "cache"}, merged.Services["api"].DependsOn)
+	assert.Equal(t, "postgres:16", merged.Services["db"].Image)
+}
+
+func TestImporter_CatalogName(t *testing.T) {
+	assert.Equal(t, "shop-api", NewImporter(nil, "shop-").CatalogName("api"))
+	assert.Equal(t, "api", NewImporter(nil, "").CatalogName("api"))
+}
+
+func TestImporter_Validate(t *testing.T) {
+	// Invalid services are rejected before anything is written
+	importer := NewImporter(nil, "")
+	_, err := importer.Import(context.Background(), &File{Services: map[string]Service{
+		strings.Repeat("a", 101): {Image: "api:1.0.0"},
+	}})
+	assert.ErrorContains(t, err, "Name too long")
+
+	_, err = importer.Import(context.Background(), &File{Services: map[string]Service{
+		"api": {Image: "api:" + strings.Repeat("1", 51)},
+	}})
+	assert.ErrorContains(t, err, "Version too long")
+}
@@ -0,0 +1,145 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"kong/pkg/catalog/validation"
+	"kong/pkg/models"
+)
+
+// DescriptionLabel is the compose label used as the catalog service description
+const DescriptionLabel = "org.opencontainers.image.description"
+
+// ImportResult summarizes what an import changed
+type ImportResult struct {
+	ServicesCreated      []string `json:"services_created"`
+	ServicesExisting     []string `json:"services_existing"`
+	VersionsCreated      []string `json:"versions_created"`
+	DependenciesRecorded int      `json:"dependencies_recorded"`
+}
+
+// Importer registers compose services in the catalog
+type Importer struct {
+	store  *models.Store
+	prefix string
+}
+
+// NewImporter creates an importer; prefix is prepended to every compose service name
+func NewImporter(store *models.Store, prefix string) *Importer {
+	return &Importer{store: store, prefix: prefix}
+}
+
+// CatalogName returns the catalog service name for a compose service
+func (i *Importer) CatalogName(composeName string) string {
+	return i.prefix + composeName
+}
+
+// Import registers every service in f. It is idempotent: existing services and
+// versions are reused, so the same files can be imported repeatedly. The import is
+// applied in a single transaction, so a failure leaves the catalog untouched.
+func (i *Importer) Import(ctx context.Context, f *File) (*ImportResult, error) {
+	names := make([]string, 0, len(f.Services))
+	for name := range f.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if err := i.validate(f, names); err != nil {
+		return nil, err
+	}
+
+	var result *ImportResult
+	err := i.store.WithTx(ctx, func(tx *models.Tx) error {
+		var err error
+		result, err = i.apply(ctx, tx, f, names)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// validate applies the API's validation rules to the services and versions f would create
+func (i *Importer) validate(f *File, names []string) error {
+	for _, name := range names {
+		svc := f.Services[name]
+		catalogName := i.CatalogName(name)
+		if msg := validation.ValidateCreateService(&validation.CreateServiceRequest{
+			Name:        catalogName,
+			Description: svc.Labels[DescriptionLabel],
+		}); msg != "" {
+			return fmt.Errorf("invalid service %s: %s", catalogName, msg)
+		}
+		if tag := ImageTag(svc.Image); tag != "" {
+			if msg := validation.ValidateCreateServiceVersion(&validation.CreateServiceVersionRequest{Version: tag}); msg != "" {
+				return fmt.Errorf("invalid version %s for service %s: %s", tag, catalogName, msg)
+			}
+		}
+	}
+	return nil
+}
+
+func (i *Importer) apply(ctx context.Context, tx *models.Tx, f *File, names []string) (*ImportResult, error) {
+	result := &ImportResult{
+		ServicesCreated:  []string{},
+		ServicesExisting: []string{},
+		VersionsCreated:  []string{},
+	}
+
+	// Register services and their image versions first, so dependencies can be resolved afterwards
+	ids := make(map[string]models.Service, len(names))
+	for _, name := range names {
+		svc := f.Services[name]
+		catalogName := i.CatalogName(name)
+
+		existing, err := tx.GetServiceByName(ctx, catalogName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up service %s: %w", catalogName, err)
+		}
+		if existing != nil {
+			ids[name] = *existing
+			result.ServicesExisting = append(result.ServicesExisting, catalogName)
+		} else {
+			service := &models.Service{
+				Name:        catalogName,
+				Description: svc.Labels[DescriptionLabel],
+			}
+			if err := tx.CreateService(ctx, service); err != nil {
+				return nil, fmt.Errorf("failed to create service %s: %w", catalogName, err)
+			}
+			ids[name] = *service
+			result.ServicesCreated = append(result.ServicesCreated, catalogName)
+		}
+
+		if tag := ImageTag(svc.Image); tag != "" {
+			created, err := tx.EnsureServiceVersion(ctx, &models.ServiceVersion{
+				ServiceID: ids[name].ID,
+				Version:   tag,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create version %s for service %s: %w", tag, catalogName, err)
+			}
+			if created {
+				result.VersionsCreated = append(result.VersionsCreated, catalogName+"@"+tag)
+			}
+		}
+	}
+
+	for _, name := range names {
+		for _, dep := range f.Services[name].DependsOn {
+			target, ok := ids[dep]
+			if !ok {
+				return nil, fmt.Errorf("service %s depends on unknown service %s", name, dep)
+			}
+			if err := tx.AddServiceDependency(ctx, ids[name].ID, target.ID); err != nil {
+				return nil, fmt.Errorf("failed to record dependency %s -> %s: %w", name, dep, err)
+			}
+			result.DependenciesRecorded++
+		}
+	}
+
+	return result, nil
+}
@@ -14,6 +14,8 @@ type AppConfig struct {
 	Addr        string `yaml:"addr"`
 	DatabaseURL string `yaml:"database_url"`
 	MaxPageSize int    `yaml:"max_page_size"`
+	// Unfiltered service lists report the planner's row estimate as their total past this many services, counting is always exact when 0
+	CountEstimateThreshold int64 `yaml:"count_estimate_threshold" envconfig:"COUNT_ESTIMATE_THRESHOLD"`
 
 	// Database connection configuration
 	DBMaxConnections    int           `yaml:"db_max_connections" envconfig:"DB_MAX_CONNECTIONS"`
@@ -25,6 +27,62 @@ type AppConfig struct {
 
 	// API configuration
 	ValidAPIKeys []string `yaml:"valid_api_keys" envconfig:"VALID_API_KEYS"`
+
+	// Offline OSV advisory mirror, vulnerability matching is disabled when empty
+	OSVDir             string        `yaml:"osv_dir" envconfig:"OSV_DIR"`
+	OSVRefreshInterval time.Duration `yaml:"osv_refresh_interval" envconfig:"OSV_REFRESH_INTERVAL"`
+
+	// Scorecard checks file, the built-in checks are used when empty
+	ScorecardChecks   string        `yaml:"scorecard_checks" envconfig:"SCORECARD_CHECKS"`
+	ScorecardInterval time.Duration `yaml:"scorecard_interval" envconfig:"SCORECARD_INTERVAL"`
+
+	// Maximum concurrent health probes, probing is disabled when 0
+	HealthProbeConcurrency int `yaml:"health_probe_concurrency" envconfig:"HEALTH_PROBE_CONCURRENCY"`
+	// Whether health-check and webhook URLs may point to loopback, link-local and private addresses (development only)
+	AllowPrivateTargets bool `yaml:"allow_private_targets" envconfig:"ALLOW_PRIVATE_TARGETS"`
+
+	// Services without a version newer than StaleAfter are reported stale, labeling them is disabled when the interval is 0
+	StaleAfter         time.Duration `yaml:"stale_after" envconfig:"STALE_AFTER"`
+	StaleLabelInterval time.Duration `yaml:"stale_label_interval" envconfig:"STALE_LABEL_INTERVAL"`
+
+	// Key signing pagination cursors, shared by all replicas; required
+	CursorSecret string `yaml:"cursor_secret" envconfig:"CURSOR_SECRET"`
+
+	// Latency budget of /v1/services/suggest lookups, unlimited when 0
+	SuggestTimeout time.Duration `yaml:"suggest_timeout" envconfig:"SUGGEST_TIMEOUT"`
+
+	// How long /v1/stats responses are cached, caching is disabled when 0
+	StatsCacheTTL time.Duration `yaml:"stats_cache_ttl" envconfig:"STATS_CACHE_TTL"`
+
+	// Maximum concurrent webhook deliveries, delivery is disabled when 0
+	WebhookConcurrency int `yaml:"webhook_concurrency" envconfig:"WEBHOOK_CONCURRENCY"`
+	// Failed attempts after which a delivery is dead-lettered
+	WebhookMaxAttempts int `yaml:"webhook_max_attempts" envconfig:"WEBHOOK_MAX_ATTEMPTS"`
+	// How long dispatched and published change events are kept, pruning is disabled when 0
+	OutboxRetention time.Duration `yaml:"outbox_retention" envconfig:"OUTBOX_RETENTION"`
+
+	// CloudEvents source of change events; when it is the catalog's http(s) URL, events link to their data schema
+	EventSource string `yaml:"event_source" envconfig:"EVENT_SOURCE"`
+
+	// NATS server change events are published to, publishing is disabled when empty
+	NATSURL string `yaml:"nats_url" envconfig:"NATS_URL"`
+	// Prefix of the subjects events are published to, e.g. catalog.service.created
+	NATSSubjectPrefix string `yaml:"nats_subject_prefix" envconfig:"NATS_SUBJECT_PREFIX"`
+
+	// Interval of heartbeat comments on /v1/watch streams
+	WatchHeartbeat time.Duration `yaml:"watch_heartbeat" envconfig:"WATCH_HEARTBEAT"`
+
+	// Limits of /graphql queries, unlimited when 0
+	GraphQLMaxDepth      int `yaml:"graphql_max_depth" envconfig:"GRAPHQL_MAX_DEPTH"`
+	GraphQLMaxComplexity int `yaml:"graphql_max_complexity" envconfig:"GRAPHQL_MAX_COMPLEXITY"`
+	// Serve the GraphiQL IDE at /graphiql, meant for development
+	GraphiQL bool `yaml:"graphiql" envconfig:"GRAPHIQL"`
+
+	// gRPC API listener, disabled when empty
+	GRPCAddr string `yaml:"grpc_addr" envconfig:"GRPC_ADDR"`
+
+	// Consul-compatible read-only API, disabled when empty
+	ConsulAddr string `yaml:"consul_addr" envconfig:"CONSUL_ADDR"`
 }
 
 // global app config
@@ -0,0 +1,408 @@
+// Package consul serves the read-only subset of Consul's HTTP catalog API from the service catalog,
+// so tools that only speak Consul can discover catalog services.
+//
+// Catalog concepts are mapped onto Consul as follows:
+//   - service         -> Consul service (tags are the service's versions, newest first)
+//   - scrape target   -> service instance (address:port)
+//   - environment     -> datacenter (the ?dc= parameter)
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"kong/pkg/models"
+	"kong/pkg/watch"
+)
+
+// Blocking query limits, the same as Consul's
+const (
+	defaultWait = 5 * time.Minute
+	maxWait     = 10 * time.Minute
+)
+
+// fallbackPoll is how often a blocking query checks whether the catalog changed without a
+// notification, in case one was lost while the hub reconnected
+const fallbackPoll = 30 * time.Second
+
+// CatalogService is an entry of /v1/catalog/service/:name
+type CatalogService struct {
+	ID                       string            `json:"ID"`
+	Node                     string            `json:"Node"`
+	Address                  string            `json:"Address"`
+	Datacenter               string            `json:"Datacenter"`
+	TaggedAddresses          map[string]string `json:"TaggedAddresses"`
+	NodeMeta                 map[string]string `json:"NodeMeta"`
+	ServiceID                string            `json:"ServiceID"`
+	ServiceName              string            `json:"ServiceName"`
+	ServiceTags              []string          `json:"ServiceTags"`
+	ServiceAddress           string            `json:"ServiceAddress"`
+	ServicePort              int               `json:"ServicePort"`
+	ServiceMeta              map[string]string `json:"ServiceMeta"`
+	ServiceEnableTagOverride bool              `json:"ServiceEnableTagOverride"`
+	CreateIndex              uint64            `json:"CreateIndex"`
+	ModifyIndex              uint64            `json:"ModifyIndex"`
+}
+
+// Node is the node part of a /v1/health/service/:name entry
+type Node struct {
+	ID              string            `json:"ID"`
+	Node            string            `json:"Node"`
+	Address         string            `json:"Address"`
+	Datacenter      string            `json:"Datacenter"`
+	TaggedAddresses map[string]string `json:"TaggedAddresses"`
+	Meta            map[string]string `json:"Meta"`
+}
+
+// AgentService is the service part of a /v1/health/service/:name entry
+type AgentService struct {
+	ID      string            `json:"ID"`
+	Service string            `json:"Service"`
+	Tags    []string          `json:"Tags"`
+	Address string            `json:"Address"`
+	Port    int               `json:"Port"`
+	Meta    map[string]string `json:"Meta"`
+}
+
+// HealthCheck is a check of a /v1/health/service/:name entry
+type HealthCheck struct {
+	Node        string `json:"Node"`
+	CheckID     string `json:"CheckID"`
+	Name        string `json:"Name"`
+	Status      string `json:"Status"`
+	Notes       string `json:"Notes"`
+	Output      string `json:"Output"`
+	ServiceID   string `json:"ServiceID"`
+	ServiceName string `json:"ServiceName"`
+}
+
+// ServiceEntry is an entry of /v1/health/service/:name
+type ServiceEntry struct {
+	Node    Node          `json:"Node"`
+	Service AgentService  `json:"Service"`
+	Checks  []HealthCheck `json:"Checks"`
+}
+
+// Handler serves the Consul-compatible API
+type Handler struct {
+	store        *models.Store
+	audits       *watch.Hub
+	validAPIKeys []string
+	r            *chi.Mux
+}
+
+// NewHandler creates the Consul-compatible API handler. Requests must carry one of validAPIKeys
+// as an ACL token (X-Consul-Token header, bearer token or ?token= parameter). Blocking queries
+// wake up on the notifications of audits, a hub listening on models.AuditChannel.
+func NewHandler(store *models.Store, audits *watch.Hub, validAPIKeys []string) *Handler {
+	h := &Handler{store: store, audits: audits, validAPIKeys: validAPIKeys, r: chi.NewRouter()}
+	h.r.Use(h.aclMiddleware)
+	h.r.Use(h.blockingMiddleware)
+	h.r.Get("/v1/catalog/services", h.CatalogServices)
+	h.r.Get("/v1/catalog/service/{name}", h.CatalogService)
+	h.r.Get("/v1/health/service/{name}", h.HealthService)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) { h.r.ServeHTTP(w, r) }
+
+// CatalogServices handles /v1/catalog/services
+func (h *Handler) CatalogServices(w http.ResponseWriter, r *http.Request) {
+	services, err := h.store.ListServiceVersionNames(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, indexFrom(r), services)
+}
+
+// CatalogService handles /v1/catalog/service/:name
+func (h *Handler) CatalogService(w http.ResponseWriter, r *http.Request) {
+	instances, err := h.instances(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ids := make([]uuid.UUID, len(instances))
+	for i, inst := range instances {
+		ids[i] = inst.target.ID
+	}
+	indexes, err := h.store.ListTargetIndexes(r.Context(), ids)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	entries := make([]CatalogService, 0, len(instances))
+	for _, inst := range instances {
+		// Indexes are audit event IDs like X-Consul-Index, and never 0
+		index := indexes[inst.target.ID]
+		createIndex := uint64(max(index.Create, 1))
+		modifyIndex := max(uint64(index.Modify), createIndex)
+		entries = append(entries, CatalogService{
+			ID:              inst.nodeID,
+			Node:            inst.node,
+			Address:         inst.host,
+			Datacenter:      inst.target.Environment,
+			TaggedAddresses: map[string]string{"lan": inst.host},
+			NodeMeta:        map[string]string{},
+			ServiceID:       inst.target.ID.String(),
+			ServiceName:     inst.target.ServiceName,
+			ServiceTags:     inst.tags,
+			ServiceAddress:  inst.host,
+			ServicePort:     inst.port,
+			ServiceMeta:     inst.meta(),
+			CreateIndex:     createIndex,
+			ModifyIndex:     modifyIndex,
+		})
+	}
+
+	respond(w, indexFrom(r), entries)
+}
+
+// HealthService handles /v1/health/service/:name. Every instance reports a single passing
+// check, since the catalog doesn't observe instance health.
+func (h *Handler) HealthService(w http.ResponseWriter, r *http.Request) {
+	instances, err := h.instances(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	entries := make([]ServiceEntry, 0, len(instances))
+	for _, inst := range instances {
+		serviceID := inst.target.ID.String()
+		entries = append(entries, ServiceEntry{
+			Node: Node{
+				ID:              inst.nodeID,
+				Node:            inst.node,
+				Address:         inst.host,
+				Datacenter:      inst.target.Environment,
+				TaggedAddresses: map[string]string{"lan": inst.host},
+				Meta:            map[string]string{},
+			},
+			Service: AgentService{
+				ID:      serviceID,
+				Service: inst.target.ServiceName,
+				Tags:    inst.tags,
+				Address: inst.host,
+				Port:    inst.port,
+				Meta:    inst.meta(),
+			},
+			Checks: []HealthCheck{{
+				Node:        inst.node,
+				CheckID:     "catalog:" + serviceID,
+				Name:        "Catalog registration",
+				Status:      "passing",
+				ServiceID:   serviceID,
+				ServiceName: inst.target.ServiceName,
+			}},
+		})
+	}
+
+	respond(w, indexFrom(r), entries)
+}
+
+// instance is a scrape target resolved into Consul terms
+type instance struct {
+	target models.DiscoveryTarget
+	tags   []string
+	node   string
+	nodeID string
+	host   string
+	port   int
+}
+
+func (i instance) meta() map[string]string {
+	return map[string]string{
+		"catalog_service_id": i.target.ServiceID.String(),
+		"team":               i.target.Team,
+		"version":            i.target.LatestVersion,
+		"metrics_path":       i.target.MetricsPath,
+	}
+}
+
+// instances resolves the instances of the {name} service, honoring ?dc= and ?tag=
+func (h *Handler) instances(r *http.Request) ([]instance, error) {
+	name := chi.URLParam(r, "name")
+	dc := r.URL.Query().Get("dc")
+	tagFilter := r.URL.Query()["tag"]
+
+	targets, err := h.store.ListDiscoveryTargets(r.Context(), dc, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	if len(targets) > 0 {
+		versions, err := h.store.ListVersions(r.Context(), targets[0].ServiceID, false)
+		if err != nil {
+			return nil, err
+		}
+		tags = make([]string, 0, len(versions))
+		for _, v := range versions {
+			tags = append(tags, v.Version)
+		}
+	}
+	if !hasAllTags(tags, tagFilter) {
+		return []instance{}, nil
+	}
+
+	instances := make([]instance, 0, len(targets))
+	for _, t := range targets {
+		host, portStr, err := net.SplitHostPort(t.Address)
+		if err != nil {
+			host, portStr = t.Address, "0"
+		}
+		port, _ := strconv.Atoi(portStr)
+		instances = append(instances, instance{
+			target: t,
+			tags:   tags,
+			node:   host,
+			nodeID: t.ID.String(),
+			host:   host,
+			port:   port,
+		})
+	}
+	return instances, nil
+}
+
+func hasAllTags(tags, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, t := range tags {
+			if t == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// aclMiddleware checks the ACL token the way Consul clients send it
+func (h *Handler) aclMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-Consul-Token")
+		if token == "" {
+			if auth := r.Header.Get("Authorization"); len(auth) > 7 && auth[:7] == "Bearer " {
+				token = auth[7:]
+			}
+		}
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+
+		for _, validKey := range h.validAPIKeys {
+			if token == validKey {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		http.Error(w, "ACL not found", http.StatusForbidden)
+	})
+}
+
+type indexKey struct{}
+
+// blockingMiddleware implements Consul blocking queries. The catalog index is the ID of the
+// newest audit event; a request with ?index= waits until it differs from that value or ?wait=
+// (default 5m, at most 10m) elapses. The index handlers report is read before they query, so
+// a change committed in between is reported to the next request instead of being missed.
+func (h *Handler) blockingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var seen uint64
+		if v := q.Get("index"); v != "" {
+			var err error
+			if seen, err = strconv.ParseUint(v, 10, 64); err != nil {
+				http.Error(w, "Invalid index", http.StatusBadRequest)
+				return
+			}
+		}
+		wait := defaultWait
+		if v := q.Get("wait"); v != "" {
+			d, err := parseWait(v)
+			if err != nil {
+				http.Error(w, "Invalid wait", http.StatusBadRequest)
+				return
+			}
+			wait = min(d, maxWait)
+		}
+
+		index, err := h.waitIndex(r.Context(), seen, wait)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), indexKey{}, index)))
+	})
+}
+
+// parseWait parses a ?wait= duration; Consul accepts a bare number of seconds too
+func parseWait(v string) (time.Duration, error) {
+	if n, err := strconv.ParseUint(v, 10, 32); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err == nil && d < 0 {
+		err = strconv.ErrRange
+	}
+	return d, err
+}
+
+// waitIndex returns the catalog index, waiting for audit notifications until it differs from
+// seen or wait elapses. seen 0 doesn't block.
+func (h *Handler) waitIndex(ctx context.Context, seen uint64, wait time.Duration) (uint64, error) {
+	// Subscribe before the first read so no commit between a read and the wait is missed
+	wake, unsubscribe := h.audits.Subscribe()
+	defer unsubscribe()
+
+	deadline := time.Now().Add(wait)
+	for {
+		id, err := h.store.LatestAuditEventID(ctx)
+		if err != nil {
+			return 0, err
+		}
+		// Consul indexes are never 0
+		index := uint64(max(id, 1))
+		if seen == 0 || index != seen || !time.Now().Before(deadline) {
+			return index, nil
+		}
+		timer := time.NewTimer(min(fallbackPoll, time.Until(deadline)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, ctx.Err()
+		case <-wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+func indexFrom(r *http.Request) uint64 {
+	index, _ := r.Context().Value(indexKey{}).(uint64)
+	return index
+}
+
+// respond writes a JSON response with the headers Consul clients expect
+func respond(w http.ResponseWriter, index uint64, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(index, 10))
+	w.Header().Set("X-Consul-KnownLeader", "true")
+	w.Header().Set("X-Consul-LastContact", "0")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,44 @@
+package cursor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type position struct {
+	Key string `json:"k"`
+	ID  int    `json:"i"`
+}
+
+func TestCodec(t *testing.T) {
+	codec, err := NewCodec([]byte("secret"))
+	require.NoError(t, err)
+	token := codec.Encode(position{Key: "billing", ID: 7})
+	assert.NotContains(t, token, "=")
+
+	var got position
+	require.NoError(t, codec.Decode(token, &got))
+	assert.Equal(t, position{Key: "billing", ID: 7}, got)
+
+	// Tokens of another key
+	other, err := NewCodec([]byte("other"))
+	require.NoError(t, err)
+	assert.ErrorIs(t, other.Decode(token, &got), ErrInvalid)
+
+	// Codecs need a key
+	_, err = NewCodec(nil)
+	assert.ErrorIs(t, err, ErrNoKey)
+
+	// Tampered payloads
+	data, sig, _ := strings.Cut(token, ".")
+	forged := other.Encode(position{Key: "zzz", ID: 7})
+	forgedData, _, _ := strings.Cut(forged, ".")
+	assert.ErrorIs(t, codec.Decode(forgedData+"."+sig, &got), ErrInvalid)
+
+	for _, token := range []string{"", data, data + ".", "!!." + sig, strings.Repeat("a", MaxLength+1)} {
+		assert.ErrorIs(t, codec.Decode(token, &got), ErrInvalid, token)
+	}
+}
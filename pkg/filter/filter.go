@@ -0,0 +1,293 @@
+// Package filter parses the filter expressions of list endpoints, e.g.
+//
+//	name ~ "pay" AND created_at > 2026-01-01 AND versions.count >= 3
+//
+// Expressions compare fields with literal values and combine the comparisons with AND, OR, NOT
+// and parentheses. Values are double-quoted strings, numbers, true/false, and dates (YYYY-MM-DD)
+// or RFC 3339 timestamps. Check validates an expression against the fields an endpoint allows;
+// compiling it to SQL is up to the store.
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Limits keeping expressions cheap to parse and to run
+const (
+	MaxLength      = 1000
+	MaxComparisons = 20
+	maxDepth       = 16
+)
+
+// Type is the type of a field or value
+type Type int
+
+const (
+	String Type = iota
+	Number
+	Time
+	Bool
+)
+
+func (t Type) String() string {
+	return [...]string{"string", "number", "time", "boolean"}[t]
+}
+
+// Comparison operators
+const (
+	OpEq       = "="
+	OpNe       = "!="
+	OpLt       = "<"
+	OpLe       = "<="
+	OpGt       = ">"
+	OpGe       = ">="
+	OpContains = "~" // case-insensitive substring match of strings
+)
+
+// Expr is a node of a parsed expression
+type Expr interface {
+	// Pos is the column (1-based) the expression starts at
+	Pos() int
+}
+
+// Logical combines two expressions with AND or OR
+type Logical struct {
+	Op          string // AND or OR
+	Left, Right Expr
+}
+
+// Not negates an expression
+type Not struct {
+	Column int
+	X      Expr
+}
+
+// Comparison compares a field with a value
+type Comparison struct {
+	Field  string
+	Column int
+	Op     string
+	Value  Value
+}
+
+// Value is a literal value
+type Value struct {
+	Type   Type
+	Column int
+	Str    string
+	Num    float64
+	Time   time.Time
+	Bool   bool
+}
+
+func (e *Logical) Pos() int    { return e.Left.Pos() }
+func (e *Not) Pos() int        { return e.Column }
+func (e *Comparison) Pos() int { return e.Column }
+
+// Error is a parse or validation error at a column (1-based) of the expression
+type Error struct {
+	Column  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column, e.Message)
+}
+
+func errorf(column int, format string, args ...any) *Error {
+	return &Error{Column: column, Message: fmt.Sprintf(format, args...)}
+}
+
+// Parse parses an expression. Errors are *Error.
+func Parse(s string) (Expr, error) {
+	if len([]rune(s)) > MaxLength {
+		return nil, errorf(MaxLength+1, "filter must be %d characters or less", MaxLength)
+	}
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, errorf(1, "filter is empty")
+	}
+	expr, err := p.or(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, errorf(t.column, "unexpected %s, expected AND or OR", t)
+	}
+	if p.comparisons > MaxComparisons {
+		return nil, errorf(1, "filter has more than %d comparisons", MaxComparisons)
+	}
+	return expr, nil
+}
+
+// Fields looks up the type of a field, reporting whether the field can be filtered on
+type Fields func(field string) (Type, bool)
+
+// Check validates that an expression only compares allowed fields with values of their type,
+// and that ~ is only applied to strings and ordering operators not to booleans. Errors are *Error.
+func Check(expr Expr, fields Fields) error {
+	switch e := expr.(type) {
+	case *Logical:
+		if err := Check(e.Left, fields); err != nil {
+			return err
+		}
+		return Check(e.Right, fields)
+	case *Not:
+		return Check(e.X, fields)
+	case *Comparison:
+		typ, ok := fields(e.Field)
+		if !ok {
+			return errorf(e.Column, "unknown field %q", e.Field)
+		}
+		if e.Value.Type != typ {
+			return errorf(e.Value.Column, "%s compares with a %s, got a %s", e.Field, typ, e.Value.Type)
+		}
+		switch {
+		case e.Op == OpContains && typ != String:
+			return errorf(e.Column, "~ only applies to strings, %s is a %s", e.Field, typ)
+		case typ == Bool && e.Op != OpEq && e.Op != OpNe:
+			return errorf(e.Column, "%s is a boolean and only supports = and !=", e.Field)
+		}
+	}
+	return nil
+}
+
+// Walk calls fn for every comparison of an expression, left to right
+func Walk(expr Expr, fn func(*Comparison)) {
+	switch e := expr.(type) {
+	case *Logical:
+		Walk(e.Left, fn)
+		Walk(e.Right, fn)
+	case *Not:
+		Walk(e.X, fn)
+	case *Comparison:
+		fn(e)
+	}
+}
+
+type parser struct {
+	tokens      []token
+	pos         int
+	comparisons int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// or parses AND-terms separated by OR
+func (p *parser) or(depth int) (Expr, error) {
+	left, err := p.and(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("OR") {
+		p.next()
+		right, err := p.and(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: "OR", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// and parses unary terms separated by AND
+func (p *parser) and(depth int) (Expr, error) {
+	left, err := p.unary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("AND") {
+		p.next()
+		right, err := p.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: "AND", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// unary parses NOT, parenthesized expressions and comparisons
+func (p *parser) unary(depth int) (Expr, error) {
+	t := p.peek()
+	if depth > maxDepth {
+		return nil, errorf(t.column, "filter is nested too deeply")
+	}
+	switch {
+	case t.isKeyword("NOT"):
+		p.next()
+		x, err := p.unary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Column: t.column, X: x}, nil
+	case t.kind == tokLParen:
+		p.next()
+		x, err := p.or(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, errorf(closing.column, "unexpected %s, expected )", closing)
+		}
+		return x, nil
+	case t.kind == tokIdent && !t.isKeyword("AND") && !t.isKeyword("OR"):
+		return p.comparison()
+	}
+	return nil, errorf(t.column, "unexpected %s, expected a field name", t)
+}
+
+// comparison parses `field op value`
+func (p *parser) comparison() (Expr, error) {
+	field := p.next()
+	op := p.next()
+	if op.kind != tokOp {
+		return nil, errorf(op.column, "unexpected %s, expected an operator (=, !=, <, <=, >, >=, ~)", op)
+	}
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	p.comparisons++
+	return &Comparison{Field: field.text, Column: field.column, Op: op.text, Value: value}, nil
+}
+
+// value parses a literal value
+func (p *parser) value() (Value, error) {
+	t := p.next()
+	v := Value{Column: t.column}
+	switch t.kind {
+	case tokString:
+		v.Type, v.Str = String, t.text
+	case tokNumber:
+		v.Type, v.Num = Number, t.num
+	case tokTime:
+		v.Type, v.Time = Time, t.time
+	case tokIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			v.Type, v.Bool = Bool, true
+		case "false":
+			v.Type, v.Bool = Bool, false
+		default:
+			return v, errorf(t.column, "unexpected %s, expected a value (strings are double-quoted)", t)
+		}
+	default:
+		return v, errorf(t.column, "unexpected %s, expected a value", t)
+	}
+	return v, nil
+}
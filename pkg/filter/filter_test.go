@@ -0,0 +1,113 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFields Fields = func(field string) (Type, bool) {
+	switch field {
+	case "name", "team":
+		return String, true
+	case "created_at":
+		return Time, true
+	case "versions.count":
+		return Number, true
+	case "verified":
+		return Bool, true
+	}
+	if strings.HasPrefix(field, "labels.") {
+		return String, true
+	}
+	return 0, false
+}
+
+func TestParse(t *testing.T) {
+	expr, err := Parse(`name ~ "pay" AND created_at > 2026-01-01 AND versions.count >= 3`)
+	require.NoError(t, err)
+	require.NoError(t, Check(expr, testFields))
+
+	var comparisons []*Comparison
+	Walk(expr, func(c *Comparison) { comparisons = append(comparisons, c) })
+	require.Len(t, comparisons, 3)
+	assert.Equal(t, &Comparison{Field: "name", Column: 1, Op: OpContains, Value: Value{Type: String, Column: 8, Str: "pay"}}, comparisons[0])
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), comparisons[1].Value.Time)
+	assert.Equal(t, OpGe, comparisons[2].Op)
+	assert.Equal(t, float64(3), comparisons[2].Value.Num)
+
+	// AND binds tighter than OR
+	and, ok := expr.(*Logical)
+	require.True(t, ok)
+	assert.Equal(t, "AND", and.Op)
+
+	expr, err = Parse(`team = "core" or not (labels.tier = "1" AND created_at < 2026-03-01T12:00:00Z)`)
+	require.NoError(t, err)
+	require.NoError(t, Check(expr, testFields))
+	or := expr.(*Logical)
+	assert.Equal(t, "OR", or.Op)
+	not := or.Right.(*Not)
+	assert.Equal(t, 18, not.Column)
+	assert.Equal(t, "AND", not.X.(*Logical).Op)
+
+	expr, err = Parse(`name = "say \"hi\"" AND verified != false`)
+	require.NoError(t, err)
+	require.NoError(t, Check(expr, testFields))
+	assert.Equal(t, `say "hi"`, expr.(*Logical).Left.(*Comparison).Value.Str)
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		filter  string
+		column  int
+		message string
+	}{
+		{``, 1, "filter is empty"},
+		{`name ~`, 7, "unexpected end of filter, expected a value"},
+		{`name "pay"`, 6, "expected an operator"},
+		{`name = pay`, 8, "strings are double-quoted"},
+		{`name = "pay`, 8, "unterminated string"},
+		{`name = "a" AND`, 15, "expected a field name"},
+		{`name = "a" team = "b"`, 12, "unexpected 'team', expected AND or OR"},
+		{`(name = "a"`, 12, "expected )"},
+		{`created_at > 2026-13-01`, 14, "invalid value"},
+		{`name ! "a"`, 6, "expected !="},
+		{`name = "a" ; drop`, 12, "unexpected character ';'"},
+		{strings.Repeat("(", 20) + `name = "a"` + strings.Repeat(")", 20), 18, "nested too deeply"},
+		{strings.Repeat(`name = "a" OR `, MaxComparisons) + `name = "a"`, 1, "more than 20 comparisons"},
+		{strings.Repeat("x", MaxLength+1), MaxLength + 1, "characters or less"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.filter)
+		var ferr *Error
+		require.ErrorAs(t, err, &ferr, tt.filter)
+		assert.Equal(t, tt.column, ferr.Column, tt.filter)
+		assert.Contains(t, ferr.Message, tt.message, tt.filter)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		filter  string
+		column  int
+		message string
+	}{
+		{`owner = "a"`, 1, `unknown field "owner"`},
+		{`name = "a" AND versions.count = "3"`, 33, "versions.count compares with a number, got a string"},
+		{`created_at > 3`, 14, "compares with a time"},
+		{`versions.count ~ 3`, 1, "~ only applies to strings"},
+		{`verified > true`, 1, "only supports = and !="},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.filter)
+		require.NoError(t, err, tt.filter)
+		err = Check(expr, testFields)
+		var ferr *Error
+		require.ErrorAs(t, err, &ferr, tt.filter)
+		assert.Equal(t, tt.column, ferr.Column, tt.filter)
+		assert.Contains(t, ferr.Message, tt.message, tt.filter)
+	}
+}
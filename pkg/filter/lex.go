@@ -0,0 +1,124 @@
+package filter
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokTime
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind   tokenKind
+	column int
+	text   string
+	num    float64
+	time   time.Time
+}
+
+func (t token) isKeyword(kw string) bool {
+	return t.kind == tokIdent && strings.EqualFold(t.text, kw)
+}
+
+// String describes the token in error messages
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of filter"
+	case tokString:
+		return strconv.Quote(t.text)
+	}
+	return "'" + t.text + "'"
+}
+
+// lex splits an expression into tokens, ending with tokEOF
+func lex(s string) ([]token, error) {
+	runes := []rune(s)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		column := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			kind := tokLParen
+			if r == ')' {
+				kind = tokRParen
+			}
+			tokens = append(tokens, token{kind: kind, column: column, text: string(r)})
+			i++
+		case strings.ContainsRune("=!<>~", r):
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' && r != '=' && r != '~' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, errorf(column, "unexpected '!', expected !=")
+			}
+			tokens = append(tokens, token{kind: tokOp, column: column, text: op})
+			i += len(op)
+		case r == '"':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '"'; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) && (runes[j+1] == '"' || runes[j+1] == '\\') {
+					j++
+				}
+				b.WriteRune(runes[j])
+			}
+			if j == len(runes) {
+				return nil, errorf(column, "unterminated string")
+			}
+			tokens = append(tokens, token{kind: tokString, column: column, text: b.String()})
+			i = j + 1
+		case unicode.IsDigit(r) || r == '-' || r == '.':
+			j := i + 1
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || strings.ContainsRune(".-:+TZtz", runes[j])) {
+				j++
+			}
+			t, err := literal(string(runes[i:j]), column)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t)
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_' || runes[j] == '.' || runes[j] == '-' || runes[j] == '/') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokIdent, column: column, text: string(runes[i:j])})
+			i = j
+		default:
+			return nil, errorf(column, "unexpected character %q", r)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, column: len(runes) + 1}), nil
+}
+
+// literal lexes a number, date or timestamp
+func literal(text string, column int) (token, error) {
+	if t, err := time.Parse(time.DateOnly, text); err == nil {
+		return token{kind: tokTime, column: column, text: text, time: t}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		return token{kind: tokTime, column: column, text: text, time: t}, nil
+	}
+	if n, err := strconv.ParseFloat(text, 64); err == nil {
+		return token{kind: tokNumber, column: column, text: text, num: n}, nil
+	}
+	return token{}, errorf(column, "invalid value %q (expected a number, a date YYYY-MM-DD or an RFC 3339 timestamp)", text)
+}
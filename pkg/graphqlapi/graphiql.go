@@ -0,0 +1,38 @@
+package graphqlapi
+
+import "net/http"
+
+// graphiqlPage loads GraphiQL from unpkg and points it at /graphql. The API key is entered in
+// the headers editor.
+const graphiqlPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Catalog GraphiQL</title>
+  <style>body { margin: 0; height: 100vh; } #graphiql { height: 100vh; }</style>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@3.7.1/graphiql.min.css">
+  <script crossorigin src="https://unpkg.com/react@18.3.1/umd/react.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/react-dom@18.3.1/umd/react-dom.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/graphiql@3.7.1/graphiql.min.js"></script>
+</head>
+<body>
+  <div id="graphiql">Loading…</div>
+  <script>
+    const fetcher = GraphiQL.createFetcher({ url: '/graphql' });
+    ReactDOM.createRoot(document.getElementById('graphiql')).render(
+      React.createElement(GraphiQL, {
+        fetcher,
+        defaultHeaders: JSON.stringify({ 'X-API-Key': '' }, null, 2),
+        isHeadersEditorEnabled: true,
+      }),
+    );
+  </script>
+</body>
+</html>
+`
+
+// GraphiQL serves the GraphiQL IDE for exploring the API in development
+func GraphiQL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(graphiqlPage))
+}
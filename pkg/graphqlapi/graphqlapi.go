@@ -0,0 +1,121 @@
+// Package graphqlapi serves a read-only GraphQL API over services and their versions, so clients
+// can fetch nested data with only the fields they need in one round trip. Nested fields are
+// batched per request, and queries are rejected before execution when they exceed the depth or
+// complexity limits.
+package graphqlapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	"kong/pkg/models"
+)
+
+// maxRequestBytes bounds the size of a POSTed query
+const maxRequestBytes = 1 << 20
+
+// Request is a GraphQL request, POSTed as JSON or passed as GET query parameters
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Handler serves the /graphql endpoint
+type Handler struct {
+	store   *models.Store
+	maxPage int
+	limits  Limits
+	schema  graphql.Schema
+}
+
+// NewHandler creates the GraphQL handler. Pages of services are capped at maxPage like the REST API.
+func NewHandler(store *models.Store, maxPage int, limits Limits) (*Handler, error) {
+	h := &Handler{store: store, maxPage: max(maxPage, 1), limits: limits}
+	schema, err := h.newSchema()
+	if err != nil {
+		return nil, err
+	}
+	h.schema = schema
+	return h, nil
+}
+
+// ServeHTTP executes a GraphQL query. Requests that can't be parsed, fail validation or exceed
+// the limits are answered with 400, everything else with 200 and the errors in the result.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if vars := r.URL.Query().Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				respondErrors(w, http.StatusBadRequest, errors.New("variables must be a JSON object"))
+				return
+			}
+		}
+	case http.MethodPost:
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+		if err != nil {
+			respondErrors(w, http.StatusRequestEntityTooLarge, errors.New("request body too large"))
+			return
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			respondErrors(w, http.StatusBadRequest, errors.New("invalid JSON format"))
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		respondErrors(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	if req.Query == "" {
+		respondErrors(w, http.StatusBadRequest, errors.New("query is required"))
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(req.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		respond(w, http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+		return
+	}
+	if result := graphql.ValidateDocument(&h.schema, doc, nil); !result.IsValid {
+		respond(w, http.StatusBadRequest, &graphql.Result{Errors: result.Errors})
+		return
+	}
+	if err := h.limits.check(doc, req.OperationName, req.Variables, h.maxPage); err != nil {
+		respondErrors(w, http.StatusBadRequest, err)
+		return
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoaders(r.Context(), newLoaders(h.store)),
+	})
+	respond(w, http.StatusOK, result)
+}
+
+// respond writes a GraphQL result
+func respond(w http.ResponseWriter, statusCode int, result *graphql.Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// respondErrors writes a GraphQL result without data
+func respondErrors(w http.ResponseWriter, statusCode int, err error) {
+	respond(w, statusCode, &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(err.Error())}})
+}
@@ -0,0 +1,107 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader_Batches(t *testing.T) {
+	var batches [][]uuid.UUID
+	l := newLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+		batches = append(batches, ids)
+		out := map[uuid.UUID]string{}
+		for _, id := range ids {
+			out[id] = id.String()
+		}
+		delete(out, uuid.Nil)
+		return out, nil
+	})
+
+	ctx := context.Background()
+	a, b := uuid.New(), uuid.New()
+	thunks := []func() (string, bool, error){l.load(ctx, a), l.load(ctx, b), l.load(ctx, a), l.load(ctx, uuid.Nil)}
+	for i, id := range []uuid.UUID{a, b, a} {
+		v, ok, err := thunks[i]()
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, id.String(), v)
+	}
+	_, ok, err := thunks[3]()
+	require.NoError(t, err)
+	assert.False(t, ok, "missing IDs have no value")
+
+	// Every registered ID was fetched in one batch, and loaded IDs are not fetched again
+	require.Len(t, batches, 1)
+	assert.ElementsMatch(t, []uuid.UUID{a, b, uuid.Nil}, batches[0])
+	_, _, _ = l.load(ctx, b)()
+	assert.Len(t, batches, 1)
+}
+
+func TestHandler_Limits(t *testing.T) {
+	h, err := NewHandler(nil, 50, Limits{MaxDepth: 5, MaxComplexity: 200})
+	require.NoError(t, err)
+
+	post := func(query string, variables map[string]any) (int, string) {
+		body, _ := json.Marshal(Request{Query: query, Variables: variables})
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+		return w.Code, w.Body.String()
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		message   string
+	}{
+		{"syntax error", `{ services { `, nil, "Syntax Error"},
+		{"unknown field", `{ services { nodes { owner } } }`, nil, `Cannot query field \"owner\"`},
+		{"too deep", `{ services { nodes { versions { nodes { service { name } } } } } }`, nil, "query depth 6 exceeds the limit of 5"},
+		{"too complex", `{ services(first: 100) { nodes { name versions(first: 10) { nodes { version } } } } }`, nil, "query complexity"},
+		{"too complex by variable", `query($n: Int) { services(first: $n) { nodes { versions { nodes { version } } } } }`, map[string]any{"n": float64(50)}, "query complexity"},
+		{"too deep through fragments", `{ services { ...s } } fragment s on ServiceConnection { nodes { versions { nodes { service { name } } } } }`, nil, "query depth 6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := post(tt.query, tt.variables)
+			assert.Equal(t, http.StatusBadRequest, code)
+			assert.Contains(t, body, tt.message)
+		})
+	}
+
+	t.Run("introspection is not counted", func(t *testing.T) {
+		code, body := post(`{ __schema { types { name fields { name type { name ofType { name ofType { name } } } } } } }`, nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Contains(t, body, `"ServiceVersionConnection"`)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":`)))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/graphql", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "query is required")
+	})
+}
+
+func TestCursor_RoundTrip(t *testing.T) {
+	n, err := decodeCursor(encodeCursor(42))
+	require.NoError(t, err)
+	assert.Equal(t, 42, n)
+
+	for _, c := range []string{"", "!!", encodeCursor(0)[1:], "b2Zmc2V0Oi0x"} {
+		_, err := decodeCursor(c)
+		assert.Error(t, err, c)
+	}
+}
@@ -0,0 +1,131 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits bound the cost of a query, checked before it is executed
+type Limits struct {
+	// MaxDepth is the deepest allowed field nesting, unlimited when 0
+	MaxDepth int
+	// MaxComplexity is the highest allowed query cost, unlimited when 0. Every field costs 1,
+	// and the fields below a connection count once per requested item.
+	MaxComplexity int
+}
+
+// connectionFields are the fields returning paginated connections, whose cost scales with `first`
+var connectionFields = map[string]bool{"services": true, "versions": true}
+
+// check returns an error when the selected operation of doc is deeper or more complex than
+// allowed. Introspection fields are not counted. Connections count at most maxFirst items.
+// doc must have passed validation, which rejects fragment cycles.
+func (l Limits) check(doc *ast.Document, operationName string, variables map[string]any, maxFirst int) error {
+	op := selectOperation(doc, operationName)
+	if op == nil {
+		return nil
+	}
+	c := &costCounter{fragments: map[string]*ast.FragmentDefinition{}, variables: variables, maxFirst: maxFirst}
+	for _, def := range doc.Definitions {
+		if frag, ok := def.(*ast.FragmentDefinition); ok {
+			c.fragments[frag.Name.Value] = frag
+		}
+	}
+
+	depth, complexity := c.selectionSet(op.SelectionSet)
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return fmt.Errorf("query depth %d exceeds the limit of %d", depth, l.MaxDepth)
+	}
+	if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, l.MaxComplexity)
+	}
+	return nil
+}
+
+// selectOperation returns the operation named operationName, or the only operation when it is empty
+func selectOperation(doc *ast.Document, operationName string) *ast.OperationDefinition {
+	var found *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" {
+			if found != nil {
+				return nil
+			}
+			found = op
+		} else if op.Name != nil && op.Name.Value == operationName {
+			return op
+		}
+	}
+	return found
+}
+
+type costCounter struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+	maxFirst  int
+}
+
+// selectionSet returns the depth and complexity of a selection set
+func (c *costCounter) selectionSet(set *ast.SelectionSet) (depth, complexity int) {
+	if set == nil {
+		return 0, 0
+	}
+	for _, sel := range set.Selections {
+		var d, n int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(sel.Name.Value, "__") {
+				continue
+			}
+			d, n = c.selectionSet(sel.SelectionSet)
+			d++
+			if connectionFields[sel.Name.Value] {
+				n *= c.first(sel)
+			}
+			n++
+		case *ast.InlineFragment:
+			d, n = c.selectionSet(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			if frag := c.fragments[sel.Name.Value]; frag != nil {
+				d, n = c.selectionSet(frag.SelectionSet)
+			}
+		}
+		depth = max(depth, d)
+		complexity += n
+	}
+	return depth, complexity
+}
+
+// first returns the page size requested from a connection field
+func (c *costCounter) first(field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(v.Value); err == nil {
+				return c.clampFirst(n)
+			}
+		case *ast.Variable:
+			// Variables decoded from JSON are float64
+			switch n := c.variables[v.Name.Value].(type) {
+			case float64:
+				return c.clampFirst(int(n))
+			case int:
+				return c.clampFirst(n)
+			}
+		}
+	}
+	return min(defaultFirst, c.maxFirst)
+}
+
+func (c *costCounter) clampFirst(n int) int {
+	return min(max(n, 1), c.maxFirst)
+}
@@ -0,0 +1,120 @@
+package graphqlapi
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"kong/pkg/models"
+)
+
+// loader batches lookups by ID. Resolvers register the IDs they need and get a thunk back; the
+// first thunk called fetches every registered ID in one query. graphql-go calls thunks breadth
+// first once a whole level of the response is resolved, so the versions of N listed services
+// are fetched in one query instead of N.
+type loader[V any] struct {
+	fetch func(context.Context, []uuid.UUID) (map[uuid.UUID]V, error)
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]bool
+	fetched map[uuid.UUID]bool
+	values  map[uuid.UUID]V
+	errs    map[uuid.UUID]error
+}
+
+func newLoader[V any](fetch func(context.Context, []uuid.UUID) (map[uuid.UUID]V, error)) *loader[V] {
+	return &loader[V]{
+		fetch:   fetch,
+		pending: map[uuid.UUID]bool{},
+		fetched: map[uuid.UUID]bool{},
+		values:  map[uuid.UUID]V{},
+		errs:    map[uuid.UUID]error{},
+	}
+}
+
+// load registers id with the next batch. The returned thunk reports whether a value exists for id.
+func (l *loader[V]) load(ctx context.Context, id uuid.UUID) func() (V, bool, error) {
+	l.mu.Lock()
+	if !l.fetched[id] {
+		l.pending[id] = true
+	}
+	l.mu.Unlock()
+
+	return func() (V, bool, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !l.fetched[id] {
+			l.dispatch(ctx)
+		}
+		v, ok := l.values[id]
+		return v, ok, l.errs[id]
+	}
+}
+
+// dispatch fetches the pending IDs; l.mu must be held
+func (l *loader[V]) dispatch(ctx context.Context) {
+	ids := make([]uuid.UUID, 0, len(l.pending))
+	for id := range l.pending {
+		ids = append(ids, id)
+	}
+	clear(l.pending)
+
+	values, err := l.fetch(ctx, ids)
+	for _, id := range ids {
+		l.fetched[id] = true
+		if err != nil {
+			l.errs[id] = err
+		} else if v, ok := values[id]; ok {
+			l.values[id] = v
+		}
+	}
+}
+
+// versionWindow are the arguments of a Service.versions field. Fields with the same arguments
+// are batched together.
+type versionWindow struct {
+	verifiedOnly  bool
+	offset, limit int
+}
+
+// loaders are the per-request loaders of a GraphQL query
+type loaders struct {
+	store    *models.Store
+	services *loader[models.Service]
+
+	mu       sync.Mutex
+	versions map[versionWindow]*loader[models.VersionWindow]
+}
+
+func newLoaders(store *models.Store) *loaders {
+	return &loaders{
+		store:    store,
+		services: newLoader(store.GetServicesByID),
+		versions: map[versionWindow]*loader[models.VersionWindow]{},
+	}
+}
+
+// versionWindows returns the loader of the version windows of services selected by w
+func (l *loaders) versionWindows(w versionWindow) *loader[models.VersionWindow] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ld, ok := l.versions[w]
+	if !ok {
+		ld = newLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]models.VersionWindow, error) {
+			return l.store.ListVersionWindows(ctx, ids, w.verifiedOnly, w.offset, w.limit)
+		})
+		l.versions[w] = ld
+	}
+	return ld
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,369 @@
+package graphqlapi
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	"github.com/rs/zerolog/log"
+
+	"kong/pkg/catalog/validation"
+	"kong/pkg/models"
+)
+
+// defaultFirst is the page size of connections without a `first` argument, unless the maximum
+// page size is smaller
+const defaultFirst = 20
+
+// connection is a page of a Relay-style connection
+type connection struct {
+	Edges      []edge   `json:"edges"`
+	Nodes      []any    `json:"nodes"`
+	PageInfo   pageInfo `json:"pageInfo"`
+	TotalCount *int     `json:"totalCount"`
+}
+
+type edge struct {
+	Cursor string `json:"cursor"`
+	Node   any    `json:"node"`
+}
+
+type pageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+// label is a key/value pair of Service.labels
+type label struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// newConnection builds the page of items starting at offset
+func newConnection[T any](items []T, offset int, hasNext bool) *connection {
+	c := &connection{Edges: make([]edge, len(items)), Nodes: make([]any, len(items))}
+	for i, it := range items {
+		c.Edges[i] = edge{Cursor: encodeCursor(offset + i), Node: it}
+		c.Nodes[i] = it
+	}
+	c.PageInfo.HasNextPage = hasNext
+	c.PageInfo.HasPreviousPage = offset > 0
+	if len(items) > 0 {
+		c.PageInfo.StartCursor = &c.Edges[0].Cursor
+		c.PageInfo.EndCursor = &c.Edges[len(items)-1].Cursor
+	}
+	return c
+}
+
+// encodeCursor returns the opaque cursor of the item at offset
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+// decodeCursor returns the offset of a cursor
+func decodeCursor(cursor string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		if s, ok := strings.CutPrefix(string(b), "offset:"); ok {
+			if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+				return n, nil
+			}
+		}
+	}
+	return 0, errors.New("invalid cursor")
+}
+
+// pageArgs returns the page size and offset requested by the `first` and `after` arguments;
+// first is at most maxFirst
+func pageArgs(args map[string]any, maxFirst int) (first, offset int, err error) {
+	first = min(defaultFirst, maxFirst)
+	if n, ok := args["first"].(int); ok {
+		if n < 1 || n > maxFirst {
+			return 0, 0, errors.New("first must be between 1 and " + strconv.Itoa(maxFirst))
+		}
+		first = n
+	}
+	if after, ok := args["after"].(string); ok {
+		n, err := decodeCursor(after)
+		if err != nil {
+			return 0, 0, err
+		}
+		offset = n + 1
+	}
+	return first, offset, nil
+}
+
+// connectionArgs are the pagination arguments of connection fields of at most maxFirst items
+func connectionArgs(maxFirst int, extra graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	args := graphql.FieldConfigArgument{
+		"first": &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "Number of items, " + strconv.Itoa(min(defaultFirst, maxFirst)) + " by default and at most " + strconv.Itoa(maxFirst),
+		},
+		"after": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "Cursor of the item the page starts after",
+		},
+	}
+	for k, v := range extra {
+		args[k] = v
+	}
+	return args
+}
+
+// connectionType returns the connection and edge types of nodes
+func connectionType(name string, node *graphql.Object, pageInfoType *graphql.Object, withTotal bool) *graphql.Object {
+	edgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: name + "Edge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"node":   &graphql.Field{Type: graphql.NewNonNull(node)},
+		},
+	})
+	fields := graphql.Fields{
+		"edges":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edgeType)))},
+		"nodes":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(node)))},
+		"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+	}
+	if withTotal {
+		fields["totalCount"] = &graphql.Field{Type: graphql.NewNonNull(graphql.Int)}
+	}
+	return graphql.NewObject(graphql.ObjectConfig{Name: name + "Connection", Fields: fields})
+}
+
+// newSchema builds the GraphQL schema of the catalog
+func (h *Handler) newSchema() (graphql.Schema, error) {
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"hasPreviousPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"startCursor":     &graphql.Field{Type: graphql.String},
+			"endCursor":       &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	labelType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Label",
+		Fields: graphql.Fields{
+			"key":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"value": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	// Service and ServiceVersion refer to each other, so their fields are thunks
+	var serviceType, versionType *graphql.Object
+	var versionConnectionType *graphql.Object
+
+	serviceType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Service",
+		Description: "A service in the catalog",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+				"name":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"description": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"team":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"labels": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(labelType))),
+					Resolve: resolveLabels,
+				},
+				"revision":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+				"updatedAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+				"versions": &graphql.Field{
+					Type:        graphql.NewNonNull(versionConnectionType),
+					Description: "Versions of the service, newest first",
+					Args: connectionArgs(h.maxPage, graphql.FieldConfigArgument{
+						"verified": &graphql.ArgumentConfig{
+							Type:        graphql.Boolean,
+							Description: "Only versions whose signature was verified against a trusted key",
+						},
+					}),
+					Resolve: h.resolveVersions,
+				},
+			}
+		}),
+	})
+
+	versionType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "ServiceVersion",
+		Description: "A registered version of a service",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":              &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+				"serviceId":       &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+				"version":         &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"commit":          &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"artifactDigests": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+				"verified":        &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+				"keyFingerprint":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"createdAt":       &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+				"service": &graphql.Field{
+					Type:    graphql.NewNonNull(serviceType),
+					Resolve: h.resolveVersionService,
+				},
+			}
+		}),
+	})
+
+	versionConnectionType = connectionType("ServiceVersion", versionType, pageInfoType, true)
+	serviceConnectionType := connectionType("Service", serviceType, pageInfoType, false)
+
+	serviceSortType := graphql.NewEnum(graphql.EnumConfig{
+		Name: "ServiceSort",
+		Values: graphql.EnumValueConfigMap{
+			"NAME":       &graphql.EnumValueConfig{Value: "name"},
+			"CREATED_AT": &graphql.EnumValueConfig{Value: "created_at"},
+			"UPDATED_AT": &graphql.EnumValueConfig{Value: "updated_at"},
+		},
+	})
+	orderType := graphql.NewEnum(graphql.EnumConfig{
+		Name: "Order",
+		Values: graphql.EnumValueConfigMap{
+			"ASC":  &graphql.EnumValueConfig{Value: "asc"},
+			"DESC": &graphql.EnumValueConfig{Value: "desc"},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"services": &graphql.Field{
+				Type:        graphql.NewNonNull(serviceConnectionType),
+				Description: "Services, optionally filtered by a name prefix",
+				Args: connectionArgs(h.maxPage, graphql.FieldConfigArgument{
+					"q":     &graphql.ArgumentConfig{Type: graphql.String, Description: "Name prefix"},
+					"sort":  &graphql.ArgumentConfig{Type: serviceSortType, DefaultValue: "name"},
+					"order": &graphql.ArgumentConfig{Type: orderType, DefaultValue: "asc"},
+				}),
+				Resolve: h.resolveServices,
+			},
+			"service": &graphql.Field{
+				Type:        serviceType,
+				Description: "A service by ID, null when it doesn't exist",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: h.resolveService,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// resolveServices resolves Query.services
+func (h *Handler) resolveServices(p graphql.ResolveParams) (any, error) {
+	first, offset, err := pageArgs(p.Args, h.maxPage)
+	if err != nil {
+		return nil, err
+	}
+	q, _ := p.Args["q"].(string)
+	sort, _ := p.Args["sort"].(string)
+	order, _ := p.Args["order"].(string)
+
+	query := url.Values{}
+	if q != "" {
+		query.Set("q", q)
+	}
+	if err := validation.ValidateListServicesQuery(query, h.maxPage); err != nil {
+		return nil, err
+	}
+
+	items, err := h.store.ListServices(p.Context, q, sort, order, first, offset, false)
+	if err != nil {
+		return nil, internal("Failed to list services", err)
+	}
+
+	// A full page has a next page when another service follows it
+	hasNext := false
+	if len(items) == first {
+		more, err := h.store.ListServices(p.Context, q, sort, order, 1, offset+first, false)
+		if err != nil {
+			return nil, internal("Failed to list services", err)
+		}
+		hasNext = len(more) > 0
+	}
+
+	return newConnection(items, offset, hasNext), nil
+}
+
+// resolveService resolves Query.service
+func (h *Handler) resolveService(p graphql.ResolveParams) (any, error) {
+	idStr, _ := p.Args["id"].(string)
+	if err := validation.ValidateID(idStr); err != nil {
+		return nil, err
+	}
+	it, err := h.store.GetService(p.Context, uuid.MustParse(idStr), false)
+	if err != nil {
+		return nil, internal("Failed to get service", err)
+	}
+	if it == nil {
+		return nil, nil
+	}
+	return *it, nil
+}
+
+// resolveVersions resolves Service.versions, batching the versions of all services in the response
+// that request the same page
+func (h *Handler) resolveVersions(p graphql.ResolveParams) (any, error) {
+	first, offset, err := pageArgs(p.Args, h.maxPage)
+	if err != nil {
+		return nil, err
+	}
+	verifiedOnly, _ := p.Args["verified"].(bool)
+
+	svc := p.Source.(models.Service)
+	load := loadersFrom(p.Context).versionWindows(versionWindow{verifiedOnly: verifiedOnly, offset: offset, limit: first}).load(p.Context, svc.ID)
+	return func() (any, error) {
+		window, _, err := load()
+		if err != nil {
+			return nil, internal("Failed to list service versions", err)
+		}
+
+		c := newConnection(window.Versions, offset, offset+len(window.Versions) < window.Total)
+		c.TotalCount = &window.Total
+		return c, nil
+	}, nil
+}
+
+// resolveVersionService resolves ServiceVersion.service, batching the services of all versions in the response
+func (h *Handler) resolveVersionService(p graphql.ResolveParams) (any, error) {
+	v := p.Source.(models.ServiceVersion)
+	load := loadersFrom(p.Context).services.load(p.Context, v.ServiceID)
+	return func() (any, error) {
+		svc, ok, err := load()
+		if err != nil {
+			return nil, internal("Failed to get service", err)
+		}
+		if !ok {
+			return nil, errors.New("Service not found")
+		}
+		return svc, nil
+	}, nil
+}
+
+// resolveLabels resolves Service.labels as key/value pairs sorted by key
+func resolveLabels(p graphql.ResolveParams) (any, error) {
+	svc := p.Source.(models.Service)
+	labels := make([]label, 0, len(svc.Labels))
+	for k, v := range svc.Labels {
+		labels = append(labels, label{Key: k, Value: v})
+	}
+	slices.SortFunc(labels, func(a, b label) int { return strings.Compare(a.Key, b.Key) })
+	return labels, nil
+}
+
+// internal logs an unexpected error and returns message, keeping database details out of responses
+func internal(message string, err error) error {
+	log.Error().Err(err).Msg(message)
+	return errors.New(message)
+}
@@ -0,0 +1,329 @@
+// Package grpcapi serves the catalog.v1.CatalogService gRPC API, mirroring the services and
+// versions endpoints of the REST API on the same store and validation rules. The server also
+// exposes the standard gRPC health service and server reflection.
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	catalogv1 "kong/pkg/api/catalog/v1"
+	"kong/pkg/catalog/validation"
+	"kong/pkg/catalog/writes"
+	"kong/pkg/models"
+	"kong/pkg/watch"
+)
+
+// Server implements catalogv1.CatalogServiceServer
+type Server struct {
+	catalogv1.UnimplementedCatalogServiceServer
+	store     *models.Store
+	writes    *writes.Hooks
+	changes   *watch.Hub
+	heartbeat time.Duration
+}
+
+// NewServer creates a gRPC server for the catalog API accepting the given API keys. writes
+// verifies version signatures and rescores services after writes, like in the REST API.
+// WatchServices re-reads the change log every heartbeat interval while idle.
+func NewServer(store *models.Store, writes *writes.Hooks, changes *watch.Hub, validAPIKeys []string, heartbeat time.Duration) *grpc.Server {
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	auth := &authenticator{validAPIKeys: validAPIKeys}
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(auth.unary),
+		grpc.ChainStreamInterceptor(auth.stream),
+	)
+
+	catalogv1.RegisterCatalogServiceServer(srv, &Server{store: store, writes: writes, changes: changes, heartbeat: heartbeat})
+
+	healthSrv := health.NewServer()
+	healthSrv.SetServingStatus(catalogv1.CatalogService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, healthSrv)
+
+	reflection.Register(srv)
+	return srv
+}
+
+// ListServices lists services
+func (s *Server) ListServices(ctx context.Context, req *catalogv1.ListServicesRequest) (*catalogv1.ListServicesResponse, error) {
+	query := url.Values{}
+	for k, v := range map[string]string{"q": req.Q, "sort": req.Sort, "order": req.Order} {
+		if v != "" {
+			query.Set(k, v)
+		}
+	}
+	if req.Limit != 0 {
+		query.Set("limit", strconv.Itoa(int(req.Limit)))
+	}
+	if req.Offset != 0 {
+		query.Set("offset", strconv.Itoa(int(req.Offset)))
+	}
+	if err := validation.ValidateListServicesQuery(query, s.store.MaxPageSize()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	items, err := s.store.ListServices(ctx, req.Q, req.Sort, req.Order, int(req.Limit), int(req.Offset), req.IncludeVersions)
+	if err != nil {
+		return nil, internal("Failed to list services", err)
+	}
+
+	resp := &catalogv1.ListServicesResponse{Services: make([]*catalogv1.Service, len(items))}
+	for i := range items {
+		resp.Services[i] = toService(&items[i])
+	}
+	return resp, nil
+}
+
+// GetService returns a service, optionally as it was at a point in time
+func (s *Server) GetService(ctx context.Context, req *catalogv1.GetServiceRequest) (*catalogv1.GetServiceResponse, error) {
+	id, err := parseID("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	var it *models.Service
+	if req.AsOf != nil {
+		if err := req.AsOf.CheckValid(); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "as_of: "+err.Error())
+		}
+		it, err = s.store.GetServiceAsOf(ctx, id, req.AsOf.AsTime(), req.IncludeVersions)
+	} else {
+		it, err = s.store.GetService(ctx, id, req.IncludeVersions)
+	}
+	if err != nil {
+		return nil, internal("Failed to get service", err)
+	}
+	if it == nil {
+		return nil, status.Error(codes.NotFound, "Service not found")
+	}
+
+	return &catalogv1.GetServiceResponse{Service: toService(it)}, nil
+}
+
+// CreateService registers a service
+func (s *Server) CreateService(ctx context.Context, req *catalogv1.CreateServiceRequest) (*catalogv1.CreateServiceResponse, error) {
+	create := validation.CreateServiceRequest{Name: req.Name, Description: req.Description, Team: req.Team, Labels: req.Labels}
+	if msg := validation.ValidateCreateService(&create); msg != "" {
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+
+	service := &models.Service{
+		Name:        create.Name,
+		Description: create.Description,
+		Team:        create.Team,
+		Labels:      create.Labels,
+		Versions:    []models.ServiceVersion{},
+	}
+	if err := s.store.CreateService(ctx, service); err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, status.Error(codes.AlreadyExists, "Service with this name already exists")
+		}
+		return nil, internal("Failed to create service", err)
+	}
+	s.writes.Rescore(ctx, service.ID)
+
+	return &catalogv1.CreateServiceResponse{Service: toService(service)}, nil
+}
+
+// ListVersions lists the versions of a service
+func (s *Server) ListVersions(ctx context.Context, req *catalogv1.ListVersionsRequest) (*catalogv1.ListVersionsResponse, error) {
+	id, err := parseID("service_id", req.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.store.ListVersions(ctx, id, req.VerifiedOnly)
+	if err != nil {
+		return nil, internal("Failed to list service versions", err)
+	}
+
+	resp := &catalogv1.ListVersionsResponse{Versions: make([]*catalogv1.ServiceVersion, len(versions))}
+	for i := range versions {
+		resp.Versions[i] = toServiceVersion(&versions[i])
+	}
+	return resp, nil
+}
+
+// CreateServiceVersion registers a version of a service, verifying its signature when given
+func (s *Server) CreateServiceVersion(ctx context.Context, req *catalogv1.CreateServiceVersionRequest) (*catalogv1.CreateServiceVersionResponse, error) {
+	serviceID, err := parseID("service_id", req.ServiceId)
+	if err != nil {
+		return nil, err
+	}
+	create := validation.CreateServiceVersionRequest{
+		Version:         req.Version,
+		Commit:          req.Commit,
+		ArtifactDigests: req.ArtifactDigests,
+		Signature:       req.Signature,
+	}
+	if msg := validation.ValidateCreateServiceVersion(&create); msg != "" {
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+
+	version := &models.ServiceVersion{
+		ID:              models.GenerateUUID(),
+		ServiceID:       serviceID,
+		Version:         create.Version,
+		Commit:          create.Commit,
+		ArtifactDigests: create.ArtifactDigests,
+		Signature:       create.Signature,
+		CreatedAt:       time.Now().UTC(),
+	}
+	if create.Signature != "" {
+		if err := s.writes.VerifySignature(ctx, version); err != nil {
+			return nil, internal("Failed to verify signature", err)
+		}
+	}
+
+	if err := s.store.CreateServiceVersion(ctx, version); err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, status.Error(codes.AlreadyExists, "Version already exists for this service")
+		}
+		if strings.Contains(err.Error(), "foreign key") {
+			return nil, status.Error(codes.NotFound, "Service not found")
+		}
+		return nil, internal("Failed to create service version", err)
+	}
+	s.writes.Rescore(ctx, serviceID)
+
+	return &catalogv1.CreateServiceVersionResponse{Version: toServiceVersion(version)}, nil
+}
+
+// WatchServices streams change events after after_event_id, or only new changes when it isn't set
+func (s *Server) WatchServices(req *catalogv1.WatchServicesRequest, stream grpc.ServerStreamingServer[catalogv1.WatchServicesResponse]) error {
+	ctx := stream.Context()
+
+	query := url.Values{"service_id": req.ServiceIds}
+	for k, v := range req.Labels {
+		query.Add("label", k+"="+v)
+	}
+	if req.AfterEventId != nil {
+		query.Set("last_event_id", strconv.FormatInt(*req.AfterEventId, 10))
+	}
+	if err := validation.ValidateWatchQuery(query, ""); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	f := models.ChangeFilter{Labels: req.Labels}
+	for _, id := range req.ServiceIds {
+		f.ServiceIDs = append(f.ServiceIDs, uuid.MustParse(id))
+	}
+	if req.AfterEventId != nil {
+		f.AfterID = *req.AfterEventId
+	} else {
+		latest, err := s.store.LatestOutboxEventID(ctx)
+		if err != nil {
+			return internal("Failed to read change log", err)
+		}
+		f.AfterID = latest
+	}
+
+	// Headers tell the client the stream is established, so it doesn't miss changes made after this
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	send := func(events []models.OutboxEvent) error {
+		for _, e := range events {
+			event, err := toServiceEvent(e)
+			if err != nil {
+				return internal("Failed to decode change event", err)
+			}
+			if err := stream.Send(&catalogv1.WatchServicesResponse{Event: event}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// HTTP/2 keeps idle streams open, so there is nothing to send while idle
+	idle := func() error { return nil }
+
+	err := s.changes.Follow(ctx, s.store, f, s.heartbeat, send, idle)
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return internal("Failed to read change log", err)
+}
+
+// parseID parses a UUID request field
+func parseID(field, id string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, field+": must be a valid UUID")
+	}
+	return parsed, nil
+}
+
+func toService(s *models.Service) *catalogv1.Service {
+	pb := &catalogv1.Service{
+		Id:          s.ID.String(),
+		Name:        s.Name,
+		Description: s.Description,
+		Team:        s.Team,
+		Labels:      s.Labels,
+		Revision:    int64(s.Revision),
+		CreatedAt:   timestamppb.New(s.CreatedAt),
+		UpdatedAt:   timestamppb.New(s.UpdatedAt),
+	}
+	for i := range s.Versions {
+		pb.Versions = append(pb.Versions, toServiceVersion(&s.Versions[i]))
+	}
+	return pb
+}
+
+func toServiceVersion(v *models.ServiceVersion) *catalogv1.ServiceVersion {
+	return &catalogv1.ServiceVersion{
+		Id:              v.ID.String(),
+		ServiceId:       v.ServiceID.String(),
+		Version:         v.Version,
+		Commit:          v.Commit,
+		ArtifactDigests: v.ArtifactDigests,
+		Signature:       v.Signature,
+		Verified:        v.Verified,
+		KeyFingerprint:  v.KeyFingerprint,
+		CreatedAt:       timestamppb.New(v.CreatedAt),
+	}
+}
+
+// toServiceEvent decodes the payload of a change event into the changed service or version
+func toServiceEvent(e models.OutboxEvent) (*catalogv1.ServiceEvent, error) {
+	event := &catalogv1.ServiceEvent{
+		Id:        e.ID,
+		Type:      e.Type,
+		ServiceId: e.ServiceID.String(),
+		CreatedAt: timestamppb.New(e.CreatedAt),
+	}
+	if e.Type == models.EventVersionCreated {
+		var v models.ServiceVersion
+		if err := json.Unmarshal(e.Payload, &v); err != nil {
+			return nil, err
+		}
+		event.Data = &catalogv1.ServiceEvent_Version{Version: toServiceVersion(&v)}
+	} else {
+		var svc models.Service
+		if err := json.Unmarshal(e.Payload, &svc); err != nil {
+			return nil, err
+		}
+		event.Data = &catalogv1.ServiceEvent_Service{Service: toService(&svc)}
+	}
+	return event, nil
+}
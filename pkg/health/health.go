@@ -0,0 +1,161 @@
+// Package health actively probes the health-check URLs registered for services and tracks their
+// status with success/failure threshold hysteresis, so a single flaky probe doesn't flip a status.
+package health
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"kong/pkg/models"
+	"kong/pkg/worker"
+)
+
+// Store is the persistence the scheduler needs; *models.Store implements it
+type Store interface {
+	ClaimDueHealthChecks(ctx context.Context, limit int) ([]models.HealthCheck, error)
+	RecordHealthResult(ctx context.Context, check *models.HealthCheck, transition *models.HealthTransition) error
+}
+
+// Result is the outcome of a single probe
+type Result struct {
+	OK      bool
+	Latency time.Duration
+	Error   string
+}
+
+// Probe requests url once. Any 2xx or 3xx response within the timeout is a success.
+func Probe(ctx context.Context, client *http.Client, url string, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Result{Error: err.Error()}
+	}
+	req.Header.Set("User-Agent", "kong-catalog-health-probe")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		return Result{Latency: latency, Error: worker.Truncate(err.Error())}
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return Result{Latency: latency, Error: fmt.Sprintf("unexpected status %d", resp.StatusCode)}
+	}
+	return Result{OK: true, Latency: latency}
+}
+
+// Apply folds a probe result into the check's state. The status only changes once
+// SuccessThreshold consecutive successes or FailureThreshold consecutive failures are
+// reached. It returns the previous status when the status changed, or "" otherwise.
+func Apply(check *models.HealthCheck, res Result, at time.Time) string {
+	previous := check.Status
+	check.LastCheckedAt = &at
+	check.LastLatencyMS = int(res.Latency.Milliseconds())
+	check.LastError = res.Error
+
+	if res.OK {
+		check.ConsecutiveSuccesses++
+		check.ConsecutiveFailures = 0
+		if check.ConsecutiveSuccesses >= check.SuccessThreshold {
+			check.Status = models.HealthHealthy
+		}
+	} else {
+		check.ConsecutiveFailures++
+		check.ConsecutiveSuccesses = 0
+		if check.ConsecutiveFailures >= check.FailureThreshold {
+			check.Status = models.HealthUnhealthy
+		}
+	}
+
+	if check.Status == previous {
+		return ""
+	}
+	return previous
+}
+
+// Scheduler probes due health checks with bounded concurrency
+type Scheduler struct {
+	store  Store
+	client *http.Client
+	pool   *worker.Pool[models.HealthCheck]
+}
+
+// NewScheduler creates a scheduler running at most concurrency probes at a time
+func NewScheduler(store Store, client *http.Client, concurrency int) *Scheduler {
+	if client == nil {
+		client = &http.Client{}
+	}
+	s := &Scheduler{store: store, client: client}
+	s.pool = worker.New(concurrency, time.Second, store.ClaimDueHealthChecks, s.probe)
+	return s
+}
+
+// Run claims and probes due checks until ctx is cancelled, then waits for in-flight probes
+func (s *Scheduler) Run(ctx context.Context) {
+	s.pool.Run(ctx, "Failed to claim due health checks")
+}
+
+// RunOnce probes the checks that are currently due and waits for the results to be recorded
+func (s *Scheduler) RunOnce(ctx context.Context) error {
+	return s.pool.RunOnce(ctx)
+}
+
+func (s *Scheduler) probe(ctx context.Context, check *models.HealthCheck) {
+	res := Probe(ctx, s.client, check.URL, time.Duration(check.TimeoutMS)*time.Millisecond)
+	if ctx.Err() != nil {
+		return
+	}
+
+	now := time.Now()
+	var transition *models.HealthTransition
+	if from := Apply(check, res, now); from != "" {
+		reason := "probe succeeded"
+		if !res.OK {
+			reason = res.Error
+		}
+		transition = &models.HealthTransition{
+			CheckID:     check.ID,
+			ServiceID:   check.ServiceID,
+			Environment: check.Environment,
+			From:        from,
+			To:          check.Status,
+			Reason:      reason,
+			CreatedAt:   now,
+		}
+		log.Info().Str("service_id", check.ServiceID.String()).Str("url", check.URL).
+			Str("from", from).Str("to", check.Status).Msg("Health status changed")
+	}
+
+	if err := s.store.RecordHealthResult(ctx, check, transition); err != nil {
+		log.Error().Err(err).Str("check_id", check.ID.String()).Msg("Failed to record health probe result")
+	}
+}
+
+// Summarize returns the overall status of a set of checks: unhealthy if any check is
+// unhealthy, healthy if all are healthy, unknown otherwise (including no checks).
+func Summarize(checks []models.HealthCheck) string {
+	if len(checks) == 0 {
+		return models.HealthUnknown
+	}
+	status := models.HealthHealthy
+	for _, c := range checks {
+		switch c.Status {
+		case models.HealthUnhealthy:
+			return models.HealthUnhealthy
+		case models.HealthHealthy:
+		default:
+			status = models.HealthUnknown
+		}
+	}
+	return status
+}
@@ -0,0 +1,168 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kong/pkg/models"
+)
+
+// fakeStore hands out every check on each claim and records results in memory
+type fakeStore struct {
+	mu          sync.Mutex
+	checks      []models.HealthCheck
+	transitions []models.HealthTransition
+}
+
+func (f *fakeStore) ClaimDueHealthChecks(ctx context.Context, limit int) ([]models.HealthCheck, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := min(limit, len(f.checks))
+	return append([]models.HealthCheck{}, f.checks[:n]...), nil
+}
+
+func (f *fakeStore) RecordHealthResult(ctx context.Context, check *models.HealthCheck, transition *models.HealthTransition) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.checks {
+		if f.checks[i].ID == check.ID {
+			f.checks[i] = *check
+		}
+	}
+	if transition != nil {
+		f.transitions = append(f.transitions, *transition)
+	}
+	return nil
+}
+
+func newCheck(url string) models.HealthCheck {
+	return models.HealthCheck{
+		ID:               uuid.New(),
+		ServiceID:        uuid.New(),
+		Environment:      "prod",
+		URL:              url,
+		IntervalSeconds:  10,
+		TimeoutMS:        200,
+		SuccessThreshold: 2,
+		FailureThreshold: 2,
+		Status:           models.HealthUnknown,
+	}
+}
+
+func TestProbe(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+
+	ctx := context.Background()
+	assert.True(t, Probe(ctx, http.DefaultClient, ok.URL, time.Second).OK)
+
+	res := Probe(ctx, http.DefaultClient, failing.URL, time.Second)
+	assert.False(t, res.OK)
+	assert.Equal(t, "unexpected status 503", res.Error)
+
+	res = Probe(ctx, http.DefaultClient, slow.URL, 50*time.Millisecond)
+	assert.False(t, res.OK)
+	assert.Contains(t, res.Error, "deadline exceeded")
+}
+
+func TestApply_Hysteresis(t *testing.T) {
+	check := newCheck("http://unused")
+	now := time.Now()
+	pass, fail := Result{OK: true}, Result{Error: "down"}
+
+	// One success isn't enough to leave unknown with a success threshold of 2
+	assert.Equal(t, "", Apply(&check, pass, now))
+	assert.Equal(t, models.HealthUnknown, check.Status)
+	assert.Equal(t, models.HealthUnknown, Apply(&check, pass, now))
+	assert.Equal(t, models.HealthHealthy, check.Status)
+
+	// A single failure between successes doesn't flip the status
+	assert.Equal(t, "", Apply(&check, fail, now))
+	assert.Equal(t, "", Apply(&check, pass, now))
+	assert.Equal(t, "", Apply(&check, fail, now))
+	assert.Equal(t, models.HealthHealthy, check.Status)
+
+	assert.Equal(t, models.HealthHealthy, Apply(&check, fail, now))
+	assert.Equal(t, models.HealthUnhealthy, check.Status)
+	assert.Equal(t, "down", check.LastError)
+	assert.Equal(t, 2, check.ConsecutiveFailures)
+}
+
+func TestScheduler(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	store := &fakeStore{}
+	for i := 0; i < 5; i++ {
+		store.checks = append(store.checks, newCheck(server.URL))
+	}
+	scheduler := NewScheduler(store, server.Client(), 2)
+	ctx := context.Background()
+
+	t.Run("Concurrency is bounded", func(t *testing.T) {
+		require.NoError(t, scheduler.RunOnce(ctx))
+		assert.LessOrEqual(t, maxInFlight.Load(), int32(2))
+	})
+
+	t.Run("Transitions are recorded after the threshold", func(t *testing.T) {
+		// Two rounds of two claimed checks: the first two checks become healthy
+		require.NoError(t, scheduler.RunOnce(ctx))
+		require.Len(t, store.transitions, 2)
+		assert.Equal(t, models.HealthUnknown, store.transitions[0].From)
+		assert.Equal(t, models.HealthHealthy, store.transitions[0].To)
+
+		healthy.Store(false)
+		require.NoError(t, scheduler.RunOnce(ctx))
+		require.NoError(t, scheduler.RunOnce(ctx))
+		require.Len(t, store.transitions, 4)
+		assert.Equal(t, models.HealthUnhealthy, store.transitions[3].To)
+		assert.Equal(t, "unexpected status 500", store.transitions[3].Reason)
+	})
+}
+
+func TestSummarize(t *testing.T) {
+	check := func(status string) models.HealthCheck { return models.HealthCheck{Status: status} }
+
+	assert.Equal(t, models.HealthUnknown, Summarize(nil))
+	assert.Equal(t, models.HealthHealthy, Summarize([]models.HealthCheck{check("healthy"), check("healthy")}))
+	assert.Equal(t, models.HealthUnknown, Summarize([]models.HealthCheck{check("healthy"), check("unknown")}))
+	assert.Equal(t, models.HealthUnhealthy, Summarize([]models.HealthCheck{check("unknown"), check("unhealthy")}))
+}
@@ -0,0 +1,247 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Audit actions
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditReplace = "replace"
+	AuditRevert  = "revert"
+)
+
+// AuditSystemActor is the actor recorded for writes made outside an API request
+const AuditSystemActor = "system"
+
+// AuditInfo identifies who made a write and from where
+type AuditInfo struct {
+	Actor     string
+	RequestID string
+	ClientIP  string
+}
+
+type auditInfoKey struct{}
+
+// WithAuditInfo returns a context whose writes are audited as info
+func WithAuditInfo(ctx context.Context, info AuditInfo) context.Context {
+	return context.WithValue(ctx, auditInfoKey{}, info)
+}
+
+func auditInfoFrom(ctx context.Context) AuditInfo {
+	info, _ := ctx.Value(auditInfoKey{}).(AuditInfo)
+	if info.Actor == "" {
+		info.Actor = AuditSystemActor
+	}
+	return info
+}
+
+// AuditEvent is an entry of the append-only audit log. Each event's hash covers its content
+// and the hash of the previous event, so modifying or removing an event breaks the chain.
+type AuditEvent struct {
+	ID           int64           `json:"id"`
+	CreatedAt    time.Time       `json:"created_at"`
+	Actor        string          `json:"actor"`
+	Action       string          `json:"action"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id"`
+	Before       json.RawMessage `json:"before"`
+	After        json.RawMessage `json:"after"`
+	RequestID    string          `json:"request_id"`
+	ClientIP     string          `json:"client_ip"`
+	PrevHash     string          `json:"prev_hash"`
+	Hash         string          `json:"hash"`
+}
+
+// AuditFilter selects audit events. Zero values don't filter.
+type AuditFilter struct {
+	Actor        string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	From         time.Time
+	To           time.Time
+	BeforeID     int64 // only events older than this ID, for paging backwards
+	Limit        int
+}
+
+// AuditVerification is the result of checking the audit hash chain
+type AuditVerification struct {
+	Events         int    `json:"events"`
+	Valid          bool   `json:"valid"`
+	FirstInvalidID *int64 `json:"first_invalid_id,omitempty"`
+	HeadHash       string `json:"head_hash"`
+}
+
+// auditHashSQL computes an event's hash from the row's columns and the previous hash.
+// jsonb_build_array gives an unambiguous encoding and jsonb text output is canonical.
+const auditHashSQL = `encode(sha256(convert_to(prev_hash || jsonb_build_array(
+	id, to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'),
+	actor, action, resource_type, resource_id, before, after, request_id, client_ip
+)::text, 'UTF8')), 'hex')`
+
+const auditEventColumns = `id, created_at, actor, action, resource_type, resource_id, before, after, request_id, client_ip, prev_hash, hash`
+
+// audit records an event in tx, so it is committed or rolled back together with the write.
+// before and after are encoded as JSON, nil is stored as null.
+func audit(ctx context.Context, tx pgx.Tx, action, resourceType, resourceID string, before, after any) error {
+	info := auditInfoFrom(ctx)
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	// Writers append one at a time so every event links to the one before it
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('audit_events')), pg_notify($1, '')`, AuditChannel); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO audit_events (`+auditEventColumns+`)
+		SELECT id, created_at, actor, action, resource_type, resource_id, before, after, request_id, client_ip, prev_hash, `+auditHashSQL+`
+		FROM (
+			SELECT nextval(pg_get_serial_sequence('audit_events', 'id')) AS id, now() AS created_at,
+				$1::text AS actor, $2::text AS action, $3::text AS resource_type, $4::text AS resource_id,
+				$5::jsonb AS before, $6::jsonb AS after, $7::text AS request_id, $8::text AS client_ip,
+				coalesce((SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1), '') AS prev_hash
+		) e
+	`, info.Actor, action, resourceType, resourceID, beforeJSON, afterJSON, info.RequestID, info.ClientIP)
+	return err
+}
+
+func auditJSON(v any) (*string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	s := string(b)
+	return &s, nil
+}
+
+// AuditChannel is the Postgres notification channel signalled when audit events are committed
+const AuditChannel = "catalog_audit"
+
+// LatestAuditEventID returns the ID of the newest audit event, 0 if there is none. IDs are
+// assigned in commit order and every write through the API is audited, so the ID changes
+// whenever the catalog does.
+func (s *Store) LatestAuditEventID(ctx context.Context) (int64, error) {
+	var id int64
+	err := s.pool.QueryRow(ctx, `SELECT coalesce(max(id), 0) FROM audit_events`).Scan(&id)
+	return id, err
+}
+
+// ListAuditEvents returns audit events matching f, newest first
+func (s *Store) ListAuditEvents(ctx context.Context, f AuditFilter) ([]AuditEvent, error) {
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.Actor != "" {
+		add("actor = $%d", f.Actor)
+	}
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+	if f.ResourceType != "" {
+		add("resource_type = $%d", f.ResourceType)
+	}
+	if f.ResourceID != "" {
+		add("resource_id = $%d", f.ResourceID)
+	}
+	if !f.From.IsZero() {
+		add("created_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("created_at < $%d", f.To)
+	}
+	if f.BeforeID > 0 {
+		add("id < $%d", f.BeforeID)
+	}
+	if f.Limit <= 0 || f.Limit > s.maxPage {
+		f.Limit = s.maxPage
+	}
+
+	whereSQL := ""
+	if len(where) > 0 {
+		whereSQL = "WHERE " + strings.Join(where, " AND ")
+	}
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM audit_events
+		%s
+		ORDER BY id DESC
+		LIMIT %d
+	`, auditEventColumns, whereSQL, f.Limit)
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []AuditEvent{}
+	for rows.Next() {
+		var e AuditEvent
+		if err := rows.Scan(&e.ID, &e.CreatedAt, &e.Actor, &e.Action, &e.ResourceType, &e.ResourceID,
+			&e.Before, &e.After, &e.RequestID, &e.ClientIP, &e.PrevHash, &e.Hash); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// VerifyAuditChain recomputes every event's hash and checks it links to the previous event.
+// Removing the newest events can't be detected from the chain alone; compare HeadHash with
+// a previously recorded value for that.
+func (s *Store) VerifyAuditChain(ctx context.Context) (*AuditVerification, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, hash,
+			prev_hash = coalesce(lag(hash) OVER (ORDER BY id), '') AND hash = `+auditHashSQL+`
+		FROM audit_events
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	v := &AuditVerification{Valid: true}
+	for rows.Next() {
+		var id int64
+		var ok bool
+		if err := rows.Scan(&id, &v.HeadHash, &ok); err != nil {
+			return nil, err
+		}
+		v.Events++
+		if !ok && v.Valid {
+			v.Valid = false
+			v.FirstInvalidID = &id
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
@@ -0,0 +1,350 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Component is a normalized SBOM component
+type Component struct {
+	ID      uuid.UUID `json:"id"`
+	PURL    string    `json:"purl"`
+	Package string    `json:"package"`
+	Name    string    `json:"name"`
+	Version string    `json:"version"`
+	License string    `json:"license"`
+}
+
+// SBOM is the SBOM document attached to a service version
+type SBOM struct {
+	ServiceVersionID uuid.UUID `json:"service_version_id"`
+	Format           string    `json:"format"`
+	SpecVersion      string    `json:"spec_version"`
+	Document         []byte    `json:"-"`
+	UploadedAt       time.Time `json:"uploaded_at"`
+	Components       int       `json:"components"`
+}
+
+// ComponentUsage is a service version that ships a component
+type ComponentUsage struct {
+	ServiceID   uuid.UUID `json:"service_id"`
+	ServiceName string    `json:"service_name"`
+	VersionID   uuid.UUID `json:"version_id"`
+	Version     string    `json:"version"`
+	Component   Component `json:"component"`
+}
+
+// GetServiceVersion returns a version of a service by its version string, or nil if it doesn't exist
+func (s *Store) GetServiceVersion(ctx context.Context, serviceID uuid.UUID, version string) (*ServiceVersion, error) {
+	row := s.pool.QueryRow(ctx, `
+		SELECT `+serviceVersionColumns+`
+		FROM service_versions
+		WHERE service_id = $1 AND version = $2
+	`, serviceID, version)
+	var v ServiceVersion
+	if err := scanServiceVersion(row, &v); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// ReplaceSBOM stores the SBOM of a service version and replaces its component list.
+// Components are shared across versions and keyed by purl.
+func (s *Store) ReplaceSBOM(ctx context.Context, sbom *SBOM, components []Component) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	sbom.UploadedAt = time.Now()
+	sbom.Components = len(components)
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO sboms (service_version_id, format, spec_version, document, uploaded_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (service_version_id) DO UPDATE
+		SET format = EXCLUDED.format, spec_version = EXCLUDED.spec_version,
+			document = EXCLUDED.document, uploaded_at = EXCLUDED.uploaded_at
+	`, sbom.ServiceVersionID, sbom.Format, sbom.SpecVersion, string(sbom.Document), sbom.UploadedAt); err != nil {
+		return err
+	}
+
+	previous, err := replaceComponents(ctx, tx, sbom.ServiceVersionID, components)
+	if err != nil {
+		return err
+	}
+	after := componentsSnapshot{Format: sbom.Format, SpecVersion: sbom.SpecVersion, PURLs: purls(components)}
+	if err := audit(ctx, tx, AuditReplace, "sbom", sbom.ServiceVersionID.String(), componentsSnapshot{PURLs: previous}, after); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ReplaceVersionPackages replaces the component list of a service version without attaching an SBOM document
+func (s *Store) ReplaceVersionPackages(ctx context.Context, versionID uuid.UUID, components []Component) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	previous, err := replaceComponents(ctx, tx, versionID, components)
+	if err != nil {
+		return err
+	}
+	after := componentsSnapshot{PURLs: purls(components)}
+	if err := audit(ctx, tx, AuditReplace, "version_packages", versionID.String(), componentsSnapshot{PURLs: previous}, after); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// componentsSnapshot is the audited state of a version's component list
+type componentsSnapshot struct {
+	Format      string   `json:"format,omitempty"`
+	SpecVersion string   `json:"spec_version,omitempty"`
+	PURLs       []string `json:"purls"`
+}
+
+func purls(components []Component) []string {
+	out := make([]string, len(components))
+	for i, c := range components {
+		out[i] = c.PURL
+	}
+	return out
+}
+
+// replaceComponents replaces the component links of a version, upserting the components themselves.
+// It returns the purls of the components previously linked.
+func replaceComponents(ctx context.Context, tx pgx.Tx, versionID uuid.UUID, components []Component) ([]string, error) {
+	rows, err := tx.Query(ctx, `
+		DELETE FROM version_components vc
+		USING components c
+		WHERE vc.service_version_id = $1 AND c.id = vc.component_id
+		RETURNING c.purl
+	`, versionID)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(previous)
+
+	if len(components) == 0 {
+		return previous, nil
+	}
+
+	// Upsert the distinct components in one statement, so ids are returned for both new and
+	// existing components; a statement can't upsert the same purl twice
+	var ids []uuid.UUID
+	var keys, packages, names, versions, licenses []string
+	index := map[string]int{}
+	for _, c := range components {
+		if i, ok := index[c.PURL]; ok {
+			if licenses[i] == "" {
+				licenses[i] = c.License
+			}
+			continue
+		}
+		index[c.PURL] = len(keys)
+		ids = append(ids, GenerateUUID())
+		keys = append(keys, c.PURL)
+		packages = append(packages, c.Package)
+		names = append(names, c.Name)
+		versions = append(versions, c.Version)
+		licenses = append(licenses, c.License)
+	}
+	rows, err = tx.Query(ctx, `
+		INSERT INTO components (id, purl, package, name, version, license)
+		SELECT * FROM unnest($1::uuid[], $2::text[], $3::text[], $4::text[], $5::text[], $6::text[])
+		ON CONFLICT (purl) DO UPDATE
+		SET license = CASE WHEN components.license = '' THEN EXCLUDED.license ELSE components.license END
+		RETURNING purl, id
+	`, ids, keys, packages, names, versions, licenses)
+	if err != nil {
+		return nil, err
+	}
+	byPURL := map[string]uuid.UUID{}
+	var purl string
+	var id uuid.UUID
+	if _, err := pgx.ForEachRow(rows, []any{&purl, &id}, func() error {
+		byPURL[purl] = id
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	for i := range components {
+		components[i].ID = byPURL[components[i].PURL]
+	}
+	for i, key := range keys {
+		ids[i] = byPURL[key]
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO version_components (service_version_id, component_id)
+		SELECT $1, unnest($2::uuid[])
+		ON CONFLICT DO NOTHING
+	`, versionID, ids); err != nil {
+		return nil, err
+	}
+
+	return previous, nil
+}
+
+// ListVersionComponents returns the components of a service version ordered by purl
+func (s *Store) ListVersionComponents(ctx context.Context, versionID uuid.UUID) ([]Component, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT c.id, c.purl, c.package, c.name, c.version, c.license
+		FROM version_components vc
+		JOIN components c ON c.id = vc.component_id
+		WHERE vc.service_version_id = $1
+		ORDER BY c.purl
+	`, versionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	components := []Component{}
+	for rows.Next() {
+		var c Component
+		if err := rows.Scan(&c.ID, &c.PURL, &c.Package, &c.Name, &c.Version, &c.License); err != nil {
+			return nil, err
+		}
+		components = append(components, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return components, nil
+}
+
+// latestVersionSQL selects the newest version of the service s
+const latestVersionSQL = `(
+	SELECT id FROM service_versions
+	WHERE service_id = s.id
+	ORDER BY created_at DESC, id DESC
+	LIMIT 1
+)`
+
+// ListCatalogComponentsPage returns the components of every version of a page of services
+// ordered by name, optionally restricted to the newest version of each service. Only services
+// with components are paged; the cursors continue from the first and last service of the page.
+func (s *Store) ListCatalogComponentsPage(ctx context.Context, latestOnly bool, limit int, cursor *Cursor) (Page[ComponentUsage], error) {
+	limit = s.PageSize(limit)
+	args := []any{latestOnly}
+	where, fetchOrd := "", "ASC"
+	if cursor != nil {
+		where, fetchOrd = keyset(cursor, "name", "text", "ASC", &args)
+		where = " AND " + where
+	}
+	type serviceKey struct {
+		ID   uuid.UUID
+		Name string
+	}
+	var services []serviceKey
+	err := s.query(ctx, func(rows pgx.Rows) error {
+		var err error
+		services, err = pgx.CollectRows(rows, pgx.RowToStructByPos[serviceKey])
+		return err
+	}, fmt.Sprintf(`
+		SELECT * FROM (
+			SELECT s.id, s.name
+			FROM services s
+			WHERE EXISTS (
+				SELECT 1 FROM service_versions v
+				JOIN version_components vc ON vc.service_version_id = v.id
+				WHERE v.service_id = s.id AND (NOT $1 OR v.id = %s)
+			)%s
+			ORDER BY s.name %s, s.id %s
+			LIMIT %d
+		) page
+		ORDER BY name, id
+	`, latestVersionSQL, where, fetchOrd, fetchOrd, limit+1), args...)
+	if err != nil {
+		return Page[ComponentUsage]{}, err
+	}
+	keys := paginate(services, limit, cursor, false, func(x serviceKey) Cursor {
+		return Cursor{Sort: "name", Key: x.Name, ID: x.ID}
+	})
+
+	page := Page[ComponentUsage]{Items: []ComponentUsage{}, Next: keys.Next, Prev: keys.Prev}
+	if len(keys.Items) == 0 {
+		return page, nil
+	}
+	ids := make([]uuid.UUID, len(keys.Items))
+	for i, x := range keys.Items {
+		ids[i] = x.ID
+	}
+	err = s.query(ctx, func(rows pgx.Rows) error {
+		var err error
+		page.Items, err = scanComponentUsages(rows)
+		return err
+	}, `
+		SELECT s.id, s.name, v.id, v.version, c.id, c.purl, c.package, c.name, c.version, c.license
+		FROM services s
+		JOIN service_versions v ON v.service_id = s.id
+		JOIN version_components vc ON vc.service_version_id = v.id
+		JOIN components c ON c.id = vc.component_id
+		WHERE s.id = ANY($2) AND (NOT $1 OR v.id = `+latestVersionSQL+`)
+		ORDER BY s.name, v.created_at DESC, v.id DESC, c.purl
+	`, latestOnly, ids)
+	return page, err
+}
+
+// FindComponentUsage returns every service version shipping the given component.
+// A purl with a version matches exactly; a purl without one matches every version of the package.
+func (s *Store) FindComponentUsage(ctx context.Context, purl string, exactVersion bool) ([]ComponentUsage, error) {
+	column := "c.package"
+	if exactVersion {
+		column = "c.purl"
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT s.id, s.name, v.id, v.version, c.id, c.purl, c.package, c.name, c.version, c.license
+		FROM components c
+		JOIN version_components vc ON vc.component_id = c.id
+		JOIN service_versions v ON v.id = vc.service_version_id
+		JOIN services s ON s.id = v.service_id
+		WHERE `+column+` = $1
+		ORDER BY s.name, v.created_at DESC, v.id DESC, c.purl
+	`, purl)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanComponentUsages(rows)
+}
+
+func scanComponentUsages(rows pgx.Rows) ([]ComponentUsage, error) {
+	usages := []ComponentUsage{}
+	for rows.Next() {
+		var u ComponentUsage
+		c := &u.Component
+		if err := rows.Scan(&u.ServiceID, &u.ServiceName, &u.VersionID, &u.Version,
+			&c.ID, &c.PURL, &c.Package, &c.Name, &c.Version, &c.License); err != nil {
+			return nil, err
+		}
+		usages = append(usages, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return usages, nil
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type ServiceDependency struct {
+	ServiceID   uuid.UUID `json:"service_id"`
+	DependsOnID uuid.UUID `json:"depends_on_id"`
+	DependsOn   string    `json:"depends_on"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// GetServiceByName returns the service with the given name, or nil if it doesn't exist
+func (s *Store) GetServiceByName(ctx context.Context, name string) (*Service, error) {
+	return getServiceByName(s.pool.QueryRow(ctx, serviceByNameSQL, name))
+}
+
+// GetServiceByName returns the service with the given name, or nil if it doesn't exist
+func (t *Tx) GetServiceByName(ctx context.Context, name string) (*Service, error) {
+	return getServiceByName(t.tx.QueryRow(ctx, serviceByNameSQL, name))
+}
+
+const serviceByNameSQL = `SELECT ` + serviceColumns + ` FROM services WHERE name = $1`
+
+func getServiceByName(row pgx.Row) (*Service, error) {
+	var x Service
+	if err := scanService(row, &x); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	x.Versions = []ServiceVersion{}
+	return &x, nil
+}
+
+// CreateService creates a new service
+func (t *Tx) CreateService(ctx context.Context, service *Service) error {
+	return createService(ctx, t.tx, service)
+}
+
+// EnsureServiceVersion creates the version if it doesn't exist yet.
+// It reports whether a new row was inserted.
+func (s *Store) EnsureServiceVersion(ctx context.Context, serviceVersion *ServiceVersion) (created bool, err error) {
+	err = s.WithTx(ctx, func(tx *Tx) error {
+		created, err = tx.EnsureServiceVersion(ctx, serviceVersion)
+		return err
+	})
+	return created, err
+}
+
+// EnsureServiceVersion creates the version if it doesn't exist yet.
+// It reports whether a new row was inserted.
+func (t *Tx) EnsureServiceVersion(ctx context.Context, serviceVersion *ServiceVersion) (bool, error) {
+	serviceVersion.ID = GenerateUUID()
+	serviceVersion.CreatedAt = time.Now()
+
+	tag, err := t.tx.Exec(ctx, `
+		INSERT INTO service_versions (id, service_id, version, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (service_id, version) DO NOTHING
+	`, serviceVersion.ID, serviceVersion.ServiceID, serviceVersion.Version, serviceVersion.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+	if err := audit(ctx, t.tx, AuditCreate, "service_version", serviceVersion.ID.String(), nil, serviceVersion); err != nil {
+		return false, err
+	}
+	if err := enqueue(ctx, t.tx, EventVersionCreated, serviceVersion.ServiceID, serviceVersion); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// AddServiceDependency records that serviceID depends on dependsOnID. Existing edges are left untouched.
+func (s *Store) AddServiceDependency(ctx context.Context, serviceID, dependsOnID uuid.UUID) error {
+	return s.WithTx(ctx, func(tx *Tx) error {
+		return tx.AddServiceDependency(ctx, serviceID, dependsOnID)
+	})
+}
+
+// AddServiceDependency records that serviceID depends on dependsOnID. Existing edges are left untouched.
+func (t *Tx) AddServiceDependency(ctx context.Context, serviceID, dependsOnID uuid.UUID) error {
+	tag, err := t.tx.Exec(ctx, `
+		INSERT INTO service_dependencies (service_id, depends_on_id, created_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (service_id, depends_on_id) DO NOTHING
+	`, serviceID, dependsOnID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+	edge := map[string]uuid.UUID{"service_id": serviceID, "depends_on_id": dependsOnID}
+	return audit(ctx, t.tx, AuditCreate, "service_dependency", serviceID.String()+"/"+dependsOnID.String(), nil, edge)
+}
+
+// ListDependencies returns the services the given service depends on, ordered by name,
+// or nil if the service doesn't exist
+func (s *Store) ListDependencies(ctx context.Context, id uuid.UUID) ([]ServiceDependency, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT d.service_id, d.depends_on_id, s.name, d.created_at
+		FROM service_dependencies d
+		JOIN services s ON s.id = d.depends_on_id
+		WHERE d.service_id = $1
+		ORDER BY s.name ASC
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deps := []ServiceDependency{}
+	for rows.Next() {
+		var d ServiceDependency
+		if err := rows.Scan(&d.ServiceID, &d.DependsOnID, &d.DependsOn, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		deps = append(deps, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(deps) == 0 {
+		var exists bool
+		if err := s.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM services WHERE id = $1)`, id).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, nil
+		}
+	}
+
+	return deps, nil
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// FacetCount is the number of matching services with a value of a facet
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// MaxFacetValues is the most values returned per facet: the most frequent terms, or the latest
+// years and months
+const MaxFacetValues = 50
+
+// serviceFacet computes a facet value of a service. Bucketed facets return all their buckets in
+// order, counts of 0 included; chronological facets the latest values first; the others the most
+// frequent values first.
+type serviceFacet struct {
+	sql           string
+	buckets       []string
+	chronological bool
+}
+
+// serviceFacets are the facets of services; `labels.<key>` facets count label values, with a
+// missing label counted as ""
+var serviceFacets = map[string]serviceFacet{
+	"team":          {sql: "team"},
+	"name_prefix":   {sql: "split_part(name, '-', 1)"},
+	"created_year":  {sql: "to_char(created_at AT TIME ZONE 'UTC', 'YYYY')", chronological: true},
+	"created_month": {sql: "to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM')", chronological: true},
+	"updated": {
+		sql: `CASE WHEN updated_at >= now() - interval '24 hours' THEN '24h'
+			WHEN updated_at >= now() - interval '7 days' THEN '7d'
+			WHEN updated_at >= now() - interval '30 days' THEN '30d'
+			WHEN updated_at >= now() - interval '90 days' THEN '90d'
+			ELSE 'older' END`,
+		buckets: []string{"24h", "7d", "30d", "90d", "older"},
+	},
+	"versions": {
+		sql: `CASE WHEN versions.count = 0 THEN '0'
+			WHEN versions.count = 1 THEN '1'
+			WHEN versions.count <= 5 THEN '2-5'
+			WHEN versions.count <= 20 THEN '6-20'
+			ELSE '21+' END`,
+		buckets: []string{"0", "1", "2-5", "6-20", "21+"},
+	},
+}
+
+// IsServiceFacet reports whether services can be faceted by name
+func IsServiceFacet(name string) bool {
+	if key, ok := strings.CutPrefix(name, labelFilterPrefix); ok {
+		return key != ""
+	}
+	_, ok := serviceFacets[name]
+	return ok
+}
+
+// ServiceFacets counts the services matching a query (ignoring its sorting and paging) by the
+// value of each facet, all facets in a single query
+func (s *Store) ServiceFacets(ctx context.Context, query ServiceQuery, facets []string) (map[string][]FacetCount, error) {
+	counts := make(map[string][]FacetCount, len(facets))
+	if len(facets) == 0 {
+		return counts, nil
+	}
+
+	facets = slices.Compact(slices.Sorted(slices.Values(facets)))
+	m := query.match()
+	var columns, sets, facetCase, valueCase []string
+	needVersions := false
+	for i, name := range facets {
+		var expr string
+		if key, ok := strings.CutPrefix(name, labelFilterPrefix); ok {
+			m.args = append(m.args, key)
+			expr = fmt.Sprintf("coalesce(labels->>$%d, '')", len(m.args))
+		} else {
+			expr = serviceFacets[name].sql
+			needVersions = needVersions || name == "versions"
+		}
+		col := fmt.Sprintf("f%d", i)
+		columns = append(columns, expr+" AS "+col)
+		sets = append(sets, "("+col+")")
+		facetCase = append(facetCase, fmt.Sprintf("WHEN GROUPING(%s) = 0 THEN %d", col, i))
+		valueCase = append(valueCase, fmt.Sprintf("WHEN GROUPING(%s) = 0 THEN %s", col, col))
+	}
+	from := m.from
+	if needVersions {
+		from += ", LATERAL (SELECT count(*) FROM service_versions v WHERE v.service_id = services.id) AS versions"
+	}
+	sql := fmt.Sprintf(`
+		WITH matched AS (
+			SELECT %s
+			FROM %s
+			%s
+		)
+		SELECT CASE %s END, CASE %s END, count(*)
+		FROM matched
+		GROUP BY GROUPING SETS (%s)
+	`, strings.Join(columns, ", "), from, m.where,
+		strings.Join(facetCase, " "), strings.Join(valueCase, " "), strings.Join(sets, ", "))
+
+	err := s.queryMatch(ctx, m, func(rows pgx.Rows) error {
+		for rows.Next() {
+			var i int
+			var x FacetCount
+			if err := rows.Scan(&i, &x.Value, &x.Count); err != nil {
+				return err
+			}
+			counts[facets[i]] = append(counts[facets[i]], x)
+		}
+		return rows.Err()
+	}, sql)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range facets {
+		counts[name] = sortFacet(serviceFacets[name], counts[name])
+	}
+	return counts, nil
+}
+
+// sortFacet orders the values of a facet, filling in empty buckets and keeping at most
+// MaxFacetValues
+func sortFacet(facet serviceFacet, values []FacetCount) []FacetCount {
+	if facet.buckets != nil {
+		sorted := make([]FacetCount, len(facet.buckets))
+		for i, bucket := range facet.buckets {
+			sorted[i].Value = bucket
+			for _, x := range values {
+				if x.Value == bucket {
+					sorted[i].Count = x.Count
+				}
+			}
+		}
+		return sorted
+	}
+	slices.SortFunc(values, func(a, b FacetCount) int {
+		if facet.chronological {
+			return strings.Compare(b.Value, a.Value)
+		}
+		if a.Count != b.Count {
+			return int(b.Count - a.Count)
+		}
+		return strings.Compare(a.Value, b.Value)
+	})
+	if values == nil {
+		values = []FacetCount{}
+	}
+	return values[:min(len(values), MaxFacetValues)]
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"kong/pkg/filter"
+)
+
+// serviceFilterFields are the fields of services a filter can compare, with their SQL
+var serviceFilterFields = map[string]struct {
+	typ filter.Type
+	sql string
+}{
+	"name":           {filter.String, "name"},
+	"description":    {filter.String, "description"},
+	"team":           {filter.String, "team"},
+	"revision":       {filter.Number, "revision"},
+	"created_at":     {filter.Time, "created_at"},
+	"updated_at":     {filter.Time, "updated_at"},
+	"versions.count": {filter.Number, "(SELECT count(*) FROM service_versions v WHERE v.service_id = services.id)"},
+}
+
+// labelFilterPrefix selects a label by key, e.g. `labels.tier = "1"`. Missing labels compare as "".
+const labelFilterPrefix = "labels."
+
+// ServiceFilterField reports the type of a field services can be filtered on
+func ServiceFilterField(field string) (filter.Type, bool) {
+	if key, ok := strings.CutPrefix(field, labelFilterPrefix); ok {
+		return filter.String, key != ""
+	}
+	f, ok := serviceFilterFields[field]
+	return f.typ, ok
+}
+
+// ParseServiceFilter parses a filter over services and checks its fields. Errors are *filter.Error.
+func ParseServiceFilter(s string) (filter.Expr, error) {
+	expr, err := filter.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if err := filter.Check(expr, ServiceFilterField); err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
+
+// compileServiceFilter compiles a checked filter to a condition on services, appending its
+// values to args as parameters
+func compileServiceFilter(expr filter.Expr, args *[]any) string {
+	param := func(v any) string {
+		*args = append(*args, v)
+		return fmt.Sprintf("$%d", len(*args))
+	}
+	switch e := expr.(type) {
+	case *filter.Logical:
+		return "(" + compileServiceFilter(e.Left, args) + " " + e.Op + " " + compileServiceFilter(e.Right, args) + ")"
+	case *filter.Not:
+		return "NOT " + compileServiceFilter(e.X, args)
+	case *filter.Comparison:
+		var col string
+		if key, ok := strings.CutPrefix(e.Field, labelFilterPrefix); ok {
+			col = "coalesce(labels->>" + param(key) + ", '')"
+		} else {
+			col = serviceFilterFields[e.Field].sql
+		}
+		if e.Op == filter.OpContains {
+			return col + " ILIKE '%' || " + param(escapeLike(e.Value.Str)) + " || '%'"
+		}
+		var value string
+		switch e.Value.Type {
+		case filter.String:
+			value = param(e.Value.Str) + "::text"
+		case filter.Number:
+			value = param(e.Value.Num) + "::float8"
+		case filter.Time:
+			value = param(e.Value.Time) + "::timestamptz"
+		case filter.Bool:
+			value = param(e.Value.Bool) + "::boolean"
+		}
+		op := e.Op
+		if op == filter.OpNe {
+			op = "<>"
+		}
+		return col + " " + op + " " + value
+	}
+	// Parse only produces the expressions above
+	return "FALSE"
+}
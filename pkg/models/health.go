@@ -0,0 +1,227 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Health statuses
+const (
+	HealthUnknown   = "unknown"
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
+// HealthCheck is a health-check URL probed for a service in one environment, with its current state
+type HealthCheck struct {
+	ID                   uuid.UUID  `json:"id"`
+	ServiceID            uuid.UUID  `json:"service_id"`
+	Environment          string     `json:"environment"`
+	URL                  string     `json:"url"`
+	IntervalSeconds      int        `json:"interval_seconds"`
+	TimeoutMS            int        `json:"timeout_ms"`
+	SuccessThreshold     int        `json:"success_threshold"`
+	FailureThreshold     int        `json:"failure_threshold"`
+	Status               string     `json:"status"`
+	ConsecutiveSuccesses int        `json:"consecutive_successes"`
+	ConsecutiveFailures  int        `json:"consecutive_failures"`
+	LastCheckedAt        *time.Time `json:"last_checked_at"`
+	LastError            string     `json:"last_error,omitempty"`
+	LastLatencyMS        int        `json:"last_latency_ms"`
+	CreatedAt            time.Time  `json:"created_at"`
+}
+
+// HealthTransition is a change of the status of a health check
+type HealthTransition struct {
+	ID          uuid.UUID `json:"id"`
+	CheckID     uuid.UUID `json:"check_id"`
+	ServiceID   uuid.UUID `json:"service_id"`
+	Environment string    `json:"environment"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+const healthCheckColumns = `id, service_id, environment, url, interval_seconds, timeout_ms, success_threshold, failure_threshold,
+	status, consecutive_successes, consecutive_failures, last_checked_at, last_error, last_latency_ms, created_at`
+
+func scanHealthCheck(row pgx.Row, c *HealthCheck) error {
+	return row.Scan(&c.ID, &c.ServiceID, &c.Environment, &c.URL, &c.IntervalSeconds, &c.TimeoutMS, &c.SuccessThreshold, &c.FailureThreshold,
+		&c.Status, &c.ConsecutiveSuccesses, &c.ConsecutiveFailures, &c.LastCheckedAt, &c.LastError, &c.LastLatencyMS, &c.CreatedAt)
+}
+
+// CreateHealthCheck registers a health-check URL. It is probed as soon as the scheduler picks it up.
+func (s *Store) CreateHealthCheck(ctx context.Context, check *HealthCheck) error {
+	check.ID = GenerateUUID()
+	check.CreatedAt = time.Now()
+	check.Status = HealthUnknown
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO health_checks (id, service_id, environment, url, interval_seconds, timeout_ms, success_threshold, failure_threshold, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, check.ID, check.ServiceID, check.Environment, check.URL, check.IntervalSeconds, check.TimeoutMS,
+		check.SuccessThreshold, check.FailureThreshold, check.Status, check.CreatedAt); err != nil {
+		return err
+	}
+	if err := audit(ctx, tx, AuditCreate, "health_check", check.ID.String(), nil, check); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListHealthChecks returns the health checks of a service, optionally restricted to one environment
+func (s *Store) ListHealthChecks(ctx context.Context, serviceID uuid.UUID, environment string) ([]HealthCheck, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+healthCheckColumns+`
+		FROM health_checks
+		WHERE service_id = $1 AND ($2 = '' OR environment = $2)
+		ORDER BY environment, url
+	`, serviceID, environment)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanHealthChecks(rows)
+}
+
+// DeleteHealthCheck removes a health check and its history, reporting whether it existed
+func (s *Store) DeleteHealthCheck(ctx context.Context, serviceID, checkID uuid.UUID) (bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var c HealthCheck
+	err = scanHealthCheck(tx.QueryRow(ctx, `
+		DELETE FROM health_checks WHERE id = $1 AND service_id = $2
+		RETURNING `+healthCheckColumns, checkID, serviceID), &c)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := audit(ctx, tx, AuditDelete, "health_check", c.ID.String(), c, nil); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+// ClaimDueHealthChecks returns up to limit checks whose next probe is due and moves their next probe
+// one interval ahead. Rows locked by another catalog instance are skipped, so replicas don't probe twice.
+func (s *Store) ClaimDueHealthChecks(ctx context.Context, limit int) ([]HealthCheck, error) {
+	rows, err := s.pool.Query(ctx, `
+		UPDATE health_checks
+		SET next_probe_at = now() + make_interval(secs => interval_seconds)
+		WHERE id IN (
+			SELECT id FROM health_checks
+			WHERE next_probe_at <= now()
+			ORDER BY next_probe_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+healthCheckColumns, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanHealthChecks(rows)
+}
+
+// RecordHealthResult stores the state of a check after a probe and, when its status changed, the transition
+func (s *Store) RecordHealthResult(ctx context.Context, check *HealthCheck, transition *HealthTransition) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE health_checks
+		SET status = $2, consecutive_successes = $3, consecutive_failures = $4,
+			last_checked_at = $5, last_error = $6, last_latency_ms = $7
+		WHERE id = $1
+	`, check.ID, check.Status, check.ConsecutiveSuccesses, check.ConsecutiveFailures,
+		check.LastCheckedAt, check.LastError, check.LastLatencyMS)
+	if err != nil {
+		return err
+	}
+	// The check was deleted while it was being probed
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+
+	if transition != nil {
+		transition.ID = GenerateUUID()
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO health_transitions (id, check_id, service_id, environment, from_status, to_status, reason, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, transition.ID, transition.CheckID, transition.ServiceID, transition.Environment,
+			transition.From, transition.To, transition.Reason, transition.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListHealthTransitions returns the most recent status transitions of a service, newest first
+func (s *Store) ListHealthTransitions(ctx context.Context, serviceID uuid.UUID, environment string, limit int) ([]HealthTransition, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, check_id, service_id, environment, from_status, to_status, reason, created_at
+		FROM health_transitions
+		WHERE service_id = $1 AND ($2 = '' OR environment = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3
+	`, serviceID, environment, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transitions := []HealthTransition{}
+	for rows.Next() {
+		var t HealthTransition
+		if err := rows.Scan(&t.ID, &t.CheckID, &t.ServiceID, &t.Environment, &t.From, &t.To, &t.Reason, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return transitions, nil
+}
+
+func scanHealthChecks(rows pgx.Rows) ([]HealthCheck, error) {
+	checks := []HealthCheck{}
+	for rows.Next() {
+		var c HealthCheck
+		if err := scanHealthCheck(rows, &c); err != nil {
+			return nil, err
+		}
+		checks = append(checks, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return checks, nil
+}
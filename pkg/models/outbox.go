@@ -0,0 +1,194 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Change event types
+const (
+	EventServiceCreated = "service.created"
+	EventServiceUpdated = "service.updated"
+	EventVersionCreated = "version.created"
+)
+
+// EventTypes lists every change event type
+var EventTypes = []string{EventServiceCreated, EventServiceUpdated, EventVersionCreated}
+
+// ChangesChannel is the Postgres notification channel signalled when change events are committed
+const ChangesChannel = "catalog_changes"
+
+// OutboxEvent is a catalog change recorded in the same transaction as the change itself.
+// The outbox doubles as the change log: IDs are assigned in commit order, so a reader that has
+// seen an ID never misses an event with a smaller one.
+type OutboxEvent struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	ServiceID uuid.UUID       `json:"service_id"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// ChangeFilter selects change events. Zero values don't filter.
+type ChangeFilter struct {
+	AfterID    int64
+	ServiceIDs []uuid.UUID
+	Labels     map[string]string // services currently having all of these labels
+	Limit      int
+}
+
+// lockOutbox serializes outbox writers until tx ends and signals watchers on commit. Holding the
+// lock from ID assignment to commit keeps IDs in commit order. Writers must take it after any
+// row locks and the audit lock, so lock order is the same in every transaction.
+func lockOutbox(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('outbox_events')), pg_notify($1, '')`, ChangesChannel)
+	return err
+}
+
+// enqueue records a change event in tx. data is the changed entity, encoded as JSON.
+func enqueue(ctx context.Context, tx pgx.Tx, eventType string, serviceID uuid.UUID, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err := lockOutbox(ctx, tx); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO outbox_events (event_type, service_id, payload)
+		VALUES ($1, $2, $3)
+	`, eventType, serviceID, string(payload))
+	return err
+}
+
+// enqueueServiceUpdates records a service.updated event in tx for each of the given services,
+// built from their current rows with the same payload as a Service encoded in Go
+func enqueueServiceUpdates(ctx context.Context, tx pgx.Tx, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := lockOutbox(ctx, tx); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO outbox_events (event_type, service_id, payload)
+		SELECT $1, id, jsonb_build_object(
+			'id', id, 'name', name, 'description', coalesce(description, ''), 'team', team, 'labels', labels,
+			'revision', revision, 'created_at', created_at, 'updated_at', updated_at
+		)
+		FROM services
+		WHERE id = ANY($2)
+		ORDER BY id
+	`, EventServiceUpdated, ids)
+	return err
+}
+
+const outboxEventColumns = `id, event_type, service_id, payload, created_at`
+
+func scanOutboxEvent(row pgx.Row, e *OutboxEvent) error {
+	return row.Scan(&e.ID, &e.Type, &e.ServiceID, &e.Payload, &e.CreatedAt)
+}
+
+// ListOutboxEvents reads up to f.Limit events after f.AfterID, oldest first, and returns those
+// matching f along with the ID of the last event read, matching or not (f.AfterID when there were
+// none). Reading again after that ID continues where this read stopped, so filtered readers move
+// past events they don't match instead of scanning them again.
+func (s *Store) ListOutboxEvents(ctx context.Context, f ChangeFilter) ([]OutboxEvent, int64, error) {
+	if f.Limit <= 0 || f.Limit > s.maxPage {
+		f.Limit = s.maxPage
+	}
+
+	last := f.AfterID
+	if err := s.pool.QueryRow(ctx, fmt.Sprintf(`
+		SELECT coalesce(max(id), $1) FROM (SELECT id FROM outbox_events WHERE id > $1 ORDER BY id LIMIT %d) read
+	`, f.Limit), f.AfterID).Scan(&last); err != nil {
+		return nil, 0, err
+	}
+	if last == f.AfterID {
+		return []OutboxEvent{}, last, nil
+	}
+
+	where := []string{"id > $1", "id <= $2"}
+	args := []any{f.AfterID, last}
+	if len(f.ServiceIDs) > 0 {
+		args = append(args, f.ServiceIDs)
+		where = append(where, fmt.Sprintf("service_id = ANY($%d)", len(args)))
+	}
+	if len(f.Labels) > 0 {
+		labels, err := json.Marshal(f.Labels)
+		if err != nil {
+			return nil, 0, err
+		}
+		args = append(args, string(labels))
+		where = append(where, fmt.Sprintf("EXISTS (SELECT 1 FROM services s WHERE s.id = service_id AND s.labels @> $%d::jsonb)", len(args)))
+	}
+
+	rows, err := s.pool.Query(ctx, fmt.Sprintf(`
+		SELECT %s
+		FROM outbox_events
+		WHERE %s
+		ORDER BY id
+	`, outboxEventColumns, strings.Join(where, " AND ")), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events := []OutboxEvent{}
+	for rows.Next() {
+		var e OutboxEvent
+		if err := scanOutboxEvent(rows, &e); err != nil {
+			return nil, 0, err
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return events, last, nil
+}
+
+// PruneOutboxEvents deletes events created before olderThan that are no longer needed: dispatched
+// to webhook subscriptions with no delivery still pending, and published by every publisher.
+// Deliveries of deleted events are deleted with them. It returns the number of events deleted.
+func (s *Store) PruneOutboxEvents(ctx context.Context, olderThan time.Time) (int64, error) {
+	tag, err := s.pool.Exec(ctx, `
+		DELETE FROM outbox_events e
+		WHERE e.created_at < $1
+		  AND e.dispatched_at IS NOT NULL
+		  AND e.id <= coalesce((SELECT min(last_event_id) FROM publisher_offsets), e.id)
+		  AND NOT EXISTS (SELECT 1 FROM webhook_deliveries d WHERE d.event_id = e.id AND d.status = 'pending')
+	`, olderThan)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// LatestOutboxEventID returns the ID of the newest event, or 0 if there are none
+func (s *Store) LatestOutboxEventID(ctx context.Context) (int64, error) {
+	var id int64
+	err := s.pool.QueryRow(ctx, `SELECT coalesce(max(id), 0) FROM outbox_events`).Scan(&id)
+	return id, err
+}
+
+// GetOutboxEvent returns an event by ID, or nil if it doesn't exist
+func (s *Store) GetOutboxEvent(ctx context.Context, id int64) (*OutboxEvent, error) {
+	var e OutboxEvent
+	err := scanOutboxEvent(s.pool.QueryRow(ctx, `SELECT `+outboxEventColumns+` FROM outbox_events WHERE id = $1`, id), &e)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cursor is a keyset pagination position: the sort key and ID of the row a page continues
+// from. Unlike offsets, cursors don't skip or repeat rows when rows are added or removed
+// between pages, and seek through the sort index instead of scanning the skipped rows.
+type Cursor struct {
+	// Sort and Desc are the order the cursor pages in
+	Sort string `json:"s"`
+	Desc bool   `json:"d,omitempty"`
+	// Key is the sort key of the row, timestamps in RFC 3339
+	Key string    `json:"k"`
+	ID  uuid.UUID `json:"i"`
+	// Before continues with the rows before the position instead of after it
+	Before bool `json:"b,omitempty"`
+}
+
+// Page is a page of a keyset-paginated list
+type Page[T any] struct {
+	Items []T
+	// Next and Prev continue after the last and before the first item, nil at the ends of the list
+	Next, Prev *Cursor
+}
+
+// keyset returns the condition selecting the rows after (or before) a cursor in a list ordered
+// by col and id in ord, with the order to fetch them in: reversed for the rows before, which
+// are the ones closest to the cursor. typ is the SQL type of col.
+func keyset(c *Cursor, col, typ, ord string, args *[]any) (cond, fetchOrd string) {
+	cmp, fetchOrd := ">", ord
+	if c.Before {
+		fetchOrd = reverse(ord)
+	}
+	if fetchOrd == "DESC" {
+		cmp = "<"
+	}
+	*args = append(*args, c.Key, c.ID)
+	return fmt.Sprintf("(%s, id) %s ($%d::%s, $%d)", col, cmp, len(*args)-1, typ, len(*args)), fetchOrd
+}
+
+func reverse(ord string) string {
+	if ord == "DESC" {
+		return "ASC"
+	}
+	return "DESC"
+}
+
+// paginate trims the limit+1 rows fetched for a page to limit and sets the cursors of the page.
+// at is the cursor the page was fetched from, if any; more is whether rows precede the first
+// row when not paging from a cursor, e.g. at an offset; key is the cursor of a row.
+func paginate[T any](items []T, limit int, at *Cursor, more bool, key func(T) Cursor) Page[T] {
+	backward := at != nil && at.Before
+	hasMore := len(items) > limit
+	if hasMore {
+		if backward {
+			// The extra row is the farthest from the cursor, first in list order
+			items = slices.Delete(items, 0, 1)
+		} else {
+			items = items[:limit]
+		}
+	}
+	page := Page[T]{Items: items}
+	if len(items) == 0 {
+		return page
+	}
+	if hasMore || backward {
+		next := key(items[len(items)-1])
+		page.Next = &next
+	}
+	if (backward && hasMore) || (!backward && (at != nil || more)) {
+		prev := key(items[0])
+		prev.Before = true
+		page.Prev = &prev
+	}
+	return page
+}
+
+// formatKey formats a timestamp sort key of a cursor
+func formatKey(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
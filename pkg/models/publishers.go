@@ -0,0 +1,75 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PublishFunc publishes events in order and returns how many of them, from the first, were
+// published. Events after those are offered again on the next call.
+type PublishFunc func(ctx context.Context, events []OutboxEvent) (int, error)
+
+// PublishOutboxEvents passes up to limit events after the publisher's offset to publish, oldest
+// first, and moves the offset past the events it published. A publisher starts at the newest
+// event when it first runs. Only one replica publishes for a publisher at a time: while another
+// one holds its offset, nothing is published and 0 is returned.
+func (s *Store) PublishOutboxEvents(ctx context.Context, publisher string, limit int, publish PublishFunc) (int, error) {
+	if _, err := s.pool.Exec(ctx, `
+		INSERT INTO publisher_offsets (publisher, last_event_id)
+		SELECT $1, coalesce(max(id), 0) FROM outbox_events
+		ON CONFLICT (publisher) DO NOTHING
+	`, publisher); err != nil {
+		return 0, err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var offset int64
+	err = tx.QueryRow(ctx, `
+		SELECT last_event_id FROM publisher_offsets WHERE publisher = $1 FOR UPDATE SKIP LOCKED
+	`, publisher).Scan(&offset)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	events, _, err := s.ListOutboxEvents(ctx, ChangeFilter{AfterID: offset, Limit: limit})
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	n, publishErr := publish(ctx, events)
+	n = min(max(n, 0), len(events))
+	if n > 0 {
+		if _, err := tx.Exec(ctx, `
+			UPDATE publisher_offsets SET last_event_id = $2, updated_at = now() WHERE publisher = $1
+		`, publisher, events[n-1].ID); err != nil {
+			return 0, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return 0, err
+		}
+	}
+	return n, publishErr
+}
+
+// PublisherOffset returns the ID of the last event published by a publisher, or 0 if it never ran
+func (s *Store) PublisherOffset(ctx context.Context, publisher string) (int64, error) {
+	var offset int64
+	err := s.pool.QueryRow(ctx, `SELECT last_event_id FROM publisher_offsets WHERE publisher = $1`, publisher).Scan(&offset)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return offset, err
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// StaleCandidate is a service that has no versions, no version newer than a cutoff, or an empty description
+type StaleCandidate struct {
+	ServiceID       uuid.UUID
+	Name            string
+	Team            string
+	Description     string
+	Versions        int
+	LatestVersion   string
+	LatestVersionAt *time.Time
+}
+
+// ListStaleCandidates returns services whose newest version was created before cutoff,
+// services without versions and services with an empty description, ordered by name
+func (s *Store) ListStaleCandidates(ctx context.Context, cutoff time.Time) ([]StaleCandidate, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT s.id, s.name, s.team, coalesce(s.description, ''), c.n, coalesce(v.version, ''), v.created_at
+		FROM services s
+		CROSS JOIN LATERAL (
+			SELECT count(*) AS n FROM service_versions WHERE service_id = s.id
+		) c
+		LEFT JOIN LATERAL (
+			SELECT version, created_at FROM service_versions
+			WHERE service_id = s.id
+			ORDER BY created_at DESC, id DESC
+			LIMIT 1
+		) v ON true
+		WHERE v.created_at IS NULL OR v.created_at < $1 OR trim(coalesce(s.description, '')) = ''
+		ORDER BY s.name
+	`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := []StaleCandidate{}
+	for rows.Next() {
+		var c StaleCandidate
+		if err := rows.Scan(&c.ServiceID, &c.Name, &c.Team, &c.Description, &c.Versions, &c.LatestVersion, &c.LatestVersionAt); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+// SyncLabel sets label=value on the given services and removes the label from every other service.
+// It returns how many services gained and lost the label. Each change is recorded as a revision, an
+// audit event and a service.updated event, but updated_at is left alone since the label is derived,
+// not an edit of the service.
+func (s *Store) SyncLabel(ctx context.Context, label, value string, ids []uuid.UUID) (int64, int64, error) {
+	// A nil slice would encode as NULL and match nothing in NOT (id = ANY(...))
+	if ids == nil {
+		ids = []uuid.UUID{}
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// The self-join reads the rows as they were before the update, for the audit events
+	actor := auditInfoFrom(ctx).Actor
+	added, err := changedLabels(ctx, tx, `
+		WITH changed AS (
+			UPDATE services s
+			SET labels = s.labels || jsonb_build_object($1::text, $2::text), revision = s.revision + 1
+			FROM services old
+			WHERE old.id = s.id AND s.id = ANY($3) AND s.labels->>$1 IS DISTINCT FROM $2
+			RETURNING s.id, s.revision, s.name, s.description, s.team, s.labels, old.labels AS old_labels
+		), revisions AS (
+			INSERT INTO service_revisions (service_id, revision, name, description, team, labels, actor, created_at)
+			SELECT id, revision, name, coalesce(description, ''), team, labels, $4, now() FROM changed
+		)
+		SELECT id, old_labels FROM changed
+	`, label, value, ids, actor)
+	if err != nil {
+		return 0, 0, err
+	}
+	removed, err := changedLabels(ctx, tx, `
+		WITH changed AS (
+			UPDATE services s
+			SET labels = s.labels - $1::text, revision = s.revision + 1
+			FROM services old
+			WHERE old.id = s.id AND s.labels ? $1 AND NOT (s.id = ANY($2))
+			RETURNING s.id, s.revision, s.name, s.description, s.team, s.labels, old.labels AS old_labels
+		), revisions AS (
+			INSERT INTO service_revisions (service_id, revision, name, description, team, labels, actor, created_at)
+			SELECT id, revision, name, coalesce(description, ''), team, labels, $3, now() FROM changed
+		)
+		SELECT id, old_labels FROM changed
+	`, label, ids, actor)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	changed := append(added, removed...)
+	if err := auditLabelChanges(ctx, tx, changed); err != nil {
+		return 0, 0, err
+	}
+	changedIDs := make([]uuid.UUID, len(changed))
+	for i, c := range changed {
+		changedIDs[i] = c.ID
+	}
+	if err := enqueueServiceUpdates(ctx, tx, changedIDs); err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, err
+	}
+	return int64(len(added)), int64(len(removed)), nil
+}
+
+// labelChange is a service whose labels were changed, with its labels before the change
+type labelChange struct {
+	ID        uuid.UUID
+	OldLabels map[string]string
+}
+
+// changedLabels runs a statement returning the IDs and previous labels of the services it changed
+func changedLabels(ctx context.Context, tx pgx.Tx, sql string, args ...any) ([]labelChange, error) {
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[labelChange])
+}
+
+// auditLabelChanges records an update audit event in tx for each changed service, from its
+// current row and the row with the previous labels and revision
+func auditLabelChanges(ctx context.Context, tx pgx.Tx, changes []labelChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(changes))
+	for i, c := range changes {
+		ids[i] = c.ID
+	}
+	rows, err := tx.Query(ctx, `SELECT `+serviceColumns+` FROM services WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return err
+	}
+	services, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Service, error) {
+		var x Service
+		err := scanService(row, &x)
+		return x, err
+	})
+	if err != nil {
+		return err
+	}
+	after := make(map[uuid.UUID]Service, len(services))
+	for _, x := range services {
+		after[x.ID] = x
+	}
+
+	for _, c := range changes {
+		service := after[c.ID]
+		before := service
+		before.Labels = c.OldLabels
+		before.Revision--
+		if err := audit(ctx, tx, AuditUpdate, "service", c.ID.String(), before, service); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ServiceRevision is the state of a service after a change
+type ServiceRevision struct {
+	ServiceID    uuid.UUID         `json:"service_id"`
+	Revision     int               `json:"revision"`
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	Team         string            `json:"team"`
+	Labels       map[string]string `json:"labels"`
+	Actor        string            `json:"actor"`
+	RevertedFrom *int              `json:"reverted_from,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// ServicePatch holds the fields of a service to change. Nil fields are left unchanged;
+// Labels replaces all labels when not nil.
+type ServicePatch struct {
+	Name        *string
+	Description *string
+	Team        *string
+	Labels      map[string]string
+}
+
+// recordRevision stores the current state of service as its revision
+func recordRevision(ctx context.Context, tx pgx.Tx, service *Service, revertedFrom *int) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO service_revisions (service_id, revision, name, description, team, labels, actor, reverted_from, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, service.ID, service.Revision, service.Name, service.Description, service.Team, service.Labels,
+		auditInfoFrom(ctx).Actor, revertedFrom, service.UpdatedAt)
+	return err
+}
+
+// UpdateService applies patch to a service and records the result as a new revision.
+// It returns nil if the service doesn't exist. A patch that changes nothing doesn't create a revision.
+func (s *Store) UpdateService(ctx context.Context, id uuid.UUID, patch ServicePatch) (*Service, error) {
+	return s.updateService(ctx, id, nil, func(x *Service) {
+		if patch.Name != nil {
+			x.Name = *patch.Name
+		}
+		if patch.Description != nil {
+			x.Description = *patch.Description
+		}
+		if patch.Team != nil {
+			x.Team = *patch.Team
+		}
+		if patch.Labels != nil {
+			x.Labels = patch.Labels
+		}
+	})
+}
+
+// RevertService restores a service to the content of an earlier revision, recorded as a new revision.
+// It returns nil if the service or the revision doesn't exist.
+func (s *Store) RevertService(ctx context.Context, id uuid.UUID, revision int) (*Service, error) {
+	target, err := s.GetServiceRevision(ctx, id, revision)
+	if err != nil || target == nil {
+		return nil, err
+	}
+	return s.updateService(ctx, id, &revision, func(x *Service) {
+		x.Name = target.Name
+		x.Description = target.Description
+		x.Team = target.Team
+		x.Labels = target.Labels
+	})
+}
+
+// updateService locks a service, lets change modify a copy and stores it as the next revision
+func (s *Store) updateService(ctx context.Context, id uuid.UUID, revertedFrom *int, change func(*Service)) (*Service, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var before Service
+	if err := scanService(tx.QueryRow(ctx, `SELECT `+serviceColumns+` FROM services WHERE id = $1 FOR UPDATE`, id), &before); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	after := before
+	after.Labels = maps.Clone(before.Labels)
+	change(&after)
+	if after.Labels == nil {
+		after.Labels = map[string]string{}
+	}
+	if after.Name == before.Name && after.Description == before.Description && after.Team == before.Team &&
+		maps.Equal(after.Labels, before.Labels) {
+		return &before, nil
+	}
+
+	after.Revision = before.Revision + 1
+	after.UpdatedAt = time.Now()
+	if _, err := tx.Exec(ctx, `
+		UPDATE services
+		SET name = $2, description = $3, team = $4, labels = $5, revision = $6, updated_at = $7
+		WHERE id = $1
+	`, id, after.Name, after.Description, after.Team, after.Labels, after.Revision, after.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := recordRevision(ctx, tx, &after, revertedFrom); err != nil {
+		return nil, err
+	}
+	action := AuditUpdate
+	if revertedFrom != nil {
+		action = AuditRevert
+	}
+	if err := audit(ctx, tx, action, "service", id.String(), before, after); err != nil {
+		return nil, err
+	}
+	if err := enqueue(ctx, tx, EventServiceUpdated, id, after); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &after, nil
+}
+
+const serviceRevisionColumns = `service_id, revision, name, description, team, labels, actor, reverted_from, created_at`
+
+func scanServiceRevision(row pgx.Row, r *ServiceRevision) error {
+	return row.Scan(&r.ServiceID, &r.Revision, &r.Name, &r.Description, &r.Team, &r.Labels, &r.Actor, &r.RevertedFrom, &r.CreatedAt)
+}
+
+// ListServiceRevisions returns the revisions of a service, newest first
+func (s *Store) ListServiceRevisions(ctx context.Context, id uuid.UUID) ([]ServiceRevision, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+serviceRevisionColumns+`
+		FROM service_revisions
+		WHERE service_id = $1
+		ORDER BY revision DESC
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []ServiceRevision{}
+	for rows.Next() {
+		var r ServiceRevision
+		if err := scanServiceRevision(rows, &r); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// GetServiceRevision returns a revision of a service, or nil if it doesn't exist
+func (s *Store) GetServiceRevision(ctx context.Context, id uuid.UUID, revision int) (*ServiceRevision, error) {
+	var r ServiceRevision
+	err := scanServiceRevision(s.pool.QueryRow(ctx, `
+		SELECT `+serviceRevisionColumns+`
+		FROM service_revisions
+		WHERE service_id = $1 AND revision = $2
+	`, id, revision), &r)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetServiceAsOf returns a service as it was at asOf, with the versions that existed then.
+// It returns nil if the service didn't exist at that time.
+func (s *Store) GetServiceAsOf(ctx context.Context, id uuid.UUID, asOf time.Time, includeVersions bool) (*Service, error) {
+	var x Service
+	err := s.pool.QueryRow(ctx, `
+		SELECT s.id, r.name, r.description, r.team, r.labels, r.revision, s.created_at, r.created_at
+		FROM service_revisions r
+		JOIN services s ON s.id = r.service_id
+		WHERE r.service_id = $1 AND r.created_at <= $2
+		ORDER BY r.revision DESC
+		LIMIT 1
+	`, id, asOf).Scan(&x.ID, &x.Name, &x.Description, &x.Team, &x.Labels, &x.Revision, &x.CreatedAt, &x.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	x.Versions = []ServiceVersion{}
+	if !includeVersions {
+		return &x, nil
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+serviceVersionColumns+`
+		FROM service_versions
+		WHERE service_id = $1 AND created_at <= $2
+		ORDER BY created_at DESC, id DESC
+	`, id, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v ServiceVersion
+		if err := scanServiceVersion(rows, &v); err != nil {
+			return nil, err
+		}
+		x.Versions = append(x.Versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &x, nil
+}
@@ -41,6 +41,22 @@ func EnsureSchema(ctx context.Context, pool *pgxpool.Pool) error {
 func DropSchema(ctx context.Context, pool *pgxpool.Pool) error {
 	// Drop in reverse order due to foreign key constraints
 	dropSQL := []string{
+		"DROP TABLE IF EXISTS publisher_offsets CASCADE;",
+		"DROP TABLE IF EXISTS webhook_deliveries CASCADE;",
+		"DROP TABLE IF EXISTS webhook_subscriptions CASCADE;",
+		"DROP TABLE IF EXISTS outbox_events CASCADE;",
+		"DROP TABLE IF EXISTS service_revisions CASCADE;",
+		"DROP TABLE IF EXISTS audit_events CASCADE;",
+		"DROP TABLE IF EXISTS health_transitions CASCADE;",
+		"DROP TABLE IF EXISTS health_checks CASCADE;",
+		"DROP TABLE IF EXISTS slos CASCADE;",
+		"DROP TABLE IF EXISTS scorecards CASCADE;",
+		"DROP TABLE IF EXISTS signing_keys CASCADE;",
+		"DROP TABLE IF EXISTS version_components CASCADE;",
+		"DROP TABLE IF EXISTS components CASCADE;",
+		"DROP TABLE IF EXISTS sboms CASCADE;",
+		"DROP TABLE IF EXISTS scrape_targets CASCADE;",
+		"DROP TABLE IF EXISTS service_dependencies CASCADE;",
 		"DROP TABLE IF EXISTS service_versions CASCADE;",
 		"DROP TABLE IF EXISTS services CASCADE;",
 	}
@@ -10,6 +10,9 @@ CREATE TABLE IF NOT EXISTS services (
     updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
+-- Owning team, added after the initial release
+ALTER TABLE services ADD COLUMN IF NOT EXISTS team TEXT NOT NULL DEFAULT '';
+
 -- Service versions table
 CREATE TABLE IF NOT EXISTS service_versions (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -19,6 +22,256 @@ CREATE TABLE IF NOT EXISTS service_versions (
     UNIQUE (service_id, version)
 );
 
+-- Trigram index on names for fuzzy matching, suggestions and prefix search. It replaces a
+-- B-tree on LOWER(name), which can't serve LIKE 'prefix%' in non-C collations.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+DROP INDEX IF EXISTS services_name_lower_idx;
+CREATE INDEX IF NOT EXISTS services_name_trgm_idx ON services USING GIN (LOWER(name) gin_trgm_ops);
+
 -- Indexes
-CREATE INDEX IF NOT EXISTS services_name_lower_idx ON services (LOWER(name));
 CREATE INDEX IF NOT EXISTS service_versions_by_service_and_created_at ON service_versions (service_id, created_at DESC, id DESC);
+
+-- Full-text search over names (weight A) and descriptions (weight B)
+ALTER TABLE services ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', name), 'A') || setweight(to_tsvector('english', coalesce(description, '')), 'B')
+) STORED;
+CREATE INDEX IF NOT EXISTS services_search_idx ON services USING GIN (search_vector);
+
+-- Service dependencies (e.g. compose depends_on)
+CREATE TABLE IF NOT EXISTS service_dependencies (
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    depends_on_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (service_id, depends_on_id),
+    CHECK (service_id != depends_on_id)
+);
+
+-- Prometheus scrape targets per service and environment
+CREATE TABLE IF NOT EXISTS scrape_targets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    environment TEXT NOT NULL CHECK (environment != ''),
+    address TEXT NOT NULL CHECK (address != ''),
+    metrics_path TEXT NOT NULL DEFAULT '/metrics',
+    labels JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (service_id, environment, address, metrics_path)
+);
+
+CREATE INDEX IF NOT EXISTS scrape_targets_by_environment ON scrape_targets (environment, service_id);
+
+-- SBOMs uploaded per service version
+CREATE TABLE IF NOT EXISTS sboms (
+    service_version_id UUID PRIMARY KEY REFERENCES service_versions(id) ON DELETE CASCADE,
+    format TEXT NOT NULL,
+    spec_version TEXT NOT NULL DEFAULT '',
+    document JSONB NOT NULL,
+    uploaded_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Normalized SBOM components, keyed by purl (without qualifiers)
+CREATE TABLE IF NOT EXISTS components (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    purl TEXT UNIQUE NOT NULL CHECK (purl != ''),
+    package TEXT NOT NULL,
+    name TEXT NOT NULL,
+    version TEXT NOT NULL DEFAULT '',
+    license TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS components_by_package ON components (package);
+
+CREATE TABLE IF NOT EXISTS version_components (
+    service_version_id UUID NOT NULL REFERENCES service_versions(id) ON DELETE CASCADE,
+    component_id UUID NOT NULL REFERENCES components(id) ON DELETE CASCADE,
+    PRIMARY KEY (service_version_id, component_id)
+);
+
+CREATE INDEX IF NOT EXISTS version_components_by_component ON version_components (component_id);
+
+-- Signed version registrations: what was signed and the verification result
+ALTER TABLE service_versions ADD COLUMN IF NOT EXISTS commit TEXT NOT NULL DEFAULT '';
+ALTER TABLE service_versions ADD COLUMN IF NOT EXISTS artifact_digests TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE service_versions ADD COLUMN IF NOT EXISTS signature TEXT NOT NULL DEFAULT '';
+ALTER TABLE service_versions ADD COLUMN IF NOT EXISTS verified BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE service_versions ADD COLUMN IF NOT EXISTS key_fingerprint TEXT NOT NULL DEFAULT '';
+
+-- Trusted public keys for verifying version signatures, per service
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    name TEXT NOT NULL DEFAULT '',
+    algorithm TEXT NOT NULL,
+    public_key TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (service_id, fingerprint)
+);
+
+-- Free-form service labels (e.g. tier=1)
+ALTER TABLE services ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '{}';
+
+-- Latest scorecard evaluation per service
+CREATE TABLE IF NOT EXISTS scorecards (
+    service_id UUID PRIMARY KEY REFERENCES services(id) ON DELETE CASCADE,
+    score INTEGER NOT NULL,
+    passed INTEGER NOT NULL,
+    total INTEGER NOT NULL,
+    checks JSONB NOT NULL DEFAULT '[]',
+    evaluated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Service level objectives
+CREATE TABLE IF NOT EXISTS slos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    name TEXT NOT NULL CHECK (name != ''),
+    description TEXT NOT NULL DEFAULT '',
+    sli TEXT NOT NULL CHECK (sli IN ('availability', 'latency')),
+    objective DOUBLE PRECISION NOT NULL CHECK (objective > 0 AND objective < 100),
+    time_window TEXT NOT NULL,
+    threshold_ms INTEGER NOT NULL DEFAULT 0,
+    metric TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (service_id, name)
+);
+
+-- Health-check URLs probed by the catalog, with their current hysteresis state
+CREATE TABLE IF NOT EXISTS health_checks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    environment TEXT NOT NULL CHECK (environment != ''),
+    url TEXT NOT NULL CHECK (url != ''),
+    interval_seconds INTEGER NOT NULL DEFAULT 30 CHECK (interval_seconds > 0),
+    timeout_ms INTEGER NOT NULL DEFAULT 5000 CHECK (timeout_ms > 0),
+    success_threshold INTEGER NOT NULL DEFAULT 1 CHECK (success_threshold > 0),
+    failure_threshold INTEGER NOT NULL DEFAULT 3 CHECK (failure_threshold > 0),
+    status TEXT NOT NULL DEFAULT 'unknown',
+    consecutive_successes INTEGER NOT NULL DEFAULT 0,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    last_checked_at TIMESTAMPTZ,
+    last_error TEXT NOT NULL DEFAULT '',
+    last_latency_ms INTEGER NOT NULL DEFAULT 0,
+    next_probe_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (service_id, environment, url)
+);
+
+CREATE INDEX IF NOT EXISTS health_checks_by_next_probe ON health_checks (next_probe_at);
+
+-- Health status transitions
+CREATE TABLE IF NOT EXISTS health_transitions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    check_id UUID NOT NULL REFERENCES health_checks(id) ON DELETE CASCADE,
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    environment TEXT NOT NULL,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS health_transitions_by_service ON health_transitions (service_id, created_at DESC);
+
+-- Append-only audit log of writes; each row's hash chains to the previous row
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL,
+    resource_type TEXT NOT NULL,
+    resource_id TEXT NOT NULL,
+    before JSONB,
+    after JSONB,
+    request_id TEXT NOT NULL DEFAULT '',
+    client_ip TEXT NOT NULL DEFAULT '',
+    prev_hash TEXT NOT NULL,
+    hash TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_by_resource ON audit_events (resource_type, resource_id, id DESC);
+CREATE INDEX IF NOT EXISTS audit_events_by_actor ON audit_events (actor, id DESC);
+CREATE INDEX IF NOT EXISTS audit_events_by_created_at ON audit_events (created_at);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+-- Service revisions; every change of a service row is recorded as a new numbered revision
+ALTER TABLE services ADD COLUMN IF NOT EXISTS revision INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS service_revisions (
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL CHECK (revision > 0),
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    team TEXT NOT NULL DEFAULT '',
+    labels JSONB NOT NULL DEFAULT '{}',
+    actor TEXT NOT NULL DEFAULT '',
+    reverted_from INTEGER,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (service_id, revision)
+);
+
+-- Services created before revisions were recorded start with their current state as revision 1
+INSERT INTO service_revisions (service_id, revision, name, description, team, labels, actor, created_at)
+SELECT id, revision, name, coalesce(description, ''), team, labels, 'system', created_at FROM services
+ON CONFLICT DO NOTHING;
+
+-- Transactional outbox of catalog change events, written in the same transaction as the change
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    service_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    dispatched_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS outbox_events_undispatched ON outbox_events (id) WHERE dispatched_at IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_events_by_service ON outbox_events (service_id, id);
+
+-- Outgoing webhook subscriptions; an empty event_types list subscribes to every event
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    url TEXT NOT NULL CHECK (url != ''),
+    secret TEXT NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Payload encoding: json, cloudevents (structured mode) or cloudevents-binary
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS format TEXT NOT NULL DEFAULT 'json';
+
+-- One delivery per subscription and outbox event
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error TEXT NOT NULL DEFAULT '',
+    last_status_code INTEGER NOT NULL DEFAULT 0,
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_by_subscription ON webhook_deliveries (subscription_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_by_event ON webhook_deliveries (event_id);
+
+-- Position of each event publisher in the outbox; events after last_event_id are still to be published
+CREATE TABLE IF NOT EXISTS publisher_offsets (
+    publisher TEXT PRIMARY KEY,
+    last_event_id BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
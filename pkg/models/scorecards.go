@@ -0,0 +1,145 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Scorecard is the stored result of the latest scorecard evaluation of a service
+type Scorecard struct {
+	ServiceID   uuid.UUID       `json:"service_id"`
+	ServiceName string          `json:"service_name"`
+	Team        string          `json:"team"`
+	Score       int             `json:"score"`
+	Passed      int             `json:"passed"`
+	Total       int             `json:"total"`
+	Checks      json.RawMessage `json:"checks"`
+	EvaluatedAt time.Time       `json:"evaluated_at"`
+}
+
+// ScorecardSubject is a service with the facts scorecard checks need
+type ScorecardSubject struct {
+	Service
+	LatestVersionAt *time.Time
+}
+
+// TeamScore is a row of the scorecard leaderboard
+type TeamScore struct {
+	Team            string  `json:"team"`
+	Services        int     `json:"services"`
+	AverageScore    float64 `json:"average_score"`
+	PerfectServices int     `json:"perfect_services"`
+}
+
+const scorecardSubjectSQL = `
+	SELECT s.id, s.name, coalesce(s.description,''), s.team, s.labels, s.created_at, s.updated_at, v.latest
+	FROM services s
+	LEFT JOIN LATERAL (
+		SELECT max(created_at) AS latest FROM service_versions WHERE service_id = s.id
+	) v ON true
+`
+
+func scanScorecardSubject(row pgx.Row, x *ScorecardSubject) error {
+	return row.Scan(&x.ID, &x.Name, &x.Description, &x.Team, &x.Labels, &x.CreatedAt, &x.UpdatedAt, &x.LatestVersionAt)
+}
+
+// GetScorecardSubject returns a service with its newest version time, or nil if it doesn't exist
+func (s *Store) GetScorecardSubject(ctx context.Context, id uuid.UUID) (*ScorecardSubject, error) {
+	var x ScorecardSubject
+	if err := scanScorecardSubject(s.pool.QueryRow(ctx, scorecardSubjectSQL+` WHERE s.id = $1`, id), &x); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &x, nil
+}
+
+// ListScorecardSubjects returns every service with its newest version time
+func (s *Store) ListScorecardSubjects(ctx context.Context) ([]ScorecardSubject, error) {
+	rows, err := s.pool.Query(ctx, scorecardSubjectSQL+` ORDER BY s.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subjects := []ScorecardSubject{}
+	for rows.Next() {
+		var x ScorecardSubject
+		if err := scanScorecardSubject(rows, &x); err != nil {
+			return nil, err
+		}
+		subjects = append(subjects, x)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subjects, nil
+}
+
+// SaveScorecard stores the latest scorecard of a service, replacing the previous one
+func (s *Store) SaveScorecard(ctx context.Context, sc *Scorecard) error {
+	sc.EvaluatedAt = time.Now()
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO scorecards (service_id, score, passed, total, checks, evaluated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (service_id) DO UPDATE
+		SET score = EXCLUDED.score, passed = EXCLUDED.passed, total = EXCLUDED.total,
+			checks = EXCLUDED.checks, evaluated_at = EXCLUDED.evaluated_at
+	`, sc.ServiceID, sc.Score, sc.Passed, sc.Total, sc.Checks, sc.EvaluatedAt)
+	return err
+}
+
+// GetScorecard returns the stored scorecard of a service, or nil if it hasn't been evaluated
+func (s *Store) GetScorecard(ctx context.Context, serviceID uuid.UUID) (*Scorecard, error) {
+	var sc Scorecard
+	err := s.pool.QueryRow(ctx, `
+		SELECT sc.service_id, s.name, s.team, sc.score, sc.passed, sc.total, sc.checks, sc.evaluated_at
+		FROM scorecards sc
+		JOIN services s ON s.id = sc.service_id
+		WHERE sc.service_id = $1
+	`, serviceID).Scan(&sc.ServiceID, &sc.ServiceName, &sc.Team, &sc.Score, &sc.Passed, &sc.Total, &sc.Checks, &sc.EvaluatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &sc, nil
+}
+
+// ScorecardLeaderboard ranks teams by the average score of their services.
+// Services without a team are grouped under the empty team.
+func (s *Store) ScorecardLeaderboard(ctx context.Context) ([]TeamScore, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT s.team, count(*), round(avg(sc.score), 1)::float8, count(*) FILTER (WHERE sc.score = 100)
+		FROM scorecards sc
+		JOIN services s ON s.id = sc.service_id
+		GROUP BY s.team
+		ORDER BY 3 DESC, 2 DESC, s.team
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []TeamScore{}
+	for rows.Next() {
+		var t TeamScore
+		if err := rows.Scan(&t.Team, &t.Services, &t.AverageScore, &t.PerfectServices); err != nil {
+			return nil, err
+		}
+		teams = append(teams, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return teams, nil
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// SimilarityThreshold is the lowest trigram similarity of fuzzy name matches. It is lower than
+// pg_trgm's default of 0.3 so hyphenated names with a typo in each word (`paymnet-svc` for
+// `payment-service`) still match.
+const SimilarityThreshold = 0.2
+
+// SuggestedService is an autocomplete suggestion
+type SuggestedService struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	Similarity float32   `json:"similarity"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// SuggestServices returns up to limit services whose name starts with prefix or is similar to it.
+// Prefix matches come first, then names by similarity, recently updated services first among equals.
+// Both matches are served by the trigram index on LOWER(name).
+func (s *Store) SuggestServices(ctx context.Context, prefix string, limit int) ([]SuggestedService, error) {
+	suggestions := []SuggestedService{}
+	err := s.querySimilar(ctx, func(rows pgx.Rows) error {
+		for rows.Next() {
+			var x SuggestedService
+			if err := rows.Scan(&x.ID, &x.Name, &x.Similarity, &x.UpdatedAt); err != nil {
+				return err
+			}
+			suggestions = append(suggestions, x)
+		}
+		return rows.Err()
+	}, `
+		SELECT id, name, similarity(LOWER(name), LOWER($1)) AS sim, updated_at
+		FROM services
+		WHERE LOWER(name) LIKE $2 || '%' OR LOWER(name) % LOWER($1)
+		ORDER BY LOWER(name) LIKE $2 || '%' DESC, sim DESC, updated_at DESC, id
+		LIMIT $3
+	`, prefix, escapeLike(strings.ToLower(prefix)), limit)
+	if err != nil {
+		return nil, err
+	}
+	return suggestions, nil
+}
+
+// query runs a query and hands its rows to scan
+func (s *Store) query(ctx context.Context, scan func(pgx.Rows) error, sql string, args ...any) error {
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	return scan(rows)
+}
+
+// querySimilar runs a query in a read-only transaction where the pg_trgm % operator matches at
+// SimilarityThreshold, and hands its rows to scan. Setting the threshold per transaction keeps
+// pooled connections at their defaults.
+func (s *Store) querySimilar(ctx context.Context, scan func(pgx.Rows) error, sql string, args ...any) error {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	threshold := strconv.FormatFloat(SimilarityThreshold, 'f', -1, 64)
+	if _, err := tx.Exec(ctx, `SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, threshold); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	return scan(rows)
+}
+
+// escapeLike escapes the LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
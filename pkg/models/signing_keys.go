@@ -0,0 +1,100 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// SigningKey is a public key trusted to sign version registrations of a service
+type SigningKey struct {
+	ID          uuid.UUID `json:"id"`
+	ServiceID   uuid.UUID `json:"service_id"`
+	Name        string    `json:"name"`
+	Algorithm   string    `json:"algorithm"`
+	PublicKey   string    `json:"public_key"`
+	Fingerprint string    `json:"fingerprint"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreateSigningKey adds a trusted signing key to a service
+func (s *Store) CreateSigningKey(ctx context.Context, key *SigningKey) error {
+	key.ID = GenerateUUID()
+	key.CreatedAt = time.Now()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO signing_keys (id, service_id, name, algorithm, public_key, fingerprint, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, key.ID, key.ServiceID, key.Name, key.Algorithm, key.PublicKey, key.Fingerprint, key.CreatedAt); err != nil {
+		return err
+	}
+	if err := audit(ctx, tx, AuditCreate, "signing_key", key.ID.String(), nil, key); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListSigningKeys returns the trusted signing keys of a service, oldest first
+func (s *Store) ListSigningKeys(ctx context.Context, serviceID uuid.UUID) ([]SigningKey, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, service_id, name, algorithm, public_key, fingerprint, created_at
+		FROM signing_keys
+		WHERE service_id = $1
+		ORDER BY created_at, id
+	`, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []SigningKey{}
+	for rows.Next() {
+		var k SigningKey
+		if err := rows.Scan(&k.ID, &k.ServiceID, &k.Name, &k.Algorithm, &k.PublicKey, &k.Fingerprint, &k.CreatedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// DeleteSigningKey removes a signing key, reporting whether it existed.
+// Versions already verified with the key keep their verification result.
+func (s *Store) DeleteSigningKey(ctx context.Context, serviceID, keyID uuid.UUID) (bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var k SigningKey
+	err = tx.QueryRow(ctx, `
+		DELETE FROM signing_keys WHERE id = $1 AND service_id = $2
+		RETURNING id, service_id, name, algorithm, public_key, fingerprint, created_at
+	`, keyID, serviceID).Scan(&k.ID, &k.ServiceID, &k.Name, &k.Algorithm, &k.PublicKey, &k.Fingerprint, &k.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := audit(ctx, tx, AuditDelete, "signing_key", k.ID.String(), k, nil); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// SLO is a service level objective declared on a service
+type SLO struct {
+	ID          uuid.UUID `json:"id"`
+	ServiceID   uuid.UUID `json:"service_id"`
+	ServiceName string    `json:"service_name"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	SLI         string    `json:"sli"`
+	Objective   float64   `json:"objective"`
+	Window      string    `json:"window"`
+	ThresholdMS int       `json:"threshold_ms,omitempty"`
+	Metric      string    `json:"metric,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+const sloSelectSQL = `
+	SELECT o.id, o.service_id, s.name, o.name, o.description, o.sli, o.objective, o.time_window, o.threshold_ms, o.metric, o.created_at
+	FROM slos o
+	JOIN services s ON s.id = o.service_id
+`
+
+func scanSLO(row pgx.Row, o *SLO) error {
+	return row.Scan(&o.ID, &o.ServiceID, &o.ServiceName, &o.Name, &o.Description, &o.SLI, &o.Objective, &o.Window, &o.ThresholdMS, &o.Metric, &o.CreatedAt)
+}
+
+// CreateSLO declares an SLO on a service
+func (s *Store) CreateSLO(ctx context.Context, o *SLO) error {
+	o.ID = GenerateUUID()
+	o.CreatedAt = time.Now()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO slos (id, service_id, name, description, sli, objective, time_window, threshold_ms, metric, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING (SELECT name FROM services WHERE id = $2)
+	`, o.ID, o.ServiceID, o.Name, o.Description, o.SLI, o.Objective, o.Window, o.ThresholdMS, o.Metric, o.CreatedAt).Scan(&o.ServiceName); err != nil {
+		return err
+	}
+	if err := audit(ctx, tx, AuditCreate, "slo", o.ID.String(), nil, o); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListSLOs returns the SLOs of a service ordered by name
+func (s *Store) ListSLOs(ctx context.Context, serviceID uuid.UUID) ([]SLO, error) {
+	rows, err := s.pool.Query(ctx, sloSelectSQL+` WHERE o.service_id = $1 ORDER BY o.name`, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSLOs(rows)
+}
+
+// ListAllSLOs returns every SLO of the catalog ordered by service and name
+func (s *Store) ListAllSLOs(ctx context.Context) ([]SLO, error) {
+	rows, err := s.pool.Query(ctx, sloSelectSQL+` ORDER BY s.name, o.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSLOs(rows)
+}
+
+// GetSLO returns an SLO of a service, or nil if it doesn't exist
+func (s *Store) GetSLO(ctx context.Context, serviceID, sloID uuid.UUID) (*SLO, error) {
+	var o SLO
+	if err := scanSLO(s.pool.QueryRow(ctx, sloSelectSQL+` WHERE o.id = $1 AND o.service_id = $2`, sloID, serviceID), &o); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &o, nil
+}
+
+// DeleteSLO removes an SLO, reporting whether it existed
+func (s *Store) DeleteSLO(ctx context.Context, serviceID, sloID uuid.UUID) (bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var o SLO
+	err = scanSLO(tx.QueryRow(ctx, `
+		DELETE FROM slos o USING services s
+		WHERE o.id = $1 AND o.service_id = $2 AND s.id = o.service_id
+		RETURNING o.id, o.service_id, s.name, o.name, o.description, o.sli, o.objective, o.time_window, o.threshold_ms, o.metric, o.created_at
+	`, sloID, serviceID), &o)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := audit(ctx, tx, AuditDelete, "slo", o.ID.String(), o, nil); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+func scanSLOs(rows pgx.Rows) ([]SLO, error) {
+	slos := []SLO{}
+	for rows.Next() {
+		var o SLO
+		if err := scanSLO(rows, &o); err != nil {
+			return nil, err
+		}
+		slos = append(slos, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return slos, nil
+}
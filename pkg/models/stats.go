@@ -0,0 +1,143 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// VersionDistribution summarizes how many versions services have
+type VersionDistribution struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	Max int     `json:"max"`
+}
+
+// CatalogTotals are catalog-wide counts
+type CatalogTotals struct {
+	Services           int                 `json:"services"`
+	Versions           int                 `json:"versions"`
+	VersionsPerService VersionDistribution `json:"versions_per_service"`
+}
+
+// BucketCount is the number of rows created in the bucket starting at Start
+type BucketCount struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// ReleaseCadence is the number of versions a service released in a time range
+type ReleaseCadence struct {
+	ServiceID       uuid.UUID `json:"service_id"`
+	ServiceName     string    `json:"service_name"`
+	Releases        int       `json:"releases"`
+	ReleasesPerWeek float64   `json:"releases_per_week"`
+	LastReleaseAt   time.Time `json:"last_release_at"`
+}
+
+// CatalogTotals returns the number of services and versions and the distribution of versions
+// per service. Services without versions count as zero.
+func (s *Store) CatalogTotals(ctx context.Context) (*CatalogTotals, error) {
+	var t CatalogTotals
+	err := s.pool.QueryRow(ctx, `
+		SELECT count(*), coalesce(sum(n), 0),
+			coalesce(percentile_cont(0.5) WITHIN GROUP (ORDER BY n), 0),
+			coalesce(percentile_cont(0.95) WITHIN GROUP (ORDER BY n), 0),
+			coalesce(max(n), 0)
+		FROM (
+			SELECT count(v.id) AS n
+			FROM services s
+			LEFT JOIN service_versions v ON v.service_id = s.id
+			GROUP BY s.id
+		) per_service
+	`).Scan(&t.Services, &t.Versions, &t.VersionsPerService.P50, &t.VersionsPerService.P95, &t.VersionsPerService.Max)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// VersionsPerBucket counts the versions created in [from, to) per unit ("day", "week" or "month"),
+// including empty buckets. Buckets are aligned in UTC.
+func (s *Store) VersionsPerBucket(ctx context.Context, unit string, from, to time.Time) ([]BucketCount, error) {
+	return s.countPerBucket(ctx, "service_versions", unit, from, to)
+}
+
+// ServicesPerBucket counts the services created in [from, to) per unit ("day", "week" or "month"),
+// including empty buckets. Buckets are aligned in UTC.
+func (s *Store) ServicesPerBucket(ctx context.Context, unit string, from, to time.Time) ([]BucketCount, error) {
+	return s.countPerBucket(ctx, "services", unit, from, to)
+}
+
+// countPerBucket counts rows of table by created_at. table is never user input.
+func (s *Store) countPerBucket(ctx context.Context, table, unit string, from, to time.Time) ([]BucketCount, error) {
+	rows, err := s.pool.Query(ctx, `
+		WITH buckets AS (
+			SELECT b AT TIME ZONE 'UTC' AS start, (b + ('1 ' || $1::text)::interval) AT TIME ZONE 'UTC' AS stop
+			FROM generate_series(
+				date_trunc($1::text, $2::timestamptz AT TIME ZONE 'UTC'),
+				$3::timestamptz AT TIME ZONE 'UTC' - interval '1 microsecond',
+				('1 ' || $1::text)::interval
+			) AS b
+		)
+		SELECT b.start, count(t.created_at)
+		FROM buckets b
+		LEFT JOIN `+table+` t ON t.created_at >= b.start AND t.created_at < b.stop
+			AND t.created_at >= $2 AND t.created_at < $3
+		GROUP BY b.start
+		ORDER BY b.start
+	`, unit, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []BucketCount{}
+	for rows.Next() {
+		var b BucketCount
+		if err := rows.Scan(&b.Start, &b.Count); err != nil {
+			return nil, err
+		}
+		b.Start = b.Start.UTC()
+		buckets = append(buckets, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return buckets, nil
+}
+
+// TopServicesByCadence returns the services that released the most versions in [from, to)
+func (s *Store) TopServicesByCadence(ctx context.Context, from, to time.Time, limit int) ([]ReleaseCadence, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT s.id, s.name, count(*),
+			round((count(*) / (extract(epoch FROM $2::timestamptz - $1::timestamptz) / 604800))::numeric, 2)::float8,
+			max(v.created_at)
+		FROM service_versions v
+		JOIN services s ON s.id = v.service_id
+		WHERE v.created_at >= $1 AND v.created_at < $2
+		GROUP BY s.id, s.name
+		ORDER BY 3 DESC, 5 DESC, s.name
+		LIMIT $3
+	`, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	top := []ReleaseCadence{}
+	for rows.Next() {
+		var c ReleaseCadence
+		if err := rows.Scan(&c.ServiceID, &c.ServiceName, &c.Releases, &c.ReleasesPerWeek, &c.LastReleaseAt); err != nil {
+			return nil, err
+		}
+		top = append(top, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return top, nil
+}
@@ -4,30 +4,57 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"html"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"kong/pkg/filter"
 )
 
 // ---- Types ----
 
 type Service struct {
-	ID          uuid.UUID        `json:"id"`
-	Name        string           `json:"name"`
-	Description string           `json:"description"`
-	CreatedAt   time.Time        `json:"created_at"`
-	UpdatedAt   time.Time        `json:"updated_at"`
-	Versions    []ServiceVersion `json:"versions,omitempty"`
+	ID          uuid.UUID         `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Team        string            `json:"team"`
+	Labels      map[string]string `json:"labels"`
+	Revision    int               `json:"revision"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	Versions    []ServiceVersion  `json:"versions,omitempty"`
+	// Match is set on results of a full-text search
+	Match *SearchMatch `json:"match,omitempty"`
+}
+
+// serviceColumns is the column list scanned by scanService
+const serviceColumns = `id, name, coalesce(description,''), team, labels, revision, created_at, updated_at`
+
+func scanService(row pgx.Row, x *Service) error {
+	return row.Scan(&x.ID, &x.Name, &x.Description, &x.Team, &x.Labels, &x.Revision, &x.CreatedAt, &x.UpdatedAt)
 }
 
 type ServiceVersion struct {
-	ID        uuid.UUID `json:"id"`
-	ServiceID uuid.UUID `json:"service_id"`
-	Version   string    `json:"version"`
-	CreatedAt time.Time `json:"created_at"`
+	ID              uuid.UUID `json:"id"`
+	ServiceID       uuid.UUID `json:"service_id"`
+	Version         string    `json:"version"`
+	Commit          string    `json:"commit,omitempty"`
+	ArtifactDigests []string  `json:"artifact_digests,omitempty"`
+	Signature       string    `json:"signature,omitempty"`
+	Verified        bool      `json:"verified"`
+	KeyFingerprint  string    `json:"key_fingerprint,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// serviceVersionColumns is the column list scanned by scanServiceVersion
+const serviceVersionColumns = `id, service_id, version, commit, artifact_digests, signature, verified, key_fingerprint, created_at`
+
+func scanServiceVersion(row pgx.Row, v *ServiceVersion) error {
+	return row.Scan(&v.ID, &v.ServiceID, &v.Version, &v.Commit, &v.ArtifactDigests, &v.Signature, &v.Verified, &v.KeyFingerprint, &v.CreatedAt)
 }
 
 // ---- Store ----
@@ -41,6 +68,18 @@ func NewStore(pool *pgxpool.Pool, maxPage int) *Store {
 	return &Store{pool: pool, maxPage: maxPage}
 }
 
+// MaxPageSize is the largest page lists return
+func (s *Store) MaxPageSize() int { return s.maxPage }
+
+// PageSize returns the size of the pages returned for a requested limit: the limit, capped at
+// the maximum page size, which is also the default
+func (s *Store) PageSize(limit int) int {
+	if limit <= 0 || limit > s.maxPage {
+		return s.maxPage
+	}
+	return limit
+}
+
 // GenerateUUID generates a new UUIDv4
 func GenerateUUID() uuid.UUID {
 	return uuid.New()
@@ -53,124 +92,305 @@ func ParseUUID(id string) (uuid.UUID, error) {
 
 func (s *Store) Ping(ctx context.Context) error { return s.pool.Ping(ctx) }
 
+// Tx groups writes into a single transaction, see Store.WithTx
+type Tx struct {
+	tx pgx.Tx
+}
+
+// WithTx runs fn in a transaction, committed if fn returns nil and rolled back otherwise
+func (s *Store) WithTx(ctx context.Context, fn func(*Tx) error) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&Tx{tx: tx}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ServiceQuery selects a page of services
+type ServiceQuery struct {
+	// Q is a case-insensitive name prefix
+	Q string
+	// Search is a full-text search over name and description in websearch syntax, e.g. `billing -legacy`
+	Search string
+	// Fuzzy matches names by trigram similarity, tolerating typos, e.g. `paymnet-svc`. It can't be
+	// combined with Search.
+	Fuzzy string
+	// Filter is a parsed filter expression (ParseServiceFilter), e.g. `versions.count >= 3`
+	Filter filter.Expr
+	// Cursor continues a list from a keyset position instead of an offset; Sort and Order must be
+	// the cursor's, and can't be relevance
+	Cursor *Cursor
+	// Sort ∈ {"name","created_at","updated_at","relevance"}; relevance needs Search or Fuzzy and is the default with them
+	Sort string
+	// Order ∈ {"asc","desc"}; relevance is descending unless asc is asked for
+	Order           string
+	Limit           int
+	Offset          int
+	IncludeVersions bool
+}
+
+// SearchMatch is how a service matched a full-text or fuzzy search
+type SearchMatch struct {
+	// Rank is the full-text rank, or the name similarity of fuzzy matches
+	Rank float32 `json:"rank"`
+	// Name and Description are HTML-escaped and highlighted with <mark> tags, Description is
+	// shortened to its matching fragments. Fuzzy matches aren't highlighted.
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// searchConfig is the text search configuration of services.search_vector
+const searchConfig = "english"
+
+// highlightStart and highlightStop delimit the matches ts_headline highlights. They aren't HTML,
+// so the text can be escaped before they're replaced with <mark> tags: ts_headline copies tags
+// in the text through, which would otherwise render stored markup.
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+// highlightOptions are the ts_headline options of names and description snippets
+const (
+	nameHighlightOptions    = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", HighlightAll=true"
+	snippetHighlightOptions = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \""
+)
+
+var highlightTags = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// highlight escapes a ts_headline result and marks its matches with <mark> tags
+func highlight(headline string) string {
+	return highlightTags.Replace(html.EscapeString(headline))
+}
+
 // ListServices returns services with offset/limit pagination and optional search.
 // sort ∈ {"name","created_at","updated_at"}; order ∈ {"asc","desc"}
 func (s *Store) ListServices(ctx context.Context, q, sortKey, order string, limit int, offset int, includeVersions bool) ([]Service, error) {
-	if limit <= 0 || limit > s.maxPage {
-		limit = s.maxPage
-	}
+	return s.QueryServices(ctx, ServiceQuery{Q: q, Sort: sortKey, Order: order, Limit: limit, Offset: offset, IncludeVersions: includeVersions})
+}
+
+// QueryServices returns a page of services. With a full-text search every service carries its
+// SearchMatch, with the rank and highlighted snippets; with a fuzzy search its name similarity.
+func (s *Store) QueryServices(ctx context.Context, query ServiceQuery) ([]Service, error) {
+	page, err := s.QueryServicesPage(ctx, query)
+	return page.Items, err
+}
+
+// QueryServicesPage returns a page of services like QueryServices, with the cursors of the pages
+// before and after it. Pages sorted by relevance have no cursors.
+func (s *Store) QueryServicesPage(ctx context.Context, query ServiceQuery) (Page[Service], error) {
+	limit := s.PageSize(query.Limit)
+	m := query.match()
+	searching, fuzzy := m.searching, m.fuzzy
 	col := "name"
-	switch sortKey {
+	switch query.Sort {
 	case "created_at", "updated_at":
-		col = sortKey
+		col = query.Sort
+	case "relevance", "":
+		if searching || fuzzy {
+			col = "rank"
+		}
 	}
 	ord := "ASC"
-	if strings.EqualFold(order, "desc") {
+	if strings.EqualFold(query.Order, "desc") || (col == "rank" && !strings.EqualFold(query.Order, "asc")) {
 		ord = "DESC"
 	}
 
-	var where []string
-	var args []any
-	argn := 1
-	if q != "" {
-		where = append(where, fmt.Sprintf("LOWER(name) LIKE LOWER($%d) || '%%'", argn))
-		args = append(args, q)
-		argn++
-	}
-	whereSQL := ""
-	if len(where) > 0 {
-		whereSQL = "WHERE " + strings.Join(where, " AND ")
+	// Pages are fetched with an extra row telling whether there are more
+	fetchOrd := ord
+	if query.Cursor != nil {
+		if col == "rank" {
+			return Page[Service]{}, errors.New("can't page by relevance with a cursor")
+		}
+		typ := "text"
+		if col != "name" {
+			typ = "timestamptz"
+		}
+		var cond string
+		cond, fetchOrd = keyset(query.Cursor, col, typ, ord, &m.args)
+		m.and(cond)
 	}
 
+	// Snippets are only highlighted for the rows of the page
+	columns := serviceColumns
+	if fuzzy {
+		columns += ", rank"
+	} else if searching {
+		// The delimiters are removed from the text so only the highlights contain them
+		columns += fmt.Sprintf(", rank, ts_headline('%[1]s', translate(name, '%[2]s', ''), query, '%[3]s'), ts_headline('%[1]s', translate(coalesce(description,''), '%[2]s', ''), query, '%[4]s')",
+			searchConfig, highlightStart+highlightStop, nameHighlightOptions, snippetHighlightOptions)
+	}
 	sql := fmt.Sprintf(`
-		SELECT id, name, coalesce(description,''), created_at, updated_at
-		FROM services
-		%s
+		WITH page AS (
+			SELECT services.*%s
+			FROM %s
+			%s
+			ORDER BY %s %s, id %s
+			LIMIT %d OFFSET %d
+		)
+		SELECT %s
+		FROM page
 		ORDER BY %s %s, id %s
-		LIMIT %d OFFSET %d
-	`, whereSQL, col, ord, ord, limit, offset)
-
-	rows, err := s.pool.Query(ctx, sql, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+	`, m.rank, m.from, m.where, col, fetchOrd, fetchOrd, limit+1, query.Offset, columns, col, ord, ord)
 
 	var items []Service
-	for rows.Next() {
-		var x Service
-		if err := rows.Scan(&x.ID, &x.Name, &x.Description, &x.CreatedAt, &x.UpdatedAt); err != nil {
-			return nil, err
+	scan := func(rows pgx.Rows) error {
+		for rows.Next() {
+			var x Service
+			var err error
+			if fuzzy {
+				x.Match = &SearchMatch{}
+				err = rows.Scan(&x.ID, &x.Name, &x.Description, &x.Team, &x.Labels, &x.Revision, &x.CreatedAt, &x.UpdatedAt, &x.Match.Rank)
+			} else if searching {
+				x.Match = &SearchMatch{}
+				err = rows.Scan(&x.ID, &x.Name, &x.Description, &x.Team, &x.Labels, &x.Revision, &x.CreatedAt, &x.UpdatedAt,
+					&x.Match.Rank, &x.Match.Name, &x.Match.Description)
+				x.Match.Name, x.Match.Description = highlight(x.Match.Name), highlight(x.Match.Description)
+			} else {
+				err = scanService(rows, &x)
+			}
+			if err != nil {
+				return err
+			}
+			items = append(items, x)
 		}
-		items = append(items, x)
+		return rows.Err()
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+
+	if err := s.queryMatch(ctx, m, scan, sql); err != nil {
+		return Page[Service]{}, err
 	}
+	page := paginate(items, limit, query.Cursor, query.Offset > 0, func(x Service) Cursor {
+		c := Cursor{Sort: col, Desc: ord == "DESC", Key: x.Name, ID: x.ID}
+		switch col {
+		case "created_at":
+			c.Key = formatKey(x.CreatedAt)
+		case "updated_at":
+			c.Key = formatKey(x.UpdatedAt)
+		}
+		return c
+	})
+	if col == "rank" {
+		page.Next, page.Prev = nil, nil
+	}
+	items = page.Items
 
 	// Preload versions for all services only if requested
-	if includeVersions && len(items) > 0 {
+	var versionsByService map[uuid.UUID][]ServiceVersion
+	if query.IncludeVersions && len(items) > 0 {
 		serviceIDs := make([]uuid.UUID, len(items))
 		for i, service := range items {
 			serviceIDs[i] = service.ID
 		}
-
-		// Build placeholders for IN clause
-		placeholders := make([]string, len(serviceIDs))
-		args := make([]any, len(serviceIDs))
-		for i, id := range serviceIDs {
-			placeholders[i] = fmt.Sprintf("$%d", i+1)
-			args[i] = id
+		var err error
+		if versionsByService, err = s.ListVersionsForServices(ctx, serviceIDs); err != nil {
+			return Page[Service]{}, err
 		}
+	}
+	for i := range items {
+		if versions, exists := versionsByService[items[i].ID]; exists {
+			items[i].Versions = versions
+		} else {
+			items[i].Versions = []ServiceVersion{}
+		}
+	}
 
-		versionsSQL := fmt.Sprintf(`
-			SELECT id, service_id, version, created_at
-			FROM service_versions
-			WHERE service_id IN (%s)
-			ORDER BY service_id, created_at DESC, id DESC
-		`, strings.Join(placeholders, ","))
+	return page, nil
+}
 
-		versionRows, err := s.pool.Query(ctx, versionsSQL, args...)
+// CountServices counts the services matching a query, ignoring its sorting and paging. Counting
+// all services is slow on large tables, so past estimateAbove rows their count is the planner's
+// estimate from pg_class; exact reports whether the count is exact. 0 always counts exactly.
+func (s *Store) CountServices(ctx context.Context, query ServiceQuery, estimateAbove int64) (total int64, exact bool, err error) {
+	m := query.match()
+	if m.where == "" && estimateAbove > 0 {
+		// reltuples is -1 until the table is first analyzed
+		var estimate int64
+		err := s.pool.QueryRow(ctx, `SELECT reltuples::bigint FROM pg_class WHERE oid = 'services'::regclass`).Scan(&estimate)
 		if err != nil {
-			return nil, err
+			return 0, false, err
 		}
-		defer versionRows.Close()
-
-		// Group versions by service_id
-		versionsByService := make(map[uuid.UUID][]ServiceVersion)
-		for versionRows.Next() {
-			var v ServiceVersion
-			if err := versionRows.Scan(&v.ID, &v.ServiceID, &v.Version, &v.CreatedAt); err != nil {
-				return nil, err
-			}
-			versionsByService[v.ServiceID] = append(versionsByService[v.ServiceID], v)
-		}
-		if err := versionRows.Err(); err != nil {
-			return nil, err
+		if estimate > estimateAbove {
+			return estimate, false, nil
 		}
-
-		// Assign versions to services
-		for i := range items {
-			if versions, exists := versionsByService[items[i].ID]; exists {
-				items[i].Versions = versions
-			} else {
-				items[i].Versions = []ServiceVersion{}
+	}
+	err = s.queryMatch(ctx, m, func(rows pgx.Rows) error {
+		for rows.Next() {
+			if err := rows.Scan(&total); err != nil {
+				return err
 			}
 		}
+		return rows.Err()
+	}, fmt.Sprintf(`SELECT count(*) FROM %s %s`, m.from, m.where))
+	if err != nil {
+		return 0, false, err
+	}
+	return total, true, nil
+}
+
+// serviceMatch is the FROM and WHERE clauses of the services a query matches, with their
+// parameters. rank selects the search rank of matches, if searching.
+type serviceMatch struct {
+	from, where, rank string
+	args              []any
+	searching, fuzzy  bool
+}
+
+// match builds the clauses selecting the services of a query, before sorting and paging
+func (query ServiceQuery) match() serviceMatch {
+	m := serviceMatch{from: "services", searching: query.Search != ""}
+	m.fuzzy = query.Fuzzy != "" && !m.searching
+	var where []string
+	if query.Q != "" {
+		m.args = append(m.args, query.Q)
+		where = append(where, fmt.Sprintf("LOWER(name) LIKE LOWER($%d) || '%%'", len(m.args)))
+	}
+	if m.searching {
+		m.args = append(m.args, query.Search)
+		m.from = fmt.Sprintf("services, websearch_to_tsquery('%s', $%d) AS query", searchConfig, len(m.args))
+		where = append(where, "search_vector @@ query")
+		m.rank = ", ts_rank_cd(search_vector, query) AS rank, query"
+	} else if m.fuzzy {
+		m.args = append(m.args, query.Fuzzy)
+		where = append(where, fmt.Sprintf("LOWER(name) %% LOWER($%d)", len(m.args)))
+		m.rank = fmt.Sprintf(", similarity(LOWER(name), LOWER($%d)) AS rank", len(m.args))
+	}
+	if query.Filter != nil {
+		where = append(where, compileServiceFilter(query.Filter, &m.args))
+	}
+	for _, cond := range where {
+		m.and(cond)
+	}
+	return m
+}
+
+// and adds a condition to the WHERE clause
+func (m *serviceMatch) and(cond string) {
+	if m.where == "" {
+		m.where = "WHERE " + cond
 	} else {
-		// Set empty versions array if not requested
-		for i := range items {
-			items[i].Versions = []ServiceVersion{}
-		}
+		m.where += " AND " + cond
 	}
+}
 
-	return items, nil
+// queryMatch runs a query over the services of m, at the fuzzy similarity threshold when fuzzy
+func (s *Store) queryMatch(ctx context.Context, m serviceMatch, scan func(pgx.Rows) error, sql string) error {
+	if m.fuzzy {
+		return s.querySimilar(ctx, scan, sql, m.args...)
+	}
+	return s.query(ctx, scan, sql, m.args...)
 }
 
 func (s *Store) GetService(ctx context.Context, id uuid.UUID, includeVersions bool) (*Service, error) {
-	row := s.pool.QueryRow(ctx, `SELECT id, name, coalesce(description,''), created_at, updated_at FROM services WHERE id = $1`, id)
+	row := s.pool.QueryRow(ctx, `SELECT `+serviceColumns+` FROM services WHERE id = $1`, id)
 	var x Service
-	if err := row.Scan(&x.ID, &x.Name, &x.Description, &x.CreatedAt, &x.UpdatedAt); err != nil {
+	if err := scanService(row, &x); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
@@ -180,7 +400,7 @@ func (s *Store) GetService(ctx context.Context, id uuid.UUID, includeVersions bo
 	// Fetch versions only if requested
 	if includeVersions {
 		versionRows, err := s.pool.Query(ctx, `
-			SELECT id, service_id, version, created_at
+			SELECT `+serviceVersionColumns+`
 			FROM service_versions
 			WHERE service_id = $1
 			ORDER BY created_at DESC, id DESC
@@ -193,7 +413,7 @@ func (s *Store) GetService(ctx context.Context, id uuid.UUID, includeVersions bo
 		var versions []ServiceVersion
 		for versionRows.Next() {
 			var v ServiceVersion
-			if err := versionRows.Scan(&v.ID, &v.ServiceID, &v.Version, &v.CreatedAt); err != nil {
+			if err := scanServiceVersion(versionRows, &v); err != nil {
 				return nil, err
 			}
 			versions = append(versions, v)
@@ -210,15 +430,17 @@ func (s *Store) GetService(ctx context.Context, id uuid.UUID, includeVersions bo
 	return &x, nil
 }
 
-func (s *Store) ListVersions(ctx context.Context, id uuid.UUID) ([]ServiceVersion, error) {
+// ListVersions returns the versions of a service, newest first. verifiedOnly restricts
+// the result to versions whose signature was verified against a trusted key.
+func (s *Store) ListVersions(ctx context.Context, id uuid.UUID, verifiedOnly bool) ([]ServiceVersion, error) {
 	sql := `
-		SELECT id, service_id, version, created_at
+		SELECT ` + serviceVersionColumns + `
 		FROM service_versions
-		WHERE service_id = $1
+		WHERE service_id = $1 AND (NOT $2 OR verified)
 		ORDER BY created_at DESC, id DESC
 	`
 
-	rows, err := s.pool.Query(ctx, sql, id)
+	rows, err := s.pool.Query(ctx, sql, id, verifiedOnly)
 	if err != nil {
 		return nil, err
 	}
@@ -227,7 +449,7 @@ func (s *Store) ListVersions(ctx context.Context, id uuid.UUID) ([]ServiceVersio
 	var versions []ServiceVersion
 	for rows.Next() {
 		var v ServiceVersion
-		if err := rows.Scan(&v.ID, &v.ServiceID, &v.Version, &v.CreatedAt); err != nil {
+		if err := scanServiceVersion(rows, &v); err != nil {
 			return nil, err
 		}
 		versions = append(versions, v)
@@ -240,13 +462,201 @@ func (s *Store) ListVersions(ctx context.Context, id uuid.UUID) ([]ServiceVersio
 	return versions, nil
 }
 
+// ListVersionsPage returns a page of the versions of a service, newest first, continuing from
+// cursor when it isn't nil
+func (s *Store) ListVersionsPage(ctx context.Context, id uuid.UUID, verifiedOnly bool, limit int, cursor *Cursor) (Page[ServiceVersion], error) {
+	limit = s.PageSize(limit)
+	args := []any{id, verifiedOnly}
+	where, fetchOrd := "", "DESC"
+	if cursor != nil {
+		where, fetchOrd = keyset(cursor, "created_at", "timestamptz", "DESC", &args)
+		where = " AND " + where
+	}
+	sql := fmt.Sprintf(`
+		SELECT * FROM (
+			SELECT %s
+			FROM service_versions
+			WHERE service_id = $1 AND (NOT $2 OR verified)%s
+			ORDER BY created_at %s, id %s
+			LIMIT %d
+		) page
+		ORDER BY created_at DESC, id DESC
+	`, serviceVersionColumns, where, fetchOrd, fetchOrd, limit+1)
+
+	versions := []ServiceVersion{}
+	err := s.query(ctx, func(rows pgx.Rows) error {
+		for rows.Next() {
+			var v ServiceVersion
+			if err := scanServiceVersion(rows, &v); err != nil {
+				return err
+			}
+			versions = append(versions, v)
+		}
+		return rows.Err()
+	}, sql, args...)
+	if err != nil {
+		return Page[ServiceVersion]{}, err
+	}
+	return paginate(versions, limit, cursor, false, func(v ServiceVersion) Cursor {
+		return Cursor{Sort: "created_at", Desc: true, Key: formatKey(v.CreatedAt), ID: v.ID}
+	}), nil
+}
+
+// ListVersionsForServices returns the versions of several services in one query, keyed by
+// service ID and newest first
+func (s *Store) ListVersionsForServices(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]ServiceVersion, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+serviceVersionColumns+`
+		FROM service_versions
+		WHERE service_id = ANY($1)
+		ORDER BY service_id, created_at DESC, id DESC
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[uuid.UUID][]ServiceVersion, len(ids))
+	for rows.Next() {
+		var v ServiceVersion
+		if err := scanServiceVersion(rows, &v); err != nil {
+			return nil, err
+		}
+		versions[v.ServiceID] = append(versions[v.ServiceID], v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// VersionWindow is a slice of a service's versions, newest first, and its number of versions
+type VersionWindow struct {
+	Versions []ServiceVersion
+	Total    int
+}
+
+// ListVersionWindows returns the limit versions after the first offset of each of several
+// services, newest first, keyed by service ID. The window is applied per service in SQL, so
+// only the requested versions are read. verifiedOnly restricts the versions and their count to
+// verified ones. Services without such versions are missing from the result.
+func (s *Store) ListVersionWindows(ctx context.Context, ids []uuid.UUID, verifiedOnly bool, offset, limit int) (map[uuid.UUID]VersionWindow, error) {
+	windows := make(map[uuid.UUID]VersionWindow, len(ids))
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT service_id, count(*)
+		FROM service_versions
+		WHERE service_id = ANY($1) AND (NOT $2 OR verified)
+		GROUP BY service_id
+	`, ids, verifiedOnly)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id uuid.UUID
+		var w VersionWindow
+		if err := rows.Scan(&id, &w.Total); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		w.Versions = []ServiceVersion{}
+		windows[id] = w
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.pool.Query(ctx, `
+		SELECT `+serviceVersionColumns+`
+		FROM (
+			SELECT *, row_number() OVER (PARTITION BY service_id ORDER BY created_at DESC, id DESC) AS n
+			FROM service_versions
+			WHERE service_id = ANY($1) AND (NOT $2 OR verified)
+		) v
+		WHERE n > $3 AND n <= $3 + $4
+		ORDER BY service_id, n
+	`, ids, verifiedOnly, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v ServiceVersion
+		if err := scanServiceVersion(rows, &v); err != nil {
+			return nil, err
+		}
+		w := windows[v.ServiceID]
+		w.Versions = append(w.Versions, v)
+		windows[v.ServiceID] = w
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return windows, nil
+}
+
+// GetServicesByID returns several services in one query, keyed by ID. Services that don't
+// exist are missing from the result.
+func (s *Store) GetServicesByID(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]Service, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+serviceColumns+` FROM services WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	services := make(map[uuid.UUID]Service, len(ids))
+	for rows.Next() {
+		var x Service
+		if err := scanService(rows, &x); err != nil {
+			return nil, err
+		}
+		services[x.ID] = x
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return services, nil
+}
+
 // CreateService creates a new service
 func (s *Store) CreateService(ctx context.Context, service *Service) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := createService(ctx, tx, service); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func createService(ctx context.Context, tx pgx.Tx, service *Service) error {
 	service.ID = GenerateUUID()
 	service.CreatedAt = time.Now()
 	service.UpdatedAt = time.Now()
+	if service.Labels == nil {
+		service.Labels = map[string]string{}
+	}
+	service.Revision = 1
 
-	return s.pool.QueryRow(ctx, `INSERT INTO services (id, name, description, created_at, updated_at) VALUES ($1, $2, $3, $4, $5) RETURNING id`, service.ID, service.Name, service.Description, service.CreatedAt, service.UpdatedAt).Scan(&service.ID)
+	if err := tx.QueryRow(ctx, `INSERT INTO services (id, name, description, team, labels, revision, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`, service.ID, service.Name, service.Description, service.Team, service.Labels, service.Revision, service.CreatedAt, service.UpdatedAt).Scan(&service.ID); err != nil {
+		return err
+	}
+	if err := recordRevision(ctx, tx, service, nil); err != nil {
+		return err
+	}
+	if err := audit(ctx, tx, AuditCreate, "service", service.ID.String(), nil, service); err != nil {
+		return err
+	}
+	return enqueue(ctx, tx, EventServiceCreated, service.ID, service)
 }
 
 // CreateServiceVersion creates a new service version
@@ -254,5 +664,30 @@ func (s *Store) CreateServiceVersion(ctx context.Context, serviceVersion *Servic
 	serviceVersion.ID = GenerateUUID()
 	serviceVersion.CreatedAt = time.Now()
 
-	return s.pool.QueryRow(ctx, `INSERT INTO service_versions (id, service_id, version, created_at) VALUES ($1, $2, $3, $4) RETURNING id`, serviceVersion.ID, serviceVersion.ServiceID, serviceVersion.Version, serviceVersion.CreatedAt).Scan(&serviceVersion.ID)
+	if serviceVersion.ArtifactDigests == nil {
+		serviceVersion.ArtifactDigests = []string{}
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO service_versions (id, service_id, version, commit, artifact_digests, signature, verified, key_fingerprint, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, serviceVersion.ID, serviceVersion.ServiceID, serviceVersion.Version, serviceVersion.Commit, serviceVersion.ArtifactDigests,
+		serviceVersion.Signature, serviceVersion.Verified, serviceVersion.KeyFingerprint, serviceVersion.CreatedAt).Scan(&serviceVersion.ID); err != nil {
+		return err
+	}
+	if err := audit(ctx, tx, AuditCreate, "service_version", serviceVersion.ID.String(), nil, serviceVersion); err != nil {
+		return err
+	}
+	if err := enqueue(ctx, tx, EventVersionCreated, serviceVersion.ServiceID, serviceVersion); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
@@ -2,6 +2,7 @@ package models
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"testing"
 	"time"
@@ -340,6 +341,354 @@ func TestStore_ListServices(t *testing.T) {
 	})
 }
 
+func TestStore_SearchServices(t *testing.T) {
+	store, cleanup := testStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	for _, service := range []*Service{
+		{Name: "payments", Description: "Card payments, refunds and billing disputes"},
+		{Name: "billing", Description: "Invoices for customers"},
+		{Name: "search-indexer", Description: "Indexes the catalog"},
+	} {
+		require.NoError(t, store.CreateService(ctx, service))
+	}
+
+	names := func(items []Service) []string {
+		var out []string
+		for _, it := range items {
+			out = append(out, it.Name)
+		}
+		return out
+	}
+
+	t.Run("Matches descriptions, names rank higher", func(t *testing.T) {
+		items, err := store.QueryServices(ctx, ServiceQuery{Search: "billing"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"billing", "payments"}, names(items))
+		require.NotNil(t, items[0].Match)
+		assert.Greater(t, items[0].Match.Rank, items[1].Match.Rank)
+		assert.Equal(t, "<mark>billing</mark>", items[0].Match.Name)
+		assert.Contains(t, items[1].Match.Description, "<mark>billing</mark>")
+	})
+
+	t.Run("Websearch syntax", func(t *testing.T) {
+		items, err := store.QueryServices(ctx, ServiceQuery{Search: "billing -invoices"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"payments"}, names(items))
+
+		items, err = store.QueryServices(ctx, ServiceQuery{Search: `"card payments" or catalog`, Sort: "name"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"payments", "search-indexer"}, names(items))
+
+		// Stemming matches other forms of a word
+		items, err = store.QueryServices(ctx, ServiceQuery{Search: "invoice"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"billing"}, names(items))
+	})
+
+	t.Run("Combined with name prefix and sort", func(t *testing.T) {
+		items, err := store.QueryServices(ctx, ServiceQuery{Q: "pay", Search: "billing"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"payments"}, names(items))
+
+		items, err = store.QueryServices(ctx, ServiceQuery{Search: "billing", Sort: "relevance", Order: "asc"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"payments", "billing"}, names(items))
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		items, err := store.QueryServices(ctx, ServiceQuery{Search: "kubernetes"})
+		require.NoError(t, err)
+		assert.Empty(t, items)
+
+		// Plain listings carry no match
+		items, err = store.QueryServices(ctx, ServiceQuery{})
+		require.NoError(t, err)
+		require.Len(t, items, 3)
+		assert.Nil(t, items[0].Match)
+	})
+
+	t.Run("Escapes markup", func(t *testing.T) {
+		service := &Service{Name: "widgets", Description: "Renders <script>alert(1)</script> widgets \x02 & gadgets"}
+		require.NoError(t, store.CreateService(ctx, service))
+
+		items, err := store.QueryServices(ctx, ServiceQuery{Search: "widgets"})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "<mark>widgets</mark>", items[0].Match.Name)
+		assert.NotContains(t, items[0].Match.Description, "<script>")
+		assert.NotContains(t, items[0].Match.Description, "\x02")
+		assert.Contains(t, items[0].Match.Description, "<mark>widgets</mark>")
+		assert.Contains(t, items[0].Match.Description, "&amp; gadgets")
+	})
+}
+
+func TestStore_FuzzySearch(t *testing.T) {
+	store, cleanup := testStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	for _, name := range []string{"payment-service", "payments-gateway", "billing", "user-service"} {
+		require.NoError(t, store.CreateService(ctx, &Service{Name: name}))
+	}
+
+	t.Run("Typos in names", func(t *testing.T) {
+		items, err := store.QueryServices(ctx, ServiceQuery{Fuzzy: "paymnet-svc"})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "payment-service", items[0].Name)
+		require.NotNil(t, items[0].Match)
+		assert.GreaterOrEqual(t, items[0].Match.Rank, float32(SimilarityThreshold))
+	})
+
+	t.Run("Suggestions", func(t *testing.T) {
+		suggestions, err := store.SuggestServices(ctx, "paym", 10)
+		require.NoError(t, err)
+		require.Len(t, suggestions, 2)
+		assert.Equal(t, "payment-service", suggestions[0].Name)
+		assert.Equal(t, "payments-gateway", suggestions[1].Name)
+
+		suggestions, err = store.SuggestServices(ctx, "Biling", 10)
+		require.NoError(t, err)
+		require.Len(t, suggestions, 1)
+		assert.Equal(t, "billing", suggestions[0].Name)
+
+		suggestions, err = store.SuggestServices(ctx, "paym", 1)
+		require.NoError(t, err)
+		assert.Len(t, suggestions, 1)
+
+		// LIKE wildcards in the prefix are literal
+		suggestions, err = store.SuggestServices(ctx, "%", 10)
+		require.NoError(t, err)
+		assert.Empty(t, suggestions)
+	})
+
+	t.Run("Prefix search still works", func(t *testing.T) {
+		items, err := store.ListServices(ctx, "PAY", "", "", 10, 0, false)
+		require.NoError(t, err)
+		assert.Len(t, items, 2)
+	})
+}
+
+func TestStore_FilterServices(t *testing.T) {
+	store, cleanup := testStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	payments := &Service{Name: "payment-service", Description: "Takes 100% of payments", Team: "payments", Labels: map[string]string{"tier": "1"}}
+	require.NoError(t, store.CreateService(ctx, payments))
+	for _, v := range []string{"1.0.0", "1.1.0", "1.2.0"} {
+		require.NoError(t, store.CreateServiceVersion(ctx, &ServiceVersion{ServiceID: payments.ID, Version: v}))
+	}
+	require.NoError(t, store.CreateService(ctx, &Service{Name: "payments-gateway", Team: "payments"}))
+	require.NoError(t, store.CreateService(ctx, &Service{Name: "billing", Team: "finance", Labels: map[string]string{"tier": "2"}}))
+
+	names := func(filter string) []string {
+		expr, err := ParseServiceFilter(filter)
+		require.NoError(t, err, filter)
+		items, err := store.QueryServices(ctx, ServiceQuery{Filter: expr})
+		require.NoError(t, err, filter)
+		names := []string{}
+		for _, item := range items {
+			names = append(names, item.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"payment-service"}, names(`name ~ "PAY" AND created_at > 2020-01-01 AND versions.count >= 3`))
+	assert.Equal(t, []string{"billing", "payments-gateway"}, names(`versions.count = 0`))
+	assert.Equal(t, []string{"billing", "payment-service"}, names(`labels.tier = "1" OR team = "finance"`))
+	assert.Equal(t, []string{"payments-gateway"}, names(`labels.tier = ""`))
+	assert.Equal(t, []string{"billing"}, names(`NOT (team = "payments")`))
+	assert.Equal(t, []string{}, names(`created_at < 2020-01-01T00:00:00Z`))
+
+	// LIKE wildcards in values are literal
+	assert.Equal(t, []string{"payment-service"}, names(`description ~ "100%"`))
+	assert.Equal(t, []string{}, names(`name ~ "%"`))
+
+	// Filters combine with the other parameters
+	expr, err := ParseServiceFilter(`team = "payments"`)
+	require.NoError(t, err)
+	items, err := store.QueryServices(ctx, ServiceQuery{Fuzzy: "paymnet-svc", Filter: expr})
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "payment-service", items[0].Name)
+
+	_, err = ParseServiceFilter(`owner = "me"`)
+	assert.EqualError(t, err, `column 1: unknown field "owner"`)
+}
+
+func TestStore_ServiceFacets(t *testing.T) {
+	store, cleanup := testStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	payments := &Service{Name: "payment-service", Team: "payments", Labels: map[string]string{"tier": "critical"}}
+	require.NoError(t, store.CreateService(ctx, payments))
+	for _, v := range []string{"1.0.0", "1.1.0"} {
+		require.NoError(t, store.CreateServiceVersion(ctx, &ServiceVersion{ServiceID: payments.ID, Version: v}))
+	}
+	require.NoError(t, store.CreateService(ctx, &Service{Name: "payment-gateway", Team: "payments"}))
+	require.NoError(t, store.CreateService(ctx, &Service{Name: "billing", Team: "finance", Labels: map[string]string{"tier": "critical"}}))
+
+	month := time.Now().UTC().Format("2006-01")
+	facets, err := store.ServiceFacets(ctx, ServiceQuery{Limit: 1}, []string{"team", "name_prefix", "created_month", "updated", "versions", "labels.tier"})
+	require.NoError(t, err)
+	assert.Equal(t, []FacetCount{{"payments", 2}, {"finance", 1}}, facets["team"])
+	assert.Equal(t, []FacetCount{{"payment", 2}, {"billing", 1}}, facets["name_prefix"])
+	assert.Equal(t, []FacetCount{{month, 3}}, facets["created_month"])
+	assert.Equal(t, []FacetCount{{"24h", 3}, {"7d", 0}, {"30d", 0}, {"90d", 0}, {"older", 0}}, facets["updated"])
+	assert.Equal(t, []FacetCount{{"0", 2}, {"1", 0}, {"2-5", 1}, {"6-20", 0}, {"21+", 0}}, facets["versions"])
+	assert.Equal(t, []FacetCount{{"critical", 2}, {"", 1}}, facets["labels.tier"])
+
+	// Counts respect the other filters
+	expr, err := ParseServiceFilter(`labels.tier = "critical"`)
+	require.NoError(t, err)
+	facets, err = store.ServiceFacets(ctx, ServiceQuery{Q: "pay", Filter: expr}, []string{"team", "versions"})
+	require.NoError(t, err)
+	assert.Equal(t, []FacetCount{{"payments", 1}}, facets["team"])
+	assert.Equal(t, []FacetCount{{"0", 0}, {"1", 0}, {"2-5", 1}, {"6-20", 0}, {"21+", 0}}, facets["versions"])
+
+	facets, err = store.ServiceFacets(ctx, ServiceQuery{Fuzzy: "paymnet-service"}, []string{"team"})
+	require.NoError(t, err)
+	assert.Equal(t, []FacetCount{{"payments", 2}}, facets["team"])
+
+	facets, err = store.ServiceFacets(ctx, ServiceQuery{Q: "nothing"}, []string{"team"})
+	require.NoError(t, err)
+	assert.Equal(t, []FacetCount{}, facets["team"])
+}
+
+func TestStore_CursorPagination(t *testing.T) {
+	store, cleanup := testStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		require.NoError(t, store.CreateService(ctx, &Service{Name: name}))
+	}
+	names := func(page Page[Service]) []string {
+		names := []string{}
+		for _, item := range page.Items {
+			names = append(names, item.Name)
+		}
+		return names
+	}
+
+	t.Run("Services forward and back", func(t *testing.T) {
+		page, err := store.QueryServicesPage(ctx, ServiceQuery{Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, names(page))
+		assert.Nil(t, page.Prev)
+		require.NotNil(t, page.Next)
+
+		page, err = store.QueryServicesPage(ctx, ServiceQuery{Limit: 2, Cursor: page.Next})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c", "d"}, names(page))
+		require.NotNil(t, page.Prev)
+
+		// Rows added before the position don't shift the next page
+		require.NoError(t, store.CreateService(ctx, &Service{Name: "aa"}))
+		next, err := store.QueryServicesPage(ctx, ServiceQuery{Limit: 2, Cursor: page.Next})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"e"}, names(next))
+		assert.Nil(t, next.Next)
+
+		prev, err := store.QueryServicesPage(ctx, ServiceQuery{Limit: 2, Cursor: page.Prev})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"aa", "b"}, names(prev))
+		require.NotNil(t, prev.Prev)
+		require.NotNil(t, prev.Next)
+
+		first, err := store.QueryServicesPage(ctx, ServiceQuery{Limit: 2, Cursor: prev.Prev})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, names(first))
+		assert.Nil(t, first.Prev)
+	})
+
+	t.Run("Services by time, descending", func(t *testing.T) {
+		page, err := store.QueryServicesPage(ctx, ServiceQuery{Sort: "created_at", Order: "desc", Limit: 4})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"aa", "e", "d", "c"}, names(page))
+		require.NotNil(t, page.Next)
+		assert.Equal(t, Cursor{Sort: "created_at", Desc: true, Key: page.Next.Key, ID: page.Items[3].ID}, *page.Next)
+
+		page, err = store.QueryServicesPage(ctx, ServiceQuery{Sort: "created_at", Order: "desc", Limit: 4, Cursor: page.Next})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b", "a"}, names(page))
+		assert.Nil(t, page.Next)
+	})
+
+	t.Run("Versions", func(t *testing.T) {
+		service := &Service{Name: "versioned"}
+		require.NoError(t, store.CreateService(ctx, service))
+		for _, v := range []string{"1.0.0", "1.1.0", "1.2.0"} {
+			require.NoError(t, store.CreateServiceVersion(ctx, &ServiceVersion{ServiceID: service.ID, Version: v}))
+		}
+
+		page, err := store.ListVersionsPage(ctx, service.ID, false, 2, nil)
+		require.NoError(t, err)
+		require.Len(t, page.Items, 2)
+		assert.Equal(t, "1.2.0", page.Items[0].Version)
+		require.NotNil(t, page.Next)
+
+		page, err = store.ListVersionsPage(ctx, service.ID, false, 2, page.Next)
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, "1.0.0", page.Items[0].Version)
+		assert.Nil(t, page.Next)
+		require.NotNil(t, page.Prev)
+
+		page, err = store.ListVersionsPage(ctx, service.ID, false, 2, page.Prev)
+		require.NoError(t, err)
+		require.Len(t, page.Items, 2)
+		assert.Equal(t, "1.2.0", page.Items[0].Version)
+		assert.Nil(t, page.Prev)
+	})
+}
+
+func TestStore_CountServices(t *testing.T) {
+	store, cleanup := testStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	for _, name := range []string{"payment-service", "payments-gateway", "billing"} {
+		require.NoError(t, store.CreateService(ctx, &Service{Name: name}))
+	}
+
+	total, exact, err := store.CountServices(ctx, ServiceQuery{Limit: 1, Offset: 2}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.True(t, exact)
+
+	total, exact, err = store.CountServices(ctx, ServiceQuery{Q: "pay"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.True(t, exact)
+
+	total, _, err = store.CountServices(ctx, ServiceQuery{Fuzzy: "paymnet-svc"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	// Past the threshold, unfiltered totals are the planner's estimate
+	_, err = store.pool.Exec(ctx, `ANALYZE services`)
+	require.NoError(t, err)
+	total, exact, err = store.CountServices(ctx, ServiceQuery{}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.False(t, exact)
+
+	assert.Equal(t, store.MaxPageSize(), store.PageSize(0))
+	assert.Equal(t, 1, store.PageSize(1))
+	assert.Equal(t, store.MaxPageSize(), store.PageSize(store.MaxPageSize()+1))
+}
+
 func TestStore_CreateServiceVersion(t *testing.T) {
 	store, cleanup := testStore(t)
 	defer cleanup()
@@ -421,7 +770,7 @@ func TestStore_ListVersions(t *testing.T) {
 	}
 
 	// Test listing versions
-	retrievedVersions, err := store.ListVersions(ctx, service.ID)
+	retrievedVersions, err := store.ListVersions(ctx, service.ID, false)
 	assert.NoError(t, err)
 	assert.Len(t, retrievedVersions, 3)
 
@@ -435,6 +784,27 @@ func TestStore_ListVersions(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, retrieved)
 	assert.Len(t, retrieved.Versions, 3)
+
+	// Test windows of the versions of several services
+	other := &Service{Name: "other-service"}
+	require.NoError(t, store.CreateService(ctx, other))
+	windows, err := store.ListVersionWindows(ctx, []uuid.UUID{service.ID, other.ID}, false, 1, 1)
+	require.NoError(t, err)
+	require.Contains(t, windows, service.ID)
+	assert.Equal(t, 3, windows[service.ID].Total)
+	require.Len(t, windows[service.ID].Versions, 1)
+	assert.Equal(t, "1.1.0", windows[service.ID].Versions[0].Version)
+	assert.NotContains(t, windows, other.ID)
+
+	// A window past the last version still reports the count
+	windows, err = store.ListVersionWindows(ctx, []uuid.UUID{service.ID}, false, 5, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, windows[service.ID].Total)
+	assert.Empty(t, windows[service.ID].Versions)
+
+	windows, err = store.ListVersionWindows(ctx, []uuid.UUID{service.ID}, true, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, windows)
 }
 
 func TestStore_Validation(t *testing.T) {
@@ -511,3 +881,311 @@ func TestStore_Validation(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "duplicate key")
 }
+
+func TestStore_ServiceDependencies(t *testing.T) {
+	store, cleanup := testStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	api := &Service{Name: "api-service", Description: "API service"}
+	require.NoError(t, store.CreateService(ctx, api))
+	db := &Service{Name: "database-service", Description: "Database service"}
+	require.NoError(t, store.CreateService(ctx, db))
+
+	// Test looking up services by name
+	found, err := store.GetServiceByName(ctx, "api-service")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, api.ID, found.ID)
+
+	found, err = store.GetServiceByName(ctx, "missing-service")
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+
+	// Test that ensuring a version twice only inserts it once
+	created, err := store.EnsureServiceVersion(ctx, &ServiceVersion{ServiceID: db.ID, Version: "17-alpine"})
+	require.NoError(t, err)
+	assert.True(t, created)
+	created, err = store.EnsureServiceVersion(ctx, &ServiceVersion{ServiceID: db.ID, Version: "17-alpine"})
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	// Test recording dependencies (repeated edges are ignored)
+	require.NoError(t, store.AddServiceDependency(ctx, api.ID, db.ID))
+	require.NoError(t, store.AddServiceDependency(ctx, api.ID, db.ID))
+
+	deps, err := store.ListDependencies(ctx, api.ID)
+	require.NoError(t, err)
+	require.Len(t, deps, 1)
+	assert.Equal(t, db.ID, deps[0].DependsOnID)
+	assert.Equal(t, "database-service", deps[0].DependsOn)
+
+	deps, err = store.ListDependencies(ctx, db.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, deps)
+	assert.Empty(t, deps)
+
+	deps, err = store.ListDependencies(ctx, GenerateUUID())
+	require.NoError(t, err)
+	assert.Nil(t, deps)
+
+	// Test that a service cannot depend on itself
+	err = store.AddServiceDependency(ctx, api.ID, api.ID)
+	assert.Error(t, err)
+}
+
+func TestStore_SBOMComponents(t *testing.T) {
+	store, cleanup := testStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	service := &Service{Name: "payments", Description: "Payments service"}
+	require.NoError(t, store.CreateService(ctx, service))
+
+	var versions []*ServiceVersion
+	for _, v := range []string{"1.0.0", "1.1.0"} {
+		version := &ServiceVersion{ServiceID: service.ID, Version: v}
+		require.NoError(t, store.CreateServiceVersion(ctx, version))
+		versions = append(versions, version)
+	}
+
+	log4j := func(version string) Component {
+		purl := "pkg:maven/org.apache.logging.log4j/log4j-core@" + version
+		return Component{PURL: purl, Package: "pkg:maven/org.apache.logging.log4j/log4j-core", Name: "log4j-core", Version: version, License: "Apache-2.0"}
+	}
+
+	require.NoError(t, store.ReplaceSBOM(ctx, &SBOM{ServiceVersionID: versions[0].ID, Format: "cyclonedx", Document: []byte(`{}`)},
+		[]Component{log4j("2.14.1")}))
+	require.NoError(t, store.ReplaceSBOM(ctx, &SBOM{ServiceVersionID: versions[1].ID, Format: "cyclonedx", Document: []byte(`{}`)},
+		[]Component{log4j("2.17.1")}))
+
+	// Test exact version lookup
+	usages, err := store.FindComponentUsage(ctx, "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1", true)
+	require.NoError(t, err)
+	require.Len(t, usages, 1)
+	assert.Equal(t, "payments", usages[0].ServiceName)
+	assert.Equal(t, "1.0.0", usages[0].Version)
+
+	// Test package lookup across versions
+	usages, err = store.FindComponentUsage(ctx, "pkg:maven/org.apache.logging.log4j/log4j-core", false)
+	require.NoError(t, err)
+	assert.Len(t, usages, 2)
+
+	// Test that re-uploading replaces the component list
+	require.NoError(t, store.ReplaceSBOM(ctx, &SBOM{ServiceVersionID: versions[0].ID, Format: "spdx", Document: []byte(`{}`)},
+		[]Component{log4j("2.17.1")}))
+	components, err := store.ListVersionComponents(ctx, versions[0].ID)
+	require.NoError(t, err)
+	require.Len(t, components, 1)
+	assert.Equal(t, "2.17.1", components[0].Version)
+
+	usages, err = store.FindComponentUsage(ctx, "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1", true)
+	require.NoError(t, err)
+	assert.Empty(t, usages)
+
+	// Components listed twice are linked once, and keep the license either entry has
+	unlicensed := log4j("2.20.0")
+	unlicensed.License = ""
+	batch := []Component{unlicensed, log4j("2.20.0"), log4j("2.17.1")}
+	require.NoError(t, store.ReplaceVersionPackages(ctx, versions[0].ID, batch))
+	assert.Equal(t, batch[0].ID, batch[1].ID)
+	assert.Equal(t, components[0].ID, batch[2].ID)
+	components, err = store.ListVersionComponents(ctx, versions[0].ID)
+	require.NoError(t, err)
+	require.Len(t, components, 2)
+	assert.Equal(t, "2.17.1", components[0].Version)
+	assert.Equal(t, "Apache-2.0", components[1].License)
+}
+
+func TestStore_PublishOutboxEvents(t *testing.T) {
+	store, cleanup := testStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// Events before a publisher first runs aren't published
+	before := &Service{Name: "ledger"}
+	require.NoError(t, store.CreateService(ctx, before))
+	n, err := store.PublishOutboxEvents(ctx, "test", 10, func(ctx context.Context, events []OutboxEvent) (int, error) {
+		return len(events), nil
+	})
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	service := &Service{Name: "payments"}
+	require.NoError(t, store.CreateService(ctx, service))
+	require.NoError(t, store.CreateServiceVersion(ctx, &ServiceVersion{ServiceID: service.ID, Version: "1.0.0"}))
+
+	// Only the accepted events move the offset
+	var offered []OutboxEvent
+	n, err = store.PublishOutboxEvents(ctx, "test", 10, func(ctx context.Context, events []OutboxEvent) (int, error) {
+		offered = events
+		return 1, assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, 1, n)
+	require.Len(t, offered, 2)
+	assert.Equal(t, EventServiceCreated, offered[0].Type)
+	offset, err := store.PublisherOffset(ctx, "test")
+	require.NoError(t, err)
+	assert.Equal(t, offered[0].ID, offset)
+
+	n, err = store.PublishOutboxEvents(ctx, "test", 10, func(ctx context.Context, events []OutboxEvent) (int, error) {
+		require.Len(t, events, 1)
+		assert.Equal(t, EventVersionCreated, events[0].Type)
+		return len(events), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// A publisher whose offset is held by another replica publishes nothing
+	tx, err := store.pool.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+	_, err = tx.Exec(ctx, `SELECT 1 FROM publisher_offsets WHERE publisher = 'test' FOR UPDATE`)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateService(ctx, &Service{Name: "billing"}))
+	n, err = store.PublishOutboxEvents(ctx, "test", 10, func(ctx context.Context, events []OutboxEvent) (int, error) {
+		t.Fatal("published while another replica holds the offset")
+		return 0, nil
+	})
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestStore_ListOutboxEvents(t *testing.T) {
+	store, cleanup := testStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	payments := &Service{Name: "payments"}
+	require.NoError(t, store.CreateService(ctx, payments))
+	ledger := &Service{Name: "ledger", Labels: map[string]string{"tier": "1"}}
+	require.NoError(t, store.CreateService(ctx, ledger))
+	require.NoError(t, store.CreateServiceVersion(ctx, &ServiceVersion{ServiceID: payments.ID, Version: "1.0.0"}))
+
+	all, last, err := store.ListOutboxEvents(ctx, ChangeFilter{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, all[2].ID, last)
+
+	// A read without matches still moves past the events it read
+	events, last, err := store.ListOutboxEvents(ctx, ChangeFilter{Labels: map[string]string{"tier": "1"}, Limit: 1})
+	require.NoError(t, err)
+	assert.Empty(t, events)
+	assert.Equal(t, all[0].ID, last)
+
+	events, last, err = store.ListOutboxEvents(ctx, ChangeFilter{AfterID: last, Labels: map[string]string{"tier": "1"}, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, ledger.ID, events[0].ServiceID)
+	assert.Equal(t, all[1].ID, last)
+
+	events, last, err = store.ListOutboxEvents(ctx, ChangeFilter{AfterID: last, ServiceIDs: []uuid.UUID{payments.ID}})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, EventVersionCreated, events[0].Type)
+	assert.Equal(t, all[2].ID, last)
+
+	// Past the end, the read stays where it is
+	events, last, err = store.ListOutboxEvents(ctx, ChangeFilter{AfterID: all[2].ID})
+	require.NoError(t, err)
+	assert.Empty(t, events)
+	assert.Equal(t, all[2].ID, last)
+}
+
+func TestStore_PruneOutboxEvents(t *testing.T) {
+	store, cleanup := testStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	sub := &WebhookSubscription{URL: "https://hooks.example.com/catalog", Secret: "0123456789abcdef"}
+	require.NoError(t, store.CreateWebhookSubscription(ctx, sub))
+	_, err := store.PublishOutboxEvents(ctx, "test", 10, func(ctx context.Context, events []OutboxEvent) (int, error) {
+		return len(events), nil
+	})
+	require.NoError(t, err)
+
+	payments := &Service{Name: "payments"}
+	require.NoError(t, store.CreateService(ctx, payments))
+	require.NoError(t, store.CreateService(ctx, &Service{Name: "ledger"}))
+	_, err = store.FanOutOutbox(ctx, 10)
+	require.NoError(t, err)
+	events, _, err := store.ListOutboxEvents(ctx, ChangeFilter{})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	// Events still being delivered or not yet published are kept
+	n, err := store.PruneOutboxEvents(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	_, err = store.pool.Exec(ctx, `UPDATE webhook_deliveries SET status = 'delivered'`)
+	require.NoError(t, err)
+	_, err = store.PublishOutboxEvents(ctx, "test", 1, func(ctx context.Context, events []OutboxEvent) (int, error) {
+		return len(events), nil
+	})
+	require.NoError(t, err)
+
+	// Events created after the cutoff are kept
+	n, err = store.PruneOutboxEvents(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	// Only the event every publisher is past is deleted, with its deliveries
+	n, err = store.PruneOutboxEvents(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	pruned, err := store.GetOutboxEvent(ctx, events[0].ID)
+	require.NoError(t, err)
+	assert.Nil(t, pruned)
+	deliveries, err := store.ListWebhookDeliveries(ctx, sub.ID, "", 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, events[1].ID, deliveries[0].EventID)
+}
+
+func TestStore_SyncLabel(t *testing.T) {
+	store, cleanup := testStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	payments := &Service{Name: "payments", Labels: map[string]string{"tier": "1"}}
+	require.NoError(t, store.CreateService(ctx, payments))
+	ledger := &Service{Name: "ledger", Labels: map[string]string{"stale": "true"}}
+	require.NoError(t, store.CreateService(ctx, ledger))
+
+	added, removed, err := store.SyncLabel(ctx, "stale", "true", []uuid.UUID{payments.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), added)
+	assert.Equal(t, int64(1), removed)
+
+	// Each change is audited with the labels before and after it
+	events, err := store.ListAuditEvents(ctx, AuditFilter{Action: AuditUpdate, ResourceID: payments.ID.String()})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, map[string]string{"tier": "1"}, auditedLabels(t, events[0].Before))
+	assert.Equal(t, map[string]string{"tier": "1", "stale": "true"}, auditedLabels(t, events[0].After))
+
+	events, err = store.ListAuditEvents(ctx, AuditFilter{Action: AuditUpdate, ResourceID: ledger.ID.String()})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, map[string]string{"stale": "true"}, auditedLabels(t, events[0].Before))
+	assert.Equal(t, map[string]string{}, auditedLabels(t, events[0].After))
+
+	// Nothing changes when synced again
+	added, removed, err = store.SyncLabel(ctx, "stale", "true", []uuid.UUID{payments.ID})
+	require.NoError(t, err)
+	assert.Zero(t, added)
+	assert.Zero(t, removed)
+}
+
+// auditedLabels returns the labels of a service recorded in an audit event
+func auditedLabels(t *testing.T, data json.RawMessage) map[string]string {
+	t.Helper()
+	var service Service
+	require.NoError(t, json.Unmarshal(data, &service))
+	return service.Labels
+}
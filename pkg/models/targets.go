@@ -0,0 +1,228 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ScrapeTarget is a Prometheus scrape endpoint registered for a service in one environment
+type ScrapeTarget struct {
+	ID          uuid.UUID         `json:"id"`
+	ServiceID   uuid.UUID         `json:"service_id"`
+	Environment string            `json:"environment"`
+	Address     string            `json:"address"`
+	MetricsPath string            `json:"metrics_path"`
+	Labels      map[string]string `json:"labels"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// DiscoveryTarget is a scrape target joined with the service metadata needed for service discovery
+type DiscoveryTarget struct {
+	ScrapeTarget
+	ServiceName   string
+	Team          string
+	LatestVersion string
+}
+
+// CreateScrapeTarget registers a scrape target for a service
+func (s *Store) CreateScrapeTarget(ctx context.Context, target *ScrapeTarget) error {
+	target.ID = GenerateUUID()
+	target.CreatedAt = time.Now()
+	if target.MetricsPath == "" {
+		target.MetricsPath = "/metrics"
+	}
+	if target.Labels == nil {
+		target.Labels = map[string]string{}
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO scrape_targets (id, service_id, environment, address, metrics_path, labels, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, target.ID, target.ServiceID, target.Environment, target.Address, target.MetricsPath, target.Labels, target.CreatedAt); err != nil {
+		return err
+	}
+	if err := audit(ctx, tx, AuditCreate, "scrape_target", target.ID.String(), nil, target); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListScrapeTargets returns the scrape targets of a service ordered by environment and address
+func (s *Store) ListScrapeTargets(ctx context.Context, serviceID uuid.UUID) ([]ScrapeTarget, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, service_id, environment, address, metrics_path, labels, created_at
+		FROM scrape_targets
+		WHERE service_id = $1
+		ORDER BY environment, address, metrics_path
+	`, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	targets := []ScrapeTarget{}
+	for rows.Next() {
+		var t ScrapeTarget
+		if err := rows.Scan(&t.ID, &t.ServiceID, &t.Environment, &t.Address, &t.MetricsPath, &t.Labels, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+// DeleteScrapeTarget removes a scrape target, reporting whether it existed
+func (s *Store) DeleteScrapeTarget(ctx context.Context, serviceID, targetID uuid.UUID) (bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var t ScrapeTarget
+	err = tx.QueryRow(ctx, `
+		DELETE FROM scrape_targets WHERE id = $1 AND service_id = $2
+		RETURNING id, service_id, environment, address, metrics_path, labels, created_at
+	`, targetID, serviceID).Scan(&t.ID, &t.ServiceID, &t.Environment, &t.Address, &t.MetricsPath, &t.Labels, &t.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := audit(ctx, tx, AuditDelete, "scrape_target", t.ID.String(), t, nil); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+// ListDiscoveryTargets returns every scrape target with its service name, team and newest version.
+// Empty environment or serviceName arguments don't filter.
+func (s *Store) ListDiscoveryTargets(ctx context.Context, environment, serviceName string) ([]DiscoveryTarget, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT t.id, t.service_id, t.environment, t.address, t.metrics_path, t.labels, t.created_at,
+			s.name, s.team, coalesce(v.version, '')
+		FROM scrape_targets t
+		JOIN services s ON s.id = t.service_id
+		LEFT JOIN LATERAL (
+			SELECT version FROM service_versions
+			WHERE service_id = t.service_id
+			ORDER BY created_at DESC, id DESC
+			LIMIT 1
+		) v ON true
+		WHERE ($1 = '' OR t.environment = $1) AND ($2 = '' OR s.name = $2)
+		ORDER BY s.name, t.environment, t.address, t.metrics_path
+	`, environment, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	targets := []DiscoveryTarget{}
+	for rows.Next() {
+		var t DiscoveryTarget
+		if err := rows.Scan(&t.ID, &t.ServiceID, &t.Environment, &t.Address, &t.MetricsPath, &t.Labels, &t.CreatedAt,
+			&t.ServiceName, &t.Team, &t.LatestVersion); err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+// TargetIndex is the position of a scrape target's registration in the audit log
+type TargetIndex struct {
+	// Create is the ID of the audit event creating the target, Modify the ID of the newest event
+	// of the target, its service or the versions of its service, which discovery entries show
+	Create, Modify int64
+}
+
+// ListTargetIndexes returns the audit log positions of scrape targets by ID. Targets created
+// before audit logging have a zero Create.
+func (s *Store) ListTargetIndexes(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]TargetIndex, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT t.id,
+			coalesce((SELECT min(a.id) FROM audit_events a
+				WHERE a.resource_type = 'scrape_target' AND a.resource_id = t.id::text), 0),
+			coalesce(greatest(
+				(SELECT max(a.id) FROM audit_events a
+					WHERE a.resource_type = 'scrape_target' AND a.resource_id = t.id::text),
+				(SELECT max(a.id) FROM audit_events a
+					WHERE a.resource_type = 'service' AND a.resource_id = t.service_id::text),
+				(SELECT max(a.id) FROM audit_events a
+					JOIN service_versions v ON a.resource_type = 'service_version' AND a.resource_id = v.id::text
+					WHERE v.service_id = t.service_id)
+			), 0)
+		FROM scrape_targets t
+		WHERE t.id = ANY($1)
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indexes := make(map[uuid.UUID]TargetIndex, len(ids))
+	for rows.Next() {
+		var id uuid.UUID
+		var x TargetIndex
+		if err := rows.Scan(&id, &x.Create, &x.Modify); err != nil {
+			return nil, err
+		}
+		indexes[id] = x
+	}
+	return indexes, rows.Err()
+}
+
+// ListServiceVersionNames returns every service name mapped to its versions, newest first
+func (s *Store) ListServiceVersionNames(ctx context.Context) (map[string][]string, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT s.name, v.version
+		FROM services s
+		LEFT JOIN service_versions v ON v.service_id = s.id
+		ORDER BY s.name, v.created_at DESC, v.id DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := map[string][]string{}
+	for rows.Next() {
+		var name string
+		var version *string
+		if err := rows.Scan(&name, &version); err != nil {
+			return nil, err
+		}
+		if _, ok := names[name]; !ok {
+			names[name] = []string{}
+		}
+		if version != nil {
+			names[name] = append(names[name], *version)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
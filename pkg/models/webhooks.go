@@ -0,0 +1,328 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// Webhook payload formats
+const (
+	WebhookFormatJSON              = "json"
+	WebhookFormatCloudEvents       = "cloudevents"
+	WebhookFormatCloudEventsBinary = "cloudevents-binary"
+)
+
+// WebhookFormats lists every webhook payload format
+var WebhookFormats = []string{WebhookFormatJSON, WebhookFormatCloudEvents, WebhookFormatCloudEventsBinary}
+
+// WebhookSubscription is an endpoint that receives change events. An empty EventTypes list
+// subscribes to every event type. Format selects the payload encoding.
+type WebhookSubscription struct {
+	ID         uuid.UUID `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"-"`
+	EventTypes []string  `json:"event_types"`
+	Format     string    `json:"format"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookDelivery is the delivery state of one event to one subscription
+type WebhookDelivery struct {
+	ID             uuid.UUID  `json:"id"`
+	SubscriptionID uuid.UUID  `json:"subscription_id"`
+	EventID        int64      `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastError      string     `json:"last_error"`
+	LastStatusCode int        `json:"last_status_code"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// PendingDelivery is a claimed delivery with what the dispatcher needs to send it
+type PendingDelivery struct {
+	WebhookDelivery
+	URL    string
+	Secret string
+	Format string
+	Event  OutboxEvent
+}
+
+// CreateWebhookSubscription registers a webhook endpoint
+func (s *Store) CreateWebhookSubscription(ctx context.Context, sub *WebhookSubscription) error {
+	sub.ID = GenerateUUID()
+	sub.CreatedAt = time.Now()
+	if sub.EventTypes == nil {
+		sub.EventTypes = []string{}
+	}
+	if sub.Format == "" {
+		sub.Format = WebhookFormatJSON
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO webhook_subscriptions (id, url, secret, event_types, format, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, sub.ID, sub.URL, sub.Secret, sub.EventTypes, sub.Format, sub.CreatedAt); err != nil {
+		return err
+	}
+	if err := audit(ctx, tx, AuditCreate, "webhook_subscription", sub.ID.String(), nil, sub); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListWebhookSubscriptions returns every webhook subscription, oldest first
+func (s *Store) ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, url, secret, event_types, format, created_at
+		FROM webhook_subscriptions
+		ORDER BY created_at, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []WebhookSubscription{}
+	for rows.Next() {
+		var sub WebhookSubscription
+		if err := rows.Scan(&sub.ID, &sub.URL, &sub.Secret, &sub.EventTypes, &sub.Format, &sub.CreatedAt); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+// DeleteWebhookSubscription removes a subscription and its deliveries, reporting whether it existed
+func (s *Store) DeleteWebhookSubscription(ctx context.Context, id uuid.UUID) (bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var sub WebhookSubscription
+	err = tx.QueryRow(ctx, `
+		DELETE FROM webhook_subscriptions WHERE id = $1
+		RETURNING id, url, secret, event_types, format, created_at
+	`, id).Scan(&sub.ID, &sub.URL, &sub.Secret, &sub.EventTypes, &sub.Format, &sub.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := audit(ctx, tx, AuditDelete, "webhook_subscription", sub.ID.String(), sub, nil); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+// FanOutOutbox creates a pending delivery for every subscription matching each undispatched
+// outbox event and marks the events dispatched. Events locked by another replica are skipped.
+// It returns the number of events fanned out.
+func (s *Store) FanOutOutbox(ctx context.Context, limit int) (int64, error) {
+	tag, err := s.pool.Exec(ctx, `
+		WITH claimed AS (
+			SELECT id, event_type FROM outbox_events
+			WHERE dispatched_at IS NULL
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), fanned AS (
+			INSERT INTO webhook_deliveries (subscription_id, event_id)
+			SELECT s.id, c.id
+			FROM claimed c
+			JOIN webhook_subscriptions s ON cardinality(s.event_types) = 0 OR c.event_type = ANY(s.event_types)
+			ON CONFLICT DO NOTHING
+		)
+		UPDATE outbox_events SET dispatched_at = now()
+		WHERE id IN (SELECT id FROM claimed)
+	`, limit)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ClaimDueDeliveries returns up to limit pending deliveries whose next attempt is due and pushes
+// their next attempt lease into the future, so a crashed dispatcher's claims are retried later
+// and replicas don't send the same delivery concurrently.
+func (s *Store) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]PendingDelivery, error) {
+	rows, err := s.pool.Query(ctx, `
+		WITH due AS (
+			UPDATE webhook_deliveries
+			SET next_attempt_at = now() + make_interval(secs => $2)
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status = 'pending' AND next_attempt_at <= now()
+				ORDER BY next_attempt_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT d.id, d.subscription_id, d.event_id, e.event_type, d.status, d.attempts, d.next_attempt_at,
+			d.last_error, d.last_status_code, d.delivered_at, d.created_at,
+			s.url, s.secret, s.format, e.service_id, e.payload, e.created_at
+		FROM due d
+		JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		JOIN outbox_events e ON e.id = d.event_id
+		ORDER BY d.event_id
+	`, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []PendingDelivery{}
+	for rows.Next() {
+		var d PendingDelivery
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.LastError, &d.LastStatusCode, &d.DeliveredAt, &d.CreatedAt,
+			&d.URL, &d.Secret, &d.Format, &d.Event.ServiceID, &d.Event.Payload, &d.Event.CreatedAt); err != nil {
+			return nil, err
+		}
+		d.Event.ID = d.EventID
+		d.Event.Type = d.EventType
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// RecordDeliveryAttempt stores the state of a delivery after an attempt
+func (s *Store) RecordDeliveryAttempt(ctx context.Context, d *WebhookDelivery) error {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5, last_status_code = $6, delivered_at = $7
+		WHERE id = $1
+	`, d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.LastStatusCode, d.DeliveredAt)
+	return err
+}
+
+const webhookDeliverySelectSQL = `
+	SELECT d.id, d.subscription_id, d.event_id, e.event_type, d.status, d.attempts, d.next_attempt_at,
+		d.last_error, d.last_status_code, d.delivered_at, d.created_at
+	FROM webhook_deliveries d
+	JOIN outbox_events e ON e.id = d.event_id
+`
+
+func scanWebhookDelivery(row pgx.Row, d *WebhookDelivery) error {
+	return row.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.LastError, &d.LastStatusCode, &d.DeliveredAt, &d.CreatedAt)
+}
+
+// ListWebhookDeliveries returns the most recent deliveries of a subscription, optionally restricted to a status.
+// limit is capped at the maximum page size, which is also the default.
+func (s *Store) ListWebhookDeliveries(ctx context.Context, subscriptionID uuid.UUID, status string, limit int) ([]WebhookDelivery, error) {
+	rows, err := s.pool.Query(ctx, webhookDeliverySelectSQL+`
+		WHERE d.subscription_id = $1 AND ($2::text = '' OR d.status = $2)
+		ORDER BY d.event_id DESC
+		LIMIT $3
+	`, subscriptionID, status, s.PageSize(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		var d WebhookDelivery
+		if err := scanWebhookDelivery(rows, &d); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// RedeliverWebhook resets a delivery to pending with a fresh attempt budget, due immediately.
+// It returns nil if the delivery doesn't exist.
+func (s *Store) RedeliverWebhook(ctx context.Context, subscriptionID, deliveryID uuid.UUID) (*WebhookDelivery, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var before WebhookDelivery
+	err = scanWebhookDelivery(tx.QueryRow(ctx, webhookDeliverySelectSQL+`
+		WHERE d.id = $1 AND d.subscription_id = $2
+		FOR UPDATE OF d
+	`, deliveryID, subscriptionID), &before)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	after := before
+	after.Status = DeliveryPending
+	after.Attempts = 0
+	after.NextAttemptAt = time.Now()
+	after.LastError = ""
+	after.LastStatusCode = 0
+	after.DeliveredAt = nil
+	if _, err := tx.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = 0, next_attempt_at = $3, last_error = '', last_status_code = 0, delivered_at = NULL
+		WHERE id = $1
+	`, after.ID, after.Status, after.NextAttemptAt); err != nil {
+		return nil, err
+	}
+	if err := audit(ctx, tx, AuditUpdate, "webhook_delivery", after.ID.String(), before, after); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &after, nil
+}
+
+// MarshalEvent returns the JSON body delivered for an outbox event
+func MarshalEvent(e OutboxEvent) ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"id":         e.ID,
+		"type":       e.Type,
+		"service_id": e.ServiceID,
+		"created_at": e.CreatedAt.UTC(),
+		"data":       e.Payload,
+	})
+}
@@ -0,0 +1,64 @@
+// Package netguard keeps requests to user-supplied URLs, such as health checks and webhooks, away
+// from internal addresses: loopback, link-local (including cloud metadata endpoints such as
+// 169.254.169.254) and private networks.
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrInternalAddress is returned for URLs and connections to internal addresses
+var ErrInternalAddress = errors.New("internal address")
+
+// Internal reports whether ip is a loopback, link-local, private (RFC 1918 and RFC 4193),
+// unspecified or multicast address
+func Internal(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsPrivate() ||
+		ip.IsUnspecified() || ip.IsMulticast() || ip.IsInterfaceLocalMulticast()
+}
+
+// CheckURL rejects URLs whose host is an internal IP address or a localhost name. Other host
+// names can't be judged before they are resolved, so clients must also dial through Control.
+func CheckURL(u *url.URL) error {
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrInternalAddress
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && Internal(ip) {
+		return ErrInternalAddress
+	}
+	return nil
+}
+
+// Control is a net.Dialer Control function refusing connections to internal addresses. It runs
+// on the resolved address, so it also catches names resolving to internal addresses.
+func Control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if Internal(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrInternalAddress, address)
+	}
+	return nil
+}
+
+// NewClient returns an HTTP client refusing to connect to internal addresses, including
+// after redirects
+func NewClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: Control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would connect on our behalf, out of reach of the dialer
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: transport}
+}
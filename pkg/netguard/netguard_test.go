@@ -0,0 +1,65 @@
+package netguard
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInternal(t *testing.T) {
+	for addr, internal := range map[string]bool{
+		"127.0.0.1":        true,
+		"10.1.2.3":         true,
+		"172.16.0.1":       true,
+		"192.168.1.1":      true,
+		"169.254.169.254":  true,
+		"0.0.0.0":          true,
+		"::1":              true,
+		"fe80::1":          true,
+		"fd00::1":          true,
+		"::ffff:127.0.0.1": true,
+		"8.8.8.8":          false,
+		"172.32.0.1":       false,
+		"2606:4700::1111":  false,
+	} {
+		assert.Equal(t, internal, Internal(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	for raw, allowed := range map[string]bool{
+		"https://example.com/health":              true,
+		"http://8.8.8.8:8080/":                    true,
+		"http://localhost:8080/":                  false,
+		"http://LOCALHOST./":                      false,
+		"http://api.localhost/":                   false,
+		"http://127.0.0.1/":                       false,
+		"http://169.254.169.254/latest/meta-data": false,
+		"http://[::1]:9000/":                      false,
+		"http://192.168.0.10/hook":                false,
+	} {
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		if allowed {
+			assert.NoError(t, CheckURL(u), raw)
+		} else {
+			assert.ErrorIs(t, CheckURL(u), ErrInternalAddress, raw)
+		}
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	_, err = NewClient().Do(req)
+	assert.ErrorIs(t, err, ErrInternalAddress)
+}
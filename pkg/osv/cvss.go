@@ -0,0 +1,143 @@
+package osv
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Severity levels, ordered from least to most severe
+const (
+	SeverityUnknown  = "UNKNOWN"
+	SeverityLow      = "LOW"
+	SeverityMedium   = "MEDIUM"
+	SeverityHigh     = "HIGH"
+	SeverityCritical = "CRITICAL"
+)
+
+var severityRanks = map[string]int{
+	SeverityUnknown:  0,
+	SeverityLow:      1,
+	SeverityMedium:   2,
+	SeverityHigh:     3,
+	SeverityCritical: 4,
+}
+
+// SeverityRank orders severities for sorting; higher is more severe
+func SeverityRank(severity string) int {
+	return severityRanks[severity]
+}
+
+// normalizeSeverity maps database-specific severity names onto our levels
+func normalizeSeverity(s string) string {
+	switch strings.ToUpper(s) {
+	case "CRITICAL":
+		return SeverityCritical
+	case "HIGH":
+		return SeverityHigh
+	case "MODERATE", "MEDIUM":
+		return SeverityMedium
+	case "LOW":
+		return SeverityLow
+	}
+	return SeverityUnknown
+}
+
+// severityForScore maps a CVSS base score to its qualitative rating
+func severityForScore(score float64) string {
+	switch {
+	case score >= 9.0:
+		return SeverityCritical
+	case score >= 7.0:
+		return SeverityHigh
+	case score >= 4.0:
+		return SeverityMedium
+	case score > 0:
+		return SeverityLow
+	}
+	return SeverityUnknown
+}
+
+// CVSS3BaseScore computes the base score of a CVSS v3.0/v3.1 vector such as
+// "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H".
+func CVSS3BaseScore(vector string) (float64, error) {
+	parts := strings.Split(vector, "/")
+	if len(parts) == 0 || !strings.HasPrefix(parts[0], "CVSS:3.") {
+		return 0, fmt.Errorf("not a CVSS v3 vector: %q", vector)
+	}
+
+	metrics := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		k, v, ok := strings.Cut(p, ":")
+		if !ok {
+			return 0, fmt.Errorf("malformed CVSS metric %q", p)
+		}
+		metrics[k] = v
+	}
+
+	weights := map[string]map[string]float64{
+		"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+		"AC": {"L": 0.77, "H": 0.44},
+		"UI": {"N": 0.85, "R": 0.62},
+		"C":  {"H": 0.56, "L": 0.22, "N": 0},
+		"I":  {"H": 0.56, "L": 0.22, "N": 0},
+		"A":  {"H": 0.56, "L": 0.22, "N": 0},
+	}
+	values := make(map[string]float64, len(weights))
+	for metric, table := range weights {
+		w, ok := table[metrics[metric]]
+		if !ok {
+			return 0, fmt.Errorf("missing or invalid CVSS metric %s", metric)
+		}
+		values[metric] = w
+	}
+
+	changed := metrics["S"] == "C"
+	if !changed && metrics["S"] != "U" {
+		return 0, fmt.Errorf("missing or invalid CVSS metric S")
+	}
+
+	var pr float64
+	switch metrics["PR"] {
+	case "N":
+		pr = 0.85
+	case "L":
+		pr = 0.62
+		if changed {
+			pr = 0.68
+		}
+	case "H":
+		pr = 0.27
+		if changed {
+			pr = 0.5
+		}
+	default:
+		return 0, fmt.Errorf("missing or invalid CVSS metric PR")
+	}
+
+	iss := 1 - (1-values["C"])*(1-values["I"])*(1-values["A"])
+	var impact float64
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	} else {
+		impact = 6.42 * iss
+	}
+	if impact <= 0 {
+		return 0, nil
+	}
+
+	exploitability := 8.22 * values["AV"] * values["AC"] * pr * values["UI"]
+	if changed {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), nil
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), nil
+}
+
+// roundUp implements the CVSS v3.1 Roundup function
+func roundUp(x float64) float64 {
+	i := int64(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return float64(i/10000+1) / 10
+}
@@ -0,0 +1,386 @@
+// Package osv loads OSV-format advisories from a local directory and matches them against package URLs,
+// so vulnerability lookups work without network access.
+package osv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Advisory is the subset of the OSV schema used for matching
+type Advisory struct {
+	ID               string     `json:"id"`
+	Summary          string     `json:"summary"`
+	Aliases          []string   `json:"aliases"`
+	Modified         time.Time  `json:"modified"`
+	Withdrawn        *time.Time `json:"withdrawn"`
+	Severity         []Severity `json:"severity"`
+	Affected         []Affected `json:"affected"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+// Severity is an OSV severity score
+type Severity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+// Affected is an affected package entry of an advisory
+type Affected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+		PURL      string `json:"purl"`
+	} `json:"package"`
+	Ranges           []Range  `json:"ranges"`
+	Versions         []string `json:"versions"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+// Range is an affected version range
+type Range struct {
+	Type   string  `json:"type"`
+	Events []Event `json:"events"`
+}
+
+// Event is a range event; exactly one field is set
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+// Vulnerability is an advisory matched against a specific package version
+type Vulnerability struct {
+	ID       string   `json:"id"`
+	Aliases  []string `json:"aliases"`
+	Summary  string   `json:"summary"`
+	Severity string   `json:"severity"`
+	Score    float64  `json:"score,omitempty"`
+	PURL     string   `json:"purl"`
+	Fixed    []string `json:"fixed"`
+}
+
+// entry is an advisory indexed under one affected package
+type entry struct {
+	advisory *Advisory
+	affected Affected
+}
+
+// DB is an in-memory advisory index keyed by package (purl without version)
+type DB struct {
+	mu       sync.RWMutex
+	index    map[string][]entry
+	count    int
+	loadedAt time.Time
+}
+
+// NewDB creates an empty advisory database
+func NewDB() *DB {
+	return &DB{index: map[string][]entry{}}
+}
+
+// Stats returns the number of loaded advisories and when they were loaded
+func (db *DB) Stats() (int, time.Time) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.count, db.loadedAt
+}
+
+// Load replaces the database with every *.json advisory found under dir.
+// Files that can't be parsed are skipped and logged.
+func (db *DB) Load(dir string) error {
+	index := map[string][]entry{}
+	count := 0
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var adv Advisory
+		if err := json.Unmarshal(data, &adv); err != nil {
+			log.Warn().Err(err).Str("file", path).Msg("Skipping unparsable OSV advisory")
+			return nil
+		}
+		if adv.ID == "" || adv.Withdrawn != nil {
+			return nil
+		}
+
+		count++
+		for _, aff := range adv.Affected {
+			key := packageKey(aff)
+			if key == "" {
+				continue
+			}
+			index[key] = append(index[key], entry{advisory: &adv, affected: aff})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load OSV advisories from %s: %w", dir, err)
+	}
+
+	db.mu.Lock()
+	db.index = index
+	db.count = count
+	db.loadedAt = time.Now()
+	db.mu.Unlock()
+	return nil
+}
+
+// Run loads dir immediately and then every interval until ctx is cancelled
+func (db *DB) Run(ctx context.Context, dir string, interval time.Duration) {
+	load := func() {
+		if err := db.Load(dir); err != nil {
+			log.Error().Err(err).Msg("Failed to refresh OSV advisories")
+			return
+		}
+		count, _ := db.Stats()
+		log.Info().Int("advisories", count).Str("dir", dir).Msg("OSV advisories loaded")
+	}
+
+	load()
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			load()
+		}
+	}
+}
+
+// Match returns the advisories affecting the package version identified by purl
+// (e.g. pkg:npm/lodash@4.17.20), most severe first.
+func (db *DB) Match(purl string) []Vulnerability {
+	pkg, version := splitPURL(purl)
+	if version == "" {
+		return []Vulnerability{}
+	}
+
+	db.mu.RLock()
+	entries := db.index[pkg]
+	db.mu.RUnlock()
+
+	vulns := []Vulnerability{}
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if seen[e.advisory.ID] || !affects(e.affected, version) {
+			continue
+		}
+		seen[e.advisory.ID] = true
+
+		severity, score := rate(e.advisory, e.affected)
+		vulns = append(vulns, Vulnerability{
+			ID:       e.advisory.ID,
+			Aliases:  nonNil(e.advisory.Aliases),
+			Summary:  e.advisory.Summary,
+			Severity: severity,
+			Score:    score,
+			PURL:     purl,
+			Fixed:    fixedVersions(e.affected),
+		})
+	}
+	SortVulnerabilities(vulns)
+	return vulns
+}
+
+// SortVulnerabilities orders vulnerabilities by severity, then score, then ID
+func SortVulnerabilities(vulns []Vulnerability) {
+	sort.SliceStable(vulns, func(i, j int) bool {
+		ri, rj := SeverityRank(vulns[i].Severity), SeverityRank(vulns[j].Severity)
+		if ri != rj {
+			return ri > rj
+		}
+		if vulns[i].Score != vulns[j].Score {
+			return vulns[i].Score > vulns[j].Score
+		}
+		return vulns[i].ID < vulns[j].ID
+	})
+}
+
+// affects reports whether version falls in any of the affected versions or ranges
+func affects(aff Affected, version string) bool {
+	for _, v := range aff.Versions {
+		if v == version {
+			return true
+		}
+	}
+
+	for _, r := range aff.Ranges {
+		// GIT ranges refer to commits and can't be evaluated against version strings
+		if r.Type != "SEMVER" && r.Type != "ECOSYSTEM" {
+			continue
+		}
+		if inRange(r.Events, version) {
+			return true
+		}
+	}
+	return false
+}
+
+// inRange evaluates OSV range events in version order
+func inRange(events []Event, version string) bool {
+	sorted := make([]Event, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return CompareVersions(eventVersion(sorted[i]), eventVersion(sorted[j])) < 0
+	})
+
+	affected := false
+	for _, e := range sorted {
+		switch {
+		case e.Introduced != "":
+			if e.Introduced == "0" || CompareVersions(version, e.Introduced) >= 0 {
+				affected = true
+			}
+		case e.Fixed != "":
+			if CompareVersions(version, e.Fixed) >= 0 {
+				affected = false
+			}
+		case e.LastAffected != "":
+			if CompareVersions(version, e.LastAffected) > 0 {
+				affected = false
+			}
+		case e.Limit != "":
+			if CompareVersions(version, e.Limit) >= 0 {
+				affected = false
+			}
+		}
+	}
+	return affected
+}
+
+func eventVersion(e Event) string {
+	switch {
+	case e.Introduced != "":
+		return e.Introduced
+	case e.Fixed != "":
+		return e.Fixed
+	case e.LastAffected != "":
+		return e.LastAffected
+	}
+	return e.Limit
+}
+
+func fixedVersions(aff Affected) []string {
+	fixed := []string{}
+	for _, r := range aff.Ranges {
+		for _, e := range r.Events {
+			if e.Fixed != "" {
+				fixed = append(fixed, e.Fixed)
+			}
+		}
+	}
+	return fixed
+}
+
+// rate derives a severity level and, when a CVSS v3 vector is present, its base score
+func rate(adv *Advisory, aff Affected) (string, float64) {
+	for _, s := range adv.Severity {
+		if s.Type != "CVSS_V3" {
+			continue
+		}
+		if score, err := CVSS3BaseScore(s.Score); err == nil {
+			return severityForScore(score), score
+		}
+	}
+	if sev := normalizeSeverity(aff.DatabaseSpecific.Severity); sev != SeverityUnknown {
+		return sev, 0
+	}
+	return normalizeSeverity(adv.DatabaseSpecific.Severity), 0
+}
+
+// ecosystemTypes maps OSV ecosystems to purl types
+var ecosystemTypes = map[string]string{
+	"npm":       "npm",
+	"PyPI":      "pypi",
+	"Maven":     "maven",
+	"Go":        "golang",
+	"crates.io": "cargo",
+	"RubyGems":  "gem",
+	"NuGet":     "nuget",
+	"Packagist": "composer",
+	"Hex":       "hex",
+	"Pub":       "pub",
+}
+
+// packageKey returns the versionless purl an affected entry applies to
+func packageKey(aff Affected) string {
+	if aff.Package.PURL != "" {
+		pkg, _ := splitPURL(aff.Package.PURL)
+		return pkg
+	}
+
+	typ, ok := ecosystemTypes[aff.Package.Ecosystem]
+	if !ok || aff.Package.Name == "" {
+		return ""
+	}
+	name := aff.Package.Name
+	switch typ {
+	case "maven":
+		// Maven coordinates are group:artifact
+		name = strings.Replace(name, ":", "/", 1)
+	case "pypi":
+		name = strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+	case "npm":
+		name = strings.Replace(name, "@", "%40", 1)
+	}
+	return "pkg:" + typ + "/" + name
+}
+
+// splitPURL splits a purl into its versionless package and version, dropping qualifiers and subpath
+func splitPURL(purl string) (string, string) {
+	if i := strings.IndexAny(purl, "?#"); i >= 0 {
+		purl = purl[:i]
+	}
+	slash := strings.LastIndex(purl, "/")
+	if at := strings.LastIndex(purl, "@"); at > slash {
+		return lowerType(purl[:at]), purl[at+1:]
+	}
+	return lowerType(purl), ""
+}
+
+func lowerType(purl string) string {
+	rest, ok := strings.CutPrefix(purl, "pkg:")
+	if !ok {
+		return purl
+	}
+	typ, name, _ := strings.Cut(rest, "/")
+	return "pkg:" + strings.ToLower(typ) + "/" + name
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
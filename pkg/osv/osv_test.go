@@ -0,0 +1,107 @@
+package osv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0.0", "1.0.1", -1},
+		{"2.14.1", "2.15.0", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0-alpha.2", "1.0.0-alpha.10", -1},
+		{"1.0.0+build.5", "1.0.0", 0},
+		{"1.0", "1.0.0", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, CompareVersions(tt.a, tt.b))
+			assert.Equal(t, -tt.want, CompareVersions(tt.b, tt.a))
+		})
+	}
+}
+
+func TestCVSS3BaseScore(t *testing.T) {
+	score, err := CVSS3BaseScore("CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H")
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, score)
+
+	score, err = CVSS3BaseScore("CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H")
+	require.NoError(t, err)
+	assert.Equal(t, 9.8, score)
+
+	score, err = CVSS3BaseScore("CVSS:3.0/AV:N/AC:H/PR:N/UI:R/S:U/C:L/I:N/A:N")
+	require.NoError(t, err)
+	assert.Equal(t, 3.1, score)
+
+	score, err = CVSS3BaseScore("CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N")
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, score)
+
+	_, err = CVSS3BaseScore("CVSS:2.0/AV:N")
+	assert.Error(t, err)
+	_, err = CVSS3BaseScore("CVSS:3.1/AV:N/AC:L")
+	assert.Error(t, err)
+}
+
+func TestDB_Match(t *testing.T) {
+	db := NewDB()
+	require.NoError(t, db.Load("testdata"))
+
+	count, loadedAt := db.Stats()
+	assert.Equal(t, 2, count) // broken and withdrawn advisories are skipped
+	assert.False(t, loadedAt.IsZero())
+
+	t.Run("Ecosystem range", func(t *testing.T) {
+		vulns := db.Match("pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1")
+		require.Len(t, vulns, 1)
+		assert.Equal(t, "GHSA-jfh8-c2jp-5v3q", vulns[0].ID)
+		assert.Equal(t, SeverityCritical, vulns[0].Severity)
+		assert.Equal(t, 10.0, vulns[0].Score)
+		assert.Equal(t, []string{"2.15.0"}, vulns[0].Fixed)
+
+		assert.Empty(t, db.Match("pkg:maven/org.apache.logging.log4j/log4j-core@2.15.0"))
+		assert.Empty(t, db.Match("pkg:maven/org.apache.logging.log4j/log4j-core@2.12.0"))
+	})
+
+	t.Run("Last affected is inclusive", func(t *testing.T) {
+		vulns := db.Match("pkg:npm/lodash@4.17.19")
+		require.Len(t, vulns, 1)
+		assert.Equal(t, SeverityMedium, vulns[0].Severity)
+
+		assert.Empty(t, db.Match("pkg:npm/lodash@4.17.20"))
+	})
+
+	t.Run("Qualifiers and missing versions", func(t *testing.T) {
+		assert.Len(t, db.Match("pkg:npm/lodash@4.17.0?repository_url=https://example.com"), 1)
+		assert.Empty(t, db.Match("pkg:npm/lodash"))
+		assert.Empty(t, db.Match("pkg:npm/unknown@1.0.0"))
+	})
+}
+
+func TestSortVulnerabilities(t *testing.T) {
+	vulns := []Vulnerability{
+		{ID: "B", Severity: SeverityLow},
+		{ID: "C", Severity: SeverityCritical, Score: 9.1},
+		{ID: "A", Severity: SeverityCritical, Score: 10},
+		{ID: "D", Severity: SeverityUnknown},
+	}
+	SortVulnerabilities(vulns)
+
+	var ids []string
+	for _, v := range vulns {
+		ids = append(ids, v.ID)
+	}
+	assert.Equal(t, []string{"A", "C", "B", "D"}, ids)
+}
@@ -0,0 +1,86 @@
+package osv
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CompareVersions compares two version strings, returning -1, 0 or 1.
+//
+// It follows semver precedence (numeric segments compare numerically, a
+// pre-release sorts before its release, build metadata is ignored) and degrades
+// gracefully for ecosystem versions that aren't strict semver, such as
+// "2.14.1", "1.0.0.Final" or "v1.2".
+func CompareVersions(a, b string) int {
+	aMain, aPre := splitVersion(a)
+	bMain, bPre := splitVersion(b)
+
+	if c := compareSegments(strings.Split(aMain, "."), strings.Split(bMain, ".")); c != 0 {
+		return c
+	}
+
+	// A version without a pre-release has higher precedence than one with
+	switch {
+	case aPre == "" && bPre == "":
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return compareSegments(strings.Split(aPre, "."), strings.Split(bPre, "."))
+}
+
+// splitVersion strips a leading "v" and build metadata and separates the pre-release
+func splitVersion(v string) (string, string) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.Index(v, "+"); i >= 0 {
+		v = v[:i]
+	}
+	main, pre, _ := strings.Cut(v, "-")
+	return main, pre
+}
+
+// compareSegments compares dot-separated identifiers; missing numeric segments count as zero
+func compareSegments(a, b []string) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y string
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x == y {
+			continue
+		}
+		if x == "" {
+			x = "0"
+		}
+		if y == "" {
+			y = "0"
+		}
+
+		xn, xErr := strconv.ParseUint(x, 10, 64)
+		yn, yErr := strconv.ParseUint(y, 10, 64)
+		switch {
+		case xErr == nil && yErr == nil:
+			if xn != yn {
+				if xn < yn {
+					return -1
+				}
+				return 1
+			}
+		case xErr == nil:
+			// Numeric identifiers have lower precedence than alphanumeric ones
+			return -1
+		case yErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(x, y); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
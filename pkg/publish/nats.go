@@ -0,0 +1,96 @@
+package publish
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"kong/pkg/cloudevents"
+	"kong/pkg/models"
+)
+
+// NATS publishes change events as structured CloudEvents to the JetStream subject
+// <prefix>.<event type>, e.g. catalog.service.created. A stream must capture the subjects: events
+// only count as published once the stream has acknowledged storing them. The Nats-Msg-Id header
+// carries the event ID, so a stream drops events published again within its duplicate window;
+// consumers should still deduplicate on the event ID.
+type NATS struct {
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	prefix  string
+	source  string
+	timeout time.Duration
+}
+
+// NewNATS connects to the NATS server at url, retrying in the background while it is unreachable.
+// prefix is the subject prefix and source the CloudEvents source of the events.
+func NewNATS(url, prefix, source string) (*NATS, error) {
+	conn, err := nats.Connect(url, nats.Name("catalog"), nats.MaxReconnects(-1), nats.RetryOnFailedConnect(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	timeout := 10 * time.Second
+	js, err := jetstream.New(conn, jetstream.WithPublishAsyncTimeout(timeout))
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+	return &NATS{conn: conn, js: js, prefix: prefix, source: source, timeout: timeout}, nil
+}
+
+// Name implements Publisher
+func (p *NATS) Name() string { return "nats" }
+
+// Subject returns the subject events of a type are published to
+func (p *NATS) Subject(eventType string) string {
+	if p.prefix == "" {
+		return eventType
+	}
+	return p.prefix + "." + eventType
+}
+
+// Publish implements Publisher. Events are sent without waiting for each other's
+// acknowledgement, and count as published up to the first one the stream didn't acknowledge.
+func (p *NATS) Publish(ctx context.Context, events []models.OutboxEvent) (int, error) {
+	acks := make([]jetstream.PubAckFuture, 0, len(events))
+	var sendErr error
+	for _, e := range events {
+		data, err := cloudevents.MarshalStructured(cloudevents.FromOutbox(e, p.source))
+		if err != nil {
+			sendErr = err
+			break
+		}
+		msg := nats.NewMsg(p.Subject(e.Type))
+		msg.Header.Set(jetstream.MsgIDHeader, strconv.FormatInt(e.ID, 10))
+		msg.Header.Set("Content-Type", cloudevents.ContentTypeStructured)
+		msg.Data = data
+		ack, err := p.js.PublishMsgAsync(msg)
+		if err != nil {
+			sendErr = err
+			break
+		}
+		acks = append(acks, ack)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	for i, ack := range acks {
+		select {
+		case <-ack.Ok():
+		case err := <-ack.Err():
+			return i, err
+		case <-ctx.Done():
+			return i, ctx.Err()
+		}
+	}
+	return len(acks), sendErr
+}
+
+// Close flushes pending messages and closes the connection
+func (p *NATS) Close() error {
+	return p.conn.Drain()
+}
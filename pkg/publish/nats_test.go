@@ -0,0 +1,209 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kong/pkg/cloudevents"
+	"kong/pkg/models"
+)
+
+// startNATSServer runs nats-server with JetStream on port until the test ends, keeping streams in
+// storeDir, and skips the test when nats-server isn't installed
+func startNATSServer(t *testing.T, port int, storeDir string) func() {
+	t.Helper()
+	bin, err := exec.LookPath("nats-server")
+	if err != nil {
+		t.Skip("nats-server not found in PATH")
+	}
+
+	cmd := exec.Command(bin, "-a", "127.0.0.1", "-p", strconv.Itoa(port), "-js", "-sd", storeDir)
+	require.NoError(t, cmd.Start())
+	stop := func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}
+	t.Cleanup(stop)
+
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	}, 5*time.Second, 50*time.Millisecond, "nats-server did not start")
+	return stop
+}
+
+// freePort returns a TCP port nothing listens on
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// createStream creates a JetStream stream capturing subjects
+func createStream(t *testing.T, url string, subjects ...string) jetstream.Stream {
+	t.Helper()
+	conn, err := nats.Connect(url)
+	require.NoError(t, err)
+	t.Cleanup(conn.Close)
+	js, err := jetstream.New(conn)
+	require.NoError(t, err)
+	stream, err := js.CreateStream(context.Background(), jetstream.StreamConfig{Name: "CATALOG", Subjects: subjects})
+	require.NoError(t, err)
+	return stream
+}
+
+func testEvent(id int64, eventType string) models.OutboxEvent {
+	return models.OutboxEvent{
+		ID:        id,
+		Type:      eventType,
+		ServiceID: uuid.New(),
+		Payload:   json.RawMessage(`{"name":"payments"}`),
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// receive returns the next message of sub
+func receive(t *testing.T, sub *nats.Subscription) *nats.Msg {
+	t.Helper()
+	msg, err := sub.NextMsg(5 * time.Second)
+	require.NoError(t, err)
+	return msg
+}
+
+func TestNATS_Publish(t *testing.T) {
+	port := freePort(t)
+	startNATSServer(t, port, t.TempDir())
+	url := fmt.Sprintf("nats://127.0.0.1:%d", port)
+	createStream(t, url, "catalog.>")
+
+	consumer, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer consumer.Close()
+	sub, err := consumer.SubscribeSync("catalog.>")
+	require.NoError(t, err)
+	require.NoError(t, consumer.Flush())
+
+	publisher, err := NewNATS(url, "catalog", "urn:kong:catalog")
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	events := []models.OutboxEvent{testEvent(1, models.EventServiceCreated), testEvent(2, models.EventVersionCreated)}
+	n, err := publisher.Publish(context.Background(), events)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	for _, e := range events {
+		msg := receive(t, sub)
+		assert.Equal(t, "catalog."+e.Type, msg.Subject)
+		assert.Equal(t, strconv.FormatInt(e.ID, 10), msg.Header.Get(nats.MsgIdHdr))
+		assert.Equal(t, cloudevents.ContentTypeStructured, msg.Header.Get("Content-Type"))
+
+		var ce cloudevents.Event
+		require.NoError(t, json.Unmarshal(msg.Data, &ce))
+		assert.Equal(t, cloudevents.Type(e.Type), ce.Type)
+		assert.Equal(t, e.ServiceID.String(), ce.Subject)
+		assert.JSONEq(t, `{"name":"payments"}`, string(ce.Data))
+	}
+}
+
+func TestNATS_ServerRestart(t *testing.T) {
+	port := freePort(t)
+	storeDir := t.TempDir()
+	stop := startNATSServer(t, port, storeDir)
+	url := fmt.Sprintf("nats://127.0.0.1:%d", port)
+	createStream(t, url, "catalog.>")
+
+	publisher, err := NewNATS(url, "catalog", "urn:kong:catalog")
+	require.NoError(t, err)
+	defer publisher.Close()
+	publisher.timeout = 500 * time.Millisecond
+
+	store := &fakeStore{events: []models.OutboxEvent{testEvent(1, models.EventServiceUpdated)}}
+	relay := NewRelay(store, publisher)
+
+	// Events aren't published while the server is down, and the offset stays put
+	stop()
+	n, err := relay.RunOnce(context.Background())
+	assert.Error(t, err)
+	assert.Zero(t, n)
+	assert.Zero(t, store.offset)
+
+	startNATSServer(t, port, storeDir)
+	consumer, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer consumer.Close()
+	sub, err := consumer.SubscribeSync("catalog.service.updated")
+	require.NoError(t, err)
+	require.NoError(t, consumer.Flush())
+
+	// Once the publisher has reconnected, the event is published
+	require.Eventually(t, func() bool {
+		n, _ := relay.RunOnce(context.Background())
+		return n == 1
+	}, 10*time.Second, 100*time.Millisecond)
+	assert.Equal(t, int64(1), store.offset)
+	assert.Equal(t, "1", receive(t, sub).Header.Get(nats.MsgIdHdr))
+}
+
+func TestNATS_RetriesUnstoredEvents(t *testing.T) {
+	port := freePort(t)
+	startNATSServer(t, port, t.TempDir())
+	url := fmt.Sprintf("nats://127.0.0.1:%d", port)
+
+	publisher, err := NewNATS(url, "catalog", "urn:kong:catalog")
+	require.NoError(t, err)
+	defer publisher.Close()
+	publisher.timeout = 2 * time.Second
+
+	store := &fakeStore{events: []models.OutboxEvent{
+		testEvent(1, models.EventServiceCreated),
+		testEvent(2, models.EventVersionCreated),
+		testEvent(3, models.EventServiceUpdated),
+	}}
+	relay := NewRelay(store, publisher)
+
+	// Without a stream nothing is acknowledged, so the offset stays put
+	n, err := relay.RunOnce(context.Background())
+	assert.Error(t, err)
+	assert.Zero(t, n)
+	assert.Zero(t, store.offset)
+
+	// Events are published up to the first one no stream stores
+	stream := createStream(t, url, "catalog.service.>")
+	n, err = relay.RunOnce(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, int64(1), store.offset)
+
+	// Once every subject is stored, the remaining events are published
+	js, err := jetstream.New(publisher.conn)
+	require.NoError(t, err)
+	_, err = js.UpdateStream(context.Background(), jetstream.StreamConfig{Name: "CATALOG", Subjects: []string{"catalog.>"}})
+	require.NoError(t, err)
+	n, err = relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, int64(3), store.offset)
+
+	info, err := stream.Info(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), info.State.Msgs)
+}
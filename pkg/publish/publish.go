@@ -0,0 +1,70 @@
+// Package publish relays catalog change events from the transactional outbox to event buses.
+// Each publisher keeps its own offset in the outbox and only moves it past events the bus has
+// accepted, so delivery is at least once: events are published again after a failure or a
+// crash between publishing and saving the offset.
+package publish
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"kong/pkg/models"
+)
+
+// batchSize is the number of events offered to a publisher at a time
+const batchSize = 100
+
+// Publisher publishes change events to an event bus
+type Publisher interface {
+	// Name identifies the publisher's offset; renaming a publisher starts it from the newest event
+	Name() string
+	// Publish publishes events in order and returns how many, from the first, the bus accepted
+	Publish(ctx context.Context, events []models.OutboxEvent) (int, error)
+}
+
+// Store is the persistence the relay needs; *models.Store implements it
+type Store interface {
+	PublishOutboxEvents(ctx context.Context, publisher string, limit int, publish models.PublishFunc) (int, error)
+}
+
+// Relay publishes new outbox events with a publisher
+type Relay struct {
+	store     Store
+	publisher Publisher
+	poll      time.Duration
+}
+
+// NewRelay creates a relay publishing outbox events with publisher
+func NewRelay(store Store, publisher Publisher) *Relay {
+	return &Relay{store: store, publisher: publisher, poll: time.Second}
+}
+
+// Run publishes events until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.poll)
+	defer ticker.Stop()
+	for {
+		if _, err := r.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Str("publisher", r.publisher.Name()).Msg("Failed to publish change events")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce publishes the pending events and returns how many were published
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := r.store.PublishOutboxEvents(ctx, r.publisher.Name(), batchSize, r.publisher.Publish)
+		total += n
+		if err != nil || n == 0 {
+			return total, err
+		}
+	}
+}
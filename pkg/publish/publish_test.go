@@ -0,0 +1,84 @@
+package publish
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kong/pkg/models"
+)
+
+// fakeStore keeps the outbox and one offset in memory
+type fakeStore struct {
+	events []models.OutboxEvent
+	offset int64
+}
+
+func (s *fakeStore) PublishOutboxEvents(ctx context.Context, publisher string, limit int, publish models.PublishFunc) (int, error) {
+	var batch []models.OutboxEvent
+	for _, e := range s.events {
+		if e.ID > s.offset && len(batch) < limit {
+			batch = append(batch, e)
+		}
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	n, err := publish(ctx, batch)
+	if n > 0 {
+		s.offset = batch[n-1].ID
+	}
+	return n, err
+}
+
+// fakePublisher records published events and fails after accepting a number of them
+type fakePublisher struct {
+	published []int64
+	failAfter int
+}
+
+func (p *fakePublisher) Name() string { return "fake" }
+
+func (p *fakePublisher) Publish(ctx context.Context, events []models.OutboxEvent) (int, error) {
+	for i, e := range events {
+		if p.failAfter == 0 {
+			return i, errors.New("bus unavailable")
+		}
+		p.failAfter--
+		p.published = append(p.published, e.ID)
+	}
+	return len(events), nil
+}
+
+func TestRelay_RunOnce(t *testing.T) {
+	store := &fakeStore{}
+	for id := int64(1); id <= 250; id++ {
+		store.events = append(store.events, models.OutboxEvent{ID: id, Type: models.EventServiceCreated, ServiceID: uuid.New()})
+	}
+	publisher := &fakePublisher{failAfter: 120}
+	relay := NewRelay(store, publisher)
+
+	// Publishing stops at the first failure, after the accepted events
+	n, err := relay.RunOnce(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 120, n)
+	assert.Equal(t, int64(120), store.offset)
+
+	// The next run resumes after the last accepted event
+	publisher.failAfter = -1
+	n, err = relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 130, n)
+	require.Len(t, publisher.published, 250)
+	for i, id := range publisher.published {
+		assert.Equal(t, int64(i+1), id)
+	}
+
+	n, err = relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
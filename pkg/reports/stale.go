@@ -0,0 +1,143 @@
+// Package reports finds catalog entries that need attention, such as abandoned services.
+package reports
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"kong/pkg/models"
+)
+
+// Stale reasons
+const (
+	ReasonNoVersions       = "no_versions"
+	ReasonStaleVersions    = "stale_versions"
+	ReasonEmptyDescription = "empty_description"
+)
+
+// DefaultStaleAfter is used when no stale threshold is configured
+const DefaultStaleAfter = 180 * 24 * time.Hour
+
+// StaleLabel is the label the stale job sets on matching services
+const StaleLabel = "stale"
+
+// StaleService is an entry of the stale report
+type StaleService struct {
+	ServiceID       uuid.UUID  `json:"service_id"`
+	ServiceName     string     `json:"service_name"`
+	Team            string     `json:"team"`
+	Reasons         []string   `json:"reasons"`
+	Versions        int        `json:"versions"`
+	LatestVersion   string     `json:"latest_version,omitempty"`
+	LatestVersionAt *time.Time `json:"latest_version_at,omitempty"`
+}
+
+// FindStale returns services without versions, whose newest version is older than olderThan,
+// or that have an empty description. DefaultStaleAfter applies when olderThan isn't positive.
+func FindStale(ctx context.Context, store *models.Store, olderThan time.Duration, now time.Time) ([]StaleService, error) {
+	if olderThan <= 0 {
+		olderThan = DefaultStaleAfter
+	}
+	cutoff := now.Add(-olderThan)
+	candidates, err := store.ListStaleCandidates(ctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]StaleService, 0, len(candidates))
+	for _, c := range candidates {
+		items = append(items, Classify(c, cutoff))
+	}
+	return items, nil
+}
+
+// Classify turns a stale candidate into a report entry with the reasons it was reported for
+func Classify(c models.StaleCandidate, cutoff time.Time) StaleService {
+	item := StaleService{
+		ServiceID:       c.ServiceID,
+		ServiceName:     c.Name,
+		Team:            c.Team,
+		Reasons:         []string{},
+		Versions:        c.Versions,
+		LatestVersion:   c.LatestVersion,
+		LatestVersionAt: c.LatestVersionAt,
+	}
+	switch {
+	case c.LatestVersionAt == nil:
+		item.Reasons = append(item.Reasons, ReasonNoVersions)
+	case c.LatestVersionAt.Before(cutoff):
+		item.Reasons = append(item.Reasons, ReasonStaleVersions)
+	}
+	if strings.TrimSpace(c.Description) == "" {
+		item.Reasons = append(item.Reasons, ReasonEmptyDescription)
+	}
+	return item
+}
+
+// WriteCSV writes the stale report as CSV with a header row. Reasons are separated by ';'.
+func WriteCSV(w io.Writer, items []StaleService) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"service_id", "service_name", "team", "reasons", "versions", "latest_version", "latest_version_at"}); err != nil {
+		return err
+	}
+	for _, it := range items {
+		latestAt := ""
+		if it.LatestVersionAt != nil {
+			latestAt = it.LatestVersionAt.UTC().Format(time.RFC3339)
+		}
+		if err := cw.Write([]string{
+			it.ServiceID.String(),
+			it.ServiceName,
+			it.Team,
+			strings.Join(it.Reasons, ";"),
+			strconv.Itoa(it.Versions),
+			it.LatestVersion,
+			latestAt,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// RunStaleLabeler labels every stale service stale=true, and removes the label from services that
+// are no longer stale, immediately and then every interval until ctx is cancelled
+func RunStaleLabeler(ctx context.Context, store *models.Store, olderThan, interval time.Duration) {
+	label := func() {
+		items, err := FindStale(ctx, store, olderThan, time.Now())
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to find stale services")
+			return
+		}
+		ids := make([]uuid.UUID, len(items))
+		for i, it := range items {
+			ids[i] = it.ServiceID
+		}
+		added, removed, err := store.SyncLabel(ctx, StaleLabel, "true", ids)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to label stale services")
+			return
+		}
+		log.Info().Int("stale", len(items)).Int64("labeled", added).Int64("unlabeled", removed).Msg("Stale services labeled")
+	}
+
+	label()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			label()
+		}
+	}
+}
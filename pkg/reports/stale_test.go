@@ -0,0 +1,53 @@
+package reports
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kong/pkg/models"
+)
+
+func TestClassify(t *testing.T) {
+	now := time.Now()
+	cutoff := now.Add(-30 * 24 * time.Hour)
+	old := now.Add(-60 * 24 * time.Hour)
+
+	tests := []struct {
+		name      string
+		candidate models.StaleCandidate
+		reasons   []string
+	}{
+		{"No versions", models.StaleCandidate{Description: "svc"}, []string{ReasonNoVersions}},
+		{"Old version", models.StaleCandidate{Description: "svc", Versions: 3, LatestVersionAt: &old}, []string{ReasonStaleVersions}},
+		{"Recent version with empty description", models.StaleCandidate{Description: "  ", Versions: 1, LatestVersionAt: &now}, []string{ReasonEmptyDescription}},
+		{"Everything", models.StaleCandidate{LatestVersionAt: &old}, []string{ReasonStaleVersions, ReasonEmptyDescription}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.reasons, Classify(tt.candidate, cutoff).Reasons)
+		})
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	id := uuid.New()
+	items := []StaleService{
+		{ServiceID: id, ServiceName: "billing, legacy", Team: "core", Reasons: []string{ReasonStaleVersions, ReasonEmptyDescription}, Versions: 2, LatestVersion: "1.4.0", LatestVersionAt: &at},
+		{ServiceID: id, ServiceName: "empty", Reasons: []string{ReasonNoVersions}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, items))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "service_id,service_name,team,reasons,versions,latest_version,latest_version_at", lines[0])
+	assert.Equal(t, id.String()+`,"billing, legacy",core,stale_versions;empty_description,2,1.4.0,2024-03-01T12:00:00Z`, lines[1])
+	assert.Equal(t, id.String()+",empty,,no_versions,0,,", lines[2])
+}
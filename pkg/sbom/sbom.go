@@ -0,0 +1,209 @@
+// Package sbom parses CycloneDX and SPDX JSON documents into a normalized component list.
+package sbom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	FormatCycloneDX = "cyclonedx"
+	FormatSPDX      = "spdx"
+)
+
+// ErrUnknownFormat is returned for JSON documents that are neither CycloneDX nor SPDX
+var ErrUnknownFormat = errors.New("document is neither CycloneDX nor SPDX JSON")
+
+// Component is a normalized SBOM component
+type Component struct {
+	PURL    string `json:"purl"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	License string `json:"license"`
+}
+
+// Document is a parsed SBOM
+type Document struct {
+	Format      string      `json:"format"`
+	SpecVersion string      `json:"spec_version"`
+	Components  []Component `json:"components"`
+}
+
+// Parse detects the SBOM format and extracts its components. Components are
+// de-duplicated by purl; components without a purl get a pkg:generic one.
+func Parse(data []byte) (*Document, error) {
+	var probe struct {
+		BOMFormat   string `json:"bomFormat"`
+		SpecVersion string `json:"specVersion"`
+		SPDXVersion string `json:"spdxVersion"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var doc *Document
+	var err error
+	switch {
+	case strings.EqualFold(probe.BOMFormat, "CycloneDX"):
+		doc, err = parseCycloneDX(data, probe.SpecVersion)
+	case probe.SPDXVersion != "":
+		doc, err = parseSPDX(data, probe.SPDXVersion)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(doc.Components))
+	components := doc.Components[:0]
+	for _, c := range doc.Components {
+		if c.Name == "" {
+			continue
+		}
+		if c.PURL == "" {
+			c.PURL = "pkg:generic/" + c.Name
+			if c.Version != "" {
+				c.PURL += "@" + c.Version
+			}
+		}
+		c.PURL = NormalizePURL(c.PURL)
+		if seen[c.PURL] {
+			continue
+		}
+		seen[c.PURL] = true
+		components = append(components, c)
+	}
+	doc.Components = components
+
+	return doc, nil
+}
+
+// NormalizePURL lowercases the purl type and drops qualifiers and subpath,
+// so the same package version always has the same key.
+func NormalizePURL(purl string) string {
+	if i := strings.IndexAny(purl, "?#"); i >= 0 {
+		purl = purl[:i]
+	}
+	rest, ok := strings.CutPrefix(purl, "pkg:")
+	if !ok {
+		return purl
+	}
+	typ, name, ok := strings.Cut(rest, "/")
+	if !ok {
+		return purl
+	}
+	return "pkg:" + strings.ToLower(typ) + "/" + name
+}
+
+// PackageOf strips the version from a normalized purl
+func PackageOf(purl string) string {
+	if i := strings.LastIndex(purl, "@"); i >= 0 {
+		return purl[:i]
+	}
+	return purl
+}
+
+type cdxLicense struct {
+	License struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"license"`
+	Expression string `json:"expression"`
+}
+
+type cdxComponent struct {
+	Name       string         `json:"name"`
+	Group      string         `json:"group"`
+	Version    string         `json:"version"`
+	PURL       string         `json:"purl"`
+	Licenses   []cdxLicense   `json:"licenses"`
+	Components []cdxComponent `json:"components"`
+}
+
+func parseCycloneDX(data []byte, specVersion string) (*Document, error) {
+	var bom struct {
+		Components []cdxComponent `json:"components"`
+	}
+	if err := json.Unmarshal(data, &bom); err != nil {
+		return nil, fmt.Errorf("invalid CycloneDX document: %w", err)
+	}
+
+	doc := &Document{Format: FormatCycloneDX, SpecVersion: specVersion, Components: []Component{}}
+	var walk func([]cdxComponent)
+	walk = func(list []cdxComponent) {
+		for _, c := range list {
+			name := c.Name
+			if c.Group != "" {
+				name = c.Group + ":" + c.Name
+			}
+			var licenses []string
+			for _, l := range c.Licenses {
+				switch {
+				case l.Expression != "":
+					licenses = append(licenses, l.Expression)
+				case l.License.ID != "":
+					licenses = append(licenses, l.License.ID)
+				case l.License.Name != "":
+					licenses = append(licenses, l.License.Name)
+				}
+			}
+			doc.Components = append(doc.Components, Component{
+				PURL:    c.PURL,
+				Name:    name,
+				Version: c.Version,
+				License: strings.Join(licenses, " OR "),
+			})
+			walk(c.Components)
+		}
+	}
+	walk(bom.Components)
+
+	return doc, nil
+}
+
+func parseSPDX(data []byte, spdxVersion string) (*Document, error) {
+	var spdx struct {
+		Packages []struct {
+			Name             string `json:"name"`
+			VersionInfo      string `json:"versionInfo"`
+			LicenseConcluded string `json:"licenseConcluded"`
+			LicenseDeclared  string `json:"licenseDeclared"`
+			ExternalRefs     []struct {
+				ReferenceType    string `json:"referenceType"`
+				ReferenceLocator string `json:"referenceLocator"`
+			} `json:"externalRefs"`
+		} `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &spdx); err != nil {
+		return nil, fmt.Errorf("invalid SPDX document: %w", err)
+	}
+
+	doc := &Document{Format: FormatSPDX, SpecVersion: spdxVersion, Components: []Component{}}
+	for _, p := range spdx.Packages {
+		c := Component{Name: p.Name, Version: p.VersionInfo}
+		for _, ref := range p.ExternalRefs {
+			if ref.ReferenceType == "purl" {
+				c.PURL = ref.ReferenceLocator
+				break
+			}
+		}
+		c.License = spdxLicense(p.LicenseConcluded)
+		if c.License == "" {
+			c.License = spdxLicense(p.LicenseDeclared)
+		}
+		doc.Components = append(doc.Components, c)
+	}
+
+	return doc, nil
+}
+
+// spdxLicense maps SPDX "no information" values to an empty license
+func spdxLicense(l string) string {
+	if l == "NOASSERTION" || l == "NONE" {
+		return ""
+	}
+	return l
+}
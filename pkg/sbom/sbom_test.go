@@ -0,0 +1,87 @@
+package sbom
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_CycloneDX(t *testing.T) {
+	data := []byte(`{
+		"bomFormat": "CycloneDX",
+		"specVersion": "1.5",
+		"components": [
+			{
+				"group": "org.apache.logging.log4j",
+				"name": "log4j-core",
+				"version": "2.14.1",
+				"purl": "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1?type=jar",
+				"licenses": [{"license": {"id": "Apache-2.0"}}],
+				"components": [
+					{"name": "log4j-api", "version": "2.14.1", "purl": "pkg:maven/org.apache.logging.log4j/log4j-api@2.14.1"}
+				]
+			},
+			{"name": "left-pad", "version": "1.3.0", "licenses": [{"expression": "MIT OR ISC"}]},
+			{"name": "log4j-core", "version": "2.14.1", "purl": "pkg:MAVEN/org.apache.logging.log4j/log4j-core@2.14.1"}
+		]
+	}`)
+
+	doc, err := Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, FormatCycloneDX, doc.Format)
+	assert.Equal(t, "1.5", doc.SpecVersion)
+	require.Len(t, doc.Components, 3)
+
+	assert.Equal(t, Component{
+		PURL:    "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1",
+		Name:    "org.apache.logging.log4j:log4j-core",
+		Version: "2.14.1",
+		License: "Apache-2.0",
+	}, doc.Components[0])
+	assert.Equal(t, "pkg:maven/org.apache.logging.log4j/log4j-api@2.14.1", doc.Components[1].PURL)
+	assert.Equal(t, "pkg:generic/left-pad@1.3.0", doc.Components[2].PURL)
+	assert.Equal(t, "MIT OR ISC", doc.Components[2].License)
+}
+
+func TestParse_SPDX(t *testing.T) {
+	data := []byte(`{
+		"spdxVersion": "SPDX-2.3",
+		"packages": [
+			{
+				"name": "log4j-core",
+				"versionInfo": "2.14.1",
+				"licenseConcluded": "NOASSERTION",
+				"licenseDeclared": "Apache-2.0",
+				"externalRefs": [
+					{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1"}
+				]
+			},
+			{"name": "zlib", "versionInfo": "1.3", "licenseConcluded": "Zlib"}
+		]
+	}`)
+
+	doc, err := Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, FormatSPDX, doc.Format)
+	assert.Equal(t, "SPDX-2.3", doc.SpecVersion)
+	require.Len(t, doc.Components, 2)
+	assert.Equal(t, "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1", doc.Components[0].PURL)
+	assert.Equal(t, "Apache-2.0", doc.Components[0].License)
+	assert.Equal(t, "pkg:generic/zlib@1.3", doc.Components[1].PURL)
+	assert.Equal(t, "Zlib", doc.Components[1].License)
+}
+
+func TestParse_UnknownFormat(t *testing.T) {
+	_, err := Parse([]byte(`{"hello": "world"}`))
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+
+	_, err = Parse([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestNormalizePURL(t *testing.T) {
+	assert.Equal(t, "pkg:npm/%40angular/core@16.0.0", NormalizePURL("pkg:NPM/%40angular/core@16.0.0?arch=x86#src"))
+	assert.Equal(t, "pkg:npm/%40angular/core", PackageOf("pkg:npm/%40angular/core@16.0.0"))
+	assert.Equal(t, "pkg:npm/left-pad", PackageOf("pkg:npm/left-pad"))
+}
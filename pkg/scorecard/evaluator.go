@@ -0,0 +1,104 @@
+package scorecard
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"kong/pkg/models"
+)
+
+// Evaluator evaluates services against a set of checks and stores their scorecards
+type Evaluator struct {
+	store  *models.Store
+	checks []Check
+}
+
+// NewEvaluator creates an evaluator for already validated checks
+func NewEvaluator(store *models.Store, checks []Check) *Evaluator {
+	return &Evaluator{store: store, checks: checks}
+}
+
+// Checks returns the configured checks
+func (e *Evaluator) Checks() []Check { return e.checks }
+
+// EvaluateService evaluates and stores the scorecard of one service. It returns nil if the service doesn't exist.
+func (e *Evaluator) EvaluateService(ctx context.Context, id uuid.UUID) (*models.Scorecard, error) {
+	subject, err := e.store.GetScorecardSubject(ctx, id)
+	if err != nil || subject == nil {
+		return nil, err
+	}
+	return e.save(ctx, subject)
+}
+
+// EvaluateAll evaluates and stores the scorecard of every service, returning how many were evaluated
+func (e *Evaluator) EvaluateAll(ctx context.Context) (int, error) {
+	subjects, err := e.store.ListScorecardSubjects(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i := range subjects {
+		if _, err := e.save(ctx, &subjects[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(subjects), nil
+}
+
+// Run evaluates every service immediately and then every interval until ctx is cancelled.
+// Scheduled runs keep time-based checks such as recent_version current.
+func (e *Evaluator) Run(ctx context.Context, interval time.Duration) {
+	evaluate := func() {
+		n, err := e.EvaluateAll(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to evaluate scorecards")
+			return
+		}
+		log.Info().Int("services", n).Msg("Scorecards evaluated")
+	}
+
+	evaluate()
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			evaluate()
+		}
+	}
+}
+
+func (e *Evaluator) save(ctx context.Context, subject *models.ScorecardSubject) (*models.Scorecard, error) {
+	res := Evaluate(e.checks, Subject{
+		Description:     subject.Description,
+		Team:            subject.Team,
+		Labels:          subject.Labels,
+		LatestVersionAt: subject.LatestVersionAt,
+	}, time.Now())
+
+	checks, err := json.Marshal(res.Checks)
+	if err != nil {
+		return nil, err
+	}
+	sc := &models.Scorecard{
+		ServiceID:   subject.ID,
+		ServiceName: subject.Name,
+		Team:        subject.Team,
+		Score:       res.Score,
+		Passed:      res.Passed,
+		Total:       res.Total,
+		Checks:      checks,
+	}
+	if err := e.store.SaveScorecard(ctx, sc); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
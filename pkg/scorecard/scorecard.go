@@ -0,0 +1,201 @@
+// Package scorecard evaluates services against configurable maturity checks declared in YAML.
+package scorecard
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	yaml "sigs.k8s.io/yaml/goyaml.v2"
+)
+
+// Check types
+const (
+	TypeDescriptionLength = "description_length"
+	TypeHasTeam           = "has_team"
+	TypeRecentVersion     = "recent_version"
+	TypeHasLabel          = "has_label"
+)
+
+// Check is a single scorecard check as declared in the checks file
+type Check struct {
+	ID     string   `yaml:"id" json:"id"`
+	Name   string   `yaml:"name" json:"name"`
+	Type   string   `yaml:"type" json:"type"`
+	Weight int      `yaml:"weight" json:"weight"`
+	Min    int      `yaml:"min" json:"min,omitempty"`       // description_length: minimum characters
+	Days   int      `yaml:"days" json:"days,omitempty"`     // recent_version: maximum age of the newest version
+	Label  string   `yaml:"label" json:"label,omitempty"`   // has_label: label key
+	Values []string `yaml:"values" json:"values,omitempty"` // has_label: allowed values, any when empty
+}
+
+// Config is the checks file
+type Config struct {
+	Checks []Check `yaml:"checks"`
+}
+
+// Subject is what checks are evaluated against
+type Subject struct {
+	Description     string
+	Team            string
+	Labels          map[string]string
+	LatestVersionAt *time.Time // nil when the service has no versions
+}
+
+// CheckResult is the outcome of one check
+type CheckResult struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Weight  int    `json:"weight"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message"`
+}
+
+// Result is the scorecard of one service. Score is the weighted percentage of passed checks.
+type Result struct {
+	Score  int           `json:"score"`
+	Passed int           `json:"passed"`
+	Total  int           `json:"total"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// DefaultChecks are used when no checks file is configured
+func DefaultChecks() []Check {
+	return []Check{
+		{ID: "description", Name: "Has a description of at least 50 characters", Type: TypeDescriptionLength, Min: 50, Weight: 1},
+		{ID: "owner", Name: "Has an owning team", Type: TypeHasTeam, Weight: 2},
+		{ID: "recent_version", Name: "Has a version in the last 90 days", Type: TypeRecentVersion, Days: 90, Weight: 1},
+		{ID: "tier", Name: "Has a tier label", Type: TypeHasLabel, Label: "tier", Weight: 1},
+	}
+}
+
+// Load reads and validates a checks file
+func Load(path string) ([]Check, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scorecard checks: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse scorecard checks %s: %w", path, err)
+	}
+	if err := Validate(cfg.Checks); err != nil {
+		return nil, fmt.Errorf("invalid scorecard checks %s: %w", path, err)
+	}
+	return cfg.Checks, nil
+}
+
+// Validate checks that every check is complete and IDs are unique. Missing weights default to 1.
+func Validate(checks []Check) error {
+	if len(checks) == 0 {
+		return fmt.Errorf("no checks declared")
+	}
+
+	seen := map[string]bool{}
+	for i := range checks {
+		c := &checks[i]
+		if c.ID == "" {
+			return fmt.Errorf("check %d: id is required", i+1)
+		}
+		if seen[c.ID] {
+			return fmt.Errorf("check %s: duplicate id", c.ID)
+		}
+		seen[c.ID] = true
+		if c.Name == "" {
+			c.Name = c.ID
+		}
+		if c.Weight == 0 {
+			c.Weight = 1
+		}
+		if c.Weight < 0 {
+			return fmt.Errorf("check %s: weight must be positive", c.ID)
+		}
+
+		switch c.Type {
+		case TypeDescriptionLength:
+			if c.Min <= 0 {
+				return fmt.Errorf("check %s: min must be positive", c.ID)
+			}
+		case TypeHasTeam:
+		case TypeRecentVersion:
+			if c.Days <= 0 {
+				return fmt.Errorf("check %s: days must be positive", c.ID)
+			}
+		case TypeHasLabel:
+			if c.Label == "" {
+				return fmt.Errorf("check %s: label is required", c.ID)
+			}
+		default:
+			return fmt.Errorf("check %s: unknown type %q", c.ID, c.Type)
+		}
+	}
+	return nil
+}
+
+// Evaluate runs every check against a subject
+func Evaluate(checks []Check, s Subject, now time.Time) Result {
+	res := Result{Total: len(checks), Checks: make([]CheckResult, 0, len(checks))}
+	weight, passedWeight := 0, 0
+
+	for _, c := range checks {
+		passed, msg := evaluate(c, s, now)
+		res.Checks = append(res.Checks, CheckResult{ID: c.ID, Name: c.Name, Weight: c.Weight, Passed: passed, Message: msg})
+		weight += c.Weight
+		if passed {
+			res.Passed++
+			passedWeight += c.Weight
+		}
+	}
+
+	if weight > 0 {
+		res.Score = (passedWeight*100 + weight/2) / weight
+	}
+	return res
+}
+
+func evaluate(c Check, s Subject, now time.Time) (bool, string) {
+	switch c.Type {
+	case TypeDescriptionLength:
+		n := utf8.RuneCountInString(strings.TrimSpace(s.Description))
+		if n >= c.Min {
+			return true, fmt.Sprintf("description has %d characters", n)
+		}
+		return false, fmt.Sprintf("description has %d characters, needs at least %d", n, c.Min)
+
+	case TypeHasTeam:
+		if strings.TrimSpace(s.Team) != "" {
+			return true, "owned by " + s.Team
+		}
+		return false, "no owning team"
+
+	case TypeRecentVersion:
+		if s.LatestVersionAt == nil {
+			return false, "no versions registered"
+		}
+		elapsed := now.Sub(*s.LatestVersionAt)
+		age := int(elapsed.Hours() / 24)
+		if elapsed <= time.Duration(c.Days)*24*time.Hour {
+			return true, fmt.Sprintf("newest version is %d days old", age)
+		}
+		return false, fmt.Sprintf("newest version is %d days old, needs one within %d days", age, c.Days)
+
+	case TypeHasLabel:
+		v, ok := s.Labels[c.Label]
+		if !ok || v == "" {
+			return false, fmt.Sprintf("label %q is not set", c.Label)
+		}
+		if len(c.Values) == 0 {
+			return true, fmt.Sprintf("%s=%s", c.Label, v)
+		}
+		for _, allowed := range c.Values {
+			if v == allowed {
+				return true, fmt.Sprintf("%s=%s", c.Label, v)
+			}
+		}
+		return false, fmt.Sprintf("label %s=%s is not one of %s", c.Label, v, strings.Join(c.Values, ", "))
+	}
+	return false, "unknown check type"
+}
@@ -0,0 +1,79 @@
+package scorecard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	recent := now.AddDate(0, 0, -10)
+	old := now.AddDate(0, 0, -120)
+
+	t.Run("All checks pass", func(t *testing.T) {
+		res := Evaluate(DefaultChecks(), Subject{
+			Description:     strings.Repeat("x", 60),
+			Team:            "payments",
+			Labels:          map[string]string{"tier": "1"},
+			LatestVersionAt: &recent,
+		}, now)
+		assert.Equal(t, 100, res.Score)
+		assert.Equal(t, 4, res.Passed)
+		assert.Equal(t, 4, res.Total)
+	})
+
+	t.Run("Empty service", func(t *testing.T) {
+		res := Evaluate(DefaultChecks(), Subject{}, now)
+		assert.Equal(t, 0, res.Score)
+		assert.Equal(t, 0, res.Passed)
+		for _, c := range res.Checks {
+			assert.False(t, c.Passed, c.ID)
+			assert.NotEmpty(t, c.Message, c.ID)
+		}
+	})
+
+	t.Run("Score is weighted", func(t *testing.T) {
+		// Only the owner check (weight 2 of 5) passes
+		res := Evaluate(DefaultChecks(), Subject{Team: "payments", LatestVersionAt: &old}, now)
+		assert.Equal(t, 40, res.Score)
+		assert.Equal(t, 1, res.Passed)
+	})
+
+	t.Run("Label values", func(t *testing.T) {
+		checks := []Check{{ID: "tier", Type: TypeHasLabel, Label: "tier", Values: []string{"1", "2"}, Weight: 1}}
+		assert.True(t, Evaluate(checks, Subject{Labels: map[string]string{"tier": "2"}}, now).Checks[0].Passed)
+		assert.False(t, Evaluate(checks, Subject{Labels: map[string]string{"tier": "3"}}, now).Checks[0].Passed)
+	})
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Shipped checks match the defaults", func(t *testing.T) {
+		checks, err := Load(filepath.Join("..", "..", "config", "scorecard.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, DefaultChecks(), checks)
+	})
+
+	t.Run("Invalid files", func(t *testing.T) {
+		tests := map[string]string{
+			"unknown type":  "checks:\n  - id: a\n    type: nope\n",
+			"missing min":   "checks:\n  - id: a\n    type: description_length\n",
+			"duplicate id":  "checks:\n  - id: a\n    type: has_team\n  - id: a\n    type: has_team\n",
+			"unknown field": "checks:\n  - id: a\n    type: has_team\n    minimum: 3\n",
+			"no checks":     "checks: []\n",
+		}
+		for name, content := range tests {
+			t.Run(name, func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), "checks.yaml")
+				require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+				_, err := Load(path)
+				assert.Error(t, err)
+			})
+		}
+	})
+}
@@ -0,0 +1,116 @@
+// Package signing verifies detached signatures over version registrations, cosign style:
+// the signer signs a canonical JSON payload with an ed25519 or ECDSA P-256 key and sends
+// the base64 signature along with the registration.
+package signing
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+const (
+	AlgorithmEd25519   = "ed25519"
+	AlgorithmECDSAP256 = "ecdsa-p256"
+)
+
+// ErrInvalidSignature is returned when a signature doesn't verify against a key
+var ErrInvalidSignature = errors.New("signature verification failed")
+
+// digestRe matches artifact digests such as sha256:<hex>
+var digestRe = regexp.MustCompile(`^(sha256:[a-f0-9]{64}|sha512:[a-f0-9]{128})$`)
+
+// Payload is the signed content of a version registration. Fields are in
+// alphabetical order so the encoding is canonical.
+type Payload struct {
+	ArtifactDigests []string `json:"artifact_digests"`
+	Commit          string   `json:"commit"`
+	ServiceID       string   `json:"service_id"`
+	Version         string   `json:"version"`
+}
+
+// Canonical returns the bytes that are signed: compact JSON with sorted digests and no HTML escaping
+func (p Payload) Canonical() []byte {
+	digests := append([]string{}, p.ArtifactDigests...)
+	sort.Strings(digests)
+	p.ArtifactDigests = digests
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(p)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// ValidDigest reports whether d is a supported artifact digest
+func ValidDigest(d string) bool {
+	return digestRe.MatchString(d)
+}
+
+// PublicKey is a trusted verification key
+type PublicKey struct {
+	Algorithm   string
+	Fingerprint string
+	key         any
+}
+
+// ParsePublicKey parses a PEM-encoded PKIX public key (as written by `cosign generate-key-pair`
+// or `openssl pkey -pubout`). Only ed25519 and ECDSA P-256 keys are accepted.
+func ParsePublicKey(pemData string) (*PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.New("expected a PEM encoded PUBLIC KEY block")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	sum := sha256.Sum256(block.Bytes)
+	pk := &PublicKey{Fingerprint: "sha256:" + hex.EncodeToString(sum[:]), key: key}
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		pk.Algorithm = AlgorithmEd25519
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, errors.New("only P-256 ECDSA keys are supported")
+		}
+		pk.Algorithm = AlgorithmECDSAP256
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	return pk, nil
+}
+
+// Verify checks a base64 encoded signature over payload
+func (k *PublicKey) Verify(payload []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("signature is not valid base64: %w", err)
+	}
+
+	switch key := k.key.(type) {
+	case ed25519.PublicKey:
+		if ed25519.Verify(key, payload, sig) {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(payload)
+		if ecdsa.VerifyASN1(key, digest[:], sig) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
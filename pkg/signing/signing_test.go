@@ -0,0 +1,102 @@
+package signing
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePublicKey(t *testing.T, pub any) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestPayload_Canonical(t *testing.T) {
+	p := Payload{
+		ArtifactDigests: []string{"sha256:" + strings.Repeat("b", 64), "sha256:" + strings.Repeat("a", 64)},
+		Commit:          "abc123",
+		ServiceID:       "5f0c6b2e-8c1d-4a57-9c43-2f7f2c1b9a10",
+		Version:         "1.0.0<beta>",
+	}
+
+	want := `{"artifact_digests":["sha256:` + strings.Repeat("a", 64) + `","sha256:` + strings.Repeat("b", 64) + `"],` +
+		`"commit":"abc123","service_id":"5f0c6b2e-8c1d-4a57-9c43-2f7f2c1b9a10","version":"1.0.0<beta>"}`
+	assert.Equal(t, want, string(p.Canonical()))
+
+	// The caller's digest order is left untouched
+	assert.Equal(t, "sha256:"+strings.Repeat("b", 64), p.ArtifactDigests[0])
+
+	// Missing digests encode as an empty array so signers don't have to distinguish nil
+	assert.Contains(t, string(Payload{Version: "1"}.Canonical()), `"artifact_digests":[]`)
+}
+
+func TestValidDigest(t *testing.T) {
+	assert.True(t, ValidDigest("sha256:"+strings.Repeat("0", 64)))
+	assert.True(t, ValidDigest("sha512:"+strings.Repeat("f", 128)))
+	assert.False(t, ValidDigest("sha256:"+strings.Repeat("0", 63)))
+	assert.False(t, ValidDigest("sha256:"+strings.Repeat("G", 64)))
+	assert.False(t, ValidDigest("md5:d41d8cd98f00b204e9800998ecf8427e"))
+}
+
+func TestVerify(t *testing.T) {
+	payload := Payload{Commit: "abc123", ServiceID: "svc", Version: "1.0.0"}.Canonical()
+
+	t.Run("ed25519", func(t *testing.T) {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		key, err := ParsePublicKey(encodePublicKey(t, pub))
+		require.NoError(t, err)
+		assert.Equal(t, AlgorithmEd25519, key.Algorithm)
+		assert.True(t, strings.HasPrefix(key.Fingerprint, "sha256:"))
+
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, payload))
+		assert.NoError(t, key.Verify(payload, sig))
+		assert.ErrorIs(t, key.Verify([]byte(`{"tampered":true}`), sig), ErrInvalidSignature)
+		assert.Error(t, key.Verify(payload, "not base64!"))
+	})
+
+	t.Run("ECDSA P-256", func(t *testing.T) {
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		key, err := ParsePublicKey(encodePublicKey(t, &priv.PublicKey))
+		require.NoError(t, err)
+		assert.Equal(t, AlgorithmECDSAP256, key.Algorithm)
+
+		digest := sha256.Sum256(payload)
+		raw, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+		require.NoError(t, err)
+		sig := base64.StdEncoding.EncodeToString(raw)
+		assert.NoError(t, key.Verify(payload, sig))
+
+		// A signature from another key does not verify
+		other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		raw, err = ecdsa.SignASN1(rand.Reader, other, digest[:])
+		require.NoError(t, err)
+		assert.ErrorIs(t, key.Verify(payload, base64.StdEncoding.EncodeToString(raw)), ErrInvalidSignature)
+	})
+
+	t.Run("Unsupported keys are rejected", func(t *testing.T) {
+		priv, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		require.NoError(t, err)
+		_, err = ParsePublicKey(encodePublicKey(t, &priv.PublicKey))
+		assert.Error(t, err)
+
+		_, err = ParsePublicKey("not a pem block")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,127 @@
+package slo
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Spec is an SLO as needed to generate rules
+type Spec struct {
+	Service     string
+	Name        string
+	SLI         string
+	Objective   float64 // percentage, e.g. 99.9
+	Window      string
+	ThresholdMS int    // latency SLIs only
+	Metric      string // overrides the default metric of the SLI type
+}
+
+// RuleFile is a Prometheus rule file
+type RuleFile struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+// RuleGroup is a Prometheus rule group
+type RuleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule is a Prometheus recording or alerting rule
+type Rule struct {
+	Record      string            `yaml:"record,omitempty"`
+	Alert       string            `yaml:"alert,omitempty"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// burnAlert is one multi-window, multi-burn-rate alert condition (Google SRE workbook, chapter 5)
+type burnAlert struct {
+	long, short string
+	factor      float64
+	severity    string
+	pending     string
+}
+
+var burnAlerts = []burnAlert{
+	{"1h", "5m", 14.4, "critical", "2m"},
+	{"6h", "30m", 6, "critical", "15m"},
+	{"1d", "2h", 3, "warning", "1h"},
+	{"3d", "6h", 1, "warning", "3h"},
+}
+
+// recordWindows are the windows error ratios are recorded for
+var recordWindows = []string{"5m", "30m", "1h", "2h", "6h", "1d", "3d"}
+
+// Rules generates one rule group per SLO: error ratio recording rules over the
+// burn-rate windows plus multi-window burn-rate alerts. Series are selected by the
+// catalog_service label, which Prometheus service discovery from the catalog attaches.
+func Rules(specs []Spec) RuleFile {
+	file := RuleFile{Groups: []RuleGroup{}}
+	for _, s := range specs {
+		file.Groups = append(file.Groups, ruleGroup(s))
+	}
+	return file
+}
+
+func ruleGroup(s Spec) RuleGroup {
+	labels := map[string]string{"catalog_service": s.Service, "slo": s.Name}
+	selector := fmt.Sprintf(`catalog_service=%s,slo=%s`, strconv.Quote(s.Service), strconv.Quote(s.Name))
+	allowed := strconv.FormatFloat(round(1-s.Objective/100), 'f', -1, 64)
+
+	g := RuleGroup{Name: "slo:" + s.Service + ":" + s.Name}
+	for _, w := range recordWindows {
+		g.Rules = append(g.Rules, Rule{
+			Record: "slo:sli_error:ratio_rate" + w,
+			Expr:   errorRatioExpr(s, w),
+			Labels: labels,
+		})
+	}
+
+	for _, a := range burnAlerts {
+		threshold := strconv.FormatFloat(a.factor, 'f', -1, 64) + " * " + allowed
+		g.Rules = append(g.Rules, Rule{
+			Alert: "SLOErrorBudgetBurn",
+			Expr: fmt.Sprintf("slo:sli_error:ratio_rate%s{%s} > (%s)\nand\nslo:sli_error:ratio_rate%s{%s} > (%s)",
+				a.long, selector, threshold, a.short, selector, threshold),
+			For: a.pending,
+			Labels: map[string]string{
+				"catalog_service": s.Service,
+				"slo":             s.Name,
+				"severity":        a.severity,
+				"long_window":     a.long,
+			},
+			Annotations: map[string]string{
+				"summary": fmt.Sprintf("%s is burning its %s error budget (%s objective over %s)",
+					s.Service, s.Name, strconv.FormatFloat(s.Objective, 'f', -1, 64)+"%", s.Window),
+				"description": fmt.Sprintf("Error budget burn rate above %sx over the last %s and %s.",
+					strconv.FormatFloat(a.factor, 'f', -1, 64), a.long, a.short),
+			},
+		})
+	}
+	return g
+}
+
+// errorRatioExpr returns the PromQL ratio of bad events over window w
+func errorRatioExpr(s Spec, w string) string {
+	service := "catalog_service=" + strconv.Quote(s.Service)
+
+	if s.SLI == SLILatency {
+		metric := s.Metric
+		if metric == "" {
+			metric = DefaultLatencyMetric
+		}
+		le := strconv.FormatFloat(float64(s.ThresholdMS)/1000, 'f', -1, 64)
+		return fmt.Sprintf("1 - (\n  sum(rate(%s_bucket{%s,le=%q}[%s]))\n  /\n  sum(rate(%s_count{%s}[%s]))\n)",
+			metric, service, le, w, metric, service, w)
+	}
+
+	metric := s.Metric
+	if metric == "" {
+		metric = DefaultAvailabilityMetric
+	}
+	return fmt.Sprintf("sum(rate(%s{%s,code=~\"5..\"}[%s]))\n/\nsum(rate(%s{%s}[%s]))",
+		metric, service, w, metric, service, w)
+}
@@ -0,0 +1,109 @@
+// Package slo implements error-budget math for service level objectives and
+// generates Prometheus recording and alerting rules for them.
+package slo
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// SLI types
+const (
+	SLIAvailability = "availability"
+	SLILatency      = "latency"
+)
+
+// Default metrics the generated rules query when an SLO doesn't name one
+const (
+	DefaultAvailabilityMetric = "http_requests_total"
+	DefaultLatencyMetric      = "http_request_duration_seconds"
+)
+
+var windowRe = regexp.MustCompile(`^(\d+)([mhdw])$`)
+
+// ParseWindow parses a Prometheus-style duration with a single unit: m, h, d or w (e.g. 30d)
+func ParseWindow(s string) (time.Duration, error) {
+	m := windowRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("invalid window %q (expected a number followed by m, h, d or w, e.g. 30d)", s)
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid window %q", s)
+	}
+
+	unit := map[string]time.Duration{"m": time.Minute, "h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour}[m[2]]
+	return time.Duration(n) * unit, nil
+}
+
+// ErrorBudget is the burn of an SLO's error budget over a period
+type ErrorBudget struct {
+	Good  float64 `json:"good"`
+	Total float64 `json:"total"`
+	// Period is the length of time the counts cover
+	Period string `json:"period"`
+
+	// ErrorRatio is the observed ratio of bad events
+	ErrorRatio float64 `json:"error_ratio"`
+	// AllowedErrorRatio is 1 - objective
+	AllowedErrorRatio float64 `json:"allowed_error_ratio"`
+	// BurnRate is how fast the budget is spent relative to spending it exactly over the window (1 = on track)
+	BurnRate float64 `json:"burn_rate"`
+	// BudgetConsumed is the fraction of the window's budget spent during the period
+	BudgetConsumed float64 `json:"budget_consumed"`
+	// BudgetRemaining is 1 - BudgetConsumed; negative when the budget is exhausted
+	BudgetRemaining float64 `json:"budget_remaining"`
+	// TimeToExhaustion is how long the remaining budget lasts at the current burn rate, empty when not burning
+	TimeToExhaustion string `json:"time_to_exhaustion,omitempty"`
+}
+
+// Budget computes burn rate and remaining error budget for good/total event counts observed over period.
+// objective is a percentage (e.g. 99.9) and window the SLO window the budget applies to.
+func Budget(objective float64, window, period time.Duration, good, total float64) ErrorBudget {
+	b := ErrorBudget{
+		Good:              good,
+		Total:             total,
+		Period:            FormatWindow(period),
+		AllowedErrorRatio: round(1 - objective/100),
+	}
+	if total > 0 {
+		b.ErrorRatio = (total - good) / total
+	}
+	if b.AllowedErrorRatio > 0 {
+		b.BurnRate = b.ErrorRatio / b.AllowedErrorRatio
+	}
+	b.BudgetConsumed = b.BurnRate * period.Seconds() / window.Seconds()
+	b.BudgetRemaining = 1 - b.BudgetConsumed
+
+	if b.BurnRate > 0 && b.BudgetRemaining > 0 {
+		left := time.Duration(b.BudgetRemaining * window.Seconds() / b.BurnRate * float64(time.Second))
+		b.TimeToExhaustion = left.Round(time.Minute).String()
+	}
+
+	b.ErrorRatio = round(b.ErrorRatio)
+	b.BurnRate = round(b.BurnRate)
+	b.BudgetConsumed = round(b.BudgetConsumed)
+	b.BudgetRemaining = round(b.BudgetRemaining)
+	return b
+}
+
+// FormatWindow formats a duration in the largest whole unit ParseWindow accepts
+func FormatWindow(d time.Duration) string {
+	for _, u := range []struct {
+		suffix string
+		d      time.Duration
+	}{{"w", 7 * 24 * time.Hour}, {"d", 24 * time.Hour}, {"h", time.Hour}} {
+		if d >= u.d && d%u.d == 0 {
+			return strconv.FormatInt(int64(d/u.d), 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+}
+
+// round drops floating point noise such as 0.0010000000000000009
+func round(f float64) float64 {
+	return math.Round(f*1e9) / 1e9
+}
@@ -0,0 +1,103 @@
+package slo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "sigs.k8s.io/yaml/goyaml.v2"
+)
+
+func TestParseWindow(t *testing.T) {
+	d, err := ParseWindow("30d")
+	require.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, d)
+
+	d, err = ParseWindow("4w")
+	require.NoError(t, err)
+	assert.Equal(t, 28*24*time.Hour, d)
+
+	d, err = ParseWindow("90m")
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, d)
+
+	for _, bad := range []string{"", "30", "1.5h", "0d", "30s", "1h30m"} {
+		_, err := ParseWindow(bad)
+		assert.Error(t, err, bad)
+	}
+
+	assert.Equal(t, "4w", FormatWindow(28*24*time.Hour))
+	assert.Equal(t, "30d", FormatWindow(30*24*time.Hour))
+	assert.Equal(t, "90m", FormatWindow(90*time.Minute))
+}
+
+func TestBudget(t *testing.T) {
+	window := 30 * 24 * time.Hour
+
+	t.Run("Burning exactly at budget", func(t *testing.T) {
+		b := Budget(99.9, window, window, 999_000, 1_000_000)
+		assert.Equal(t, 0.001, b.AllowedErrorRatio)
+		assert.Equal(t, 0.001, b.ErrorRatio)
+		assert.Equal(t, 1.0, b.BurnRate)
+		assert.Equal(t, 1.0, b.BudgetConsumed)
+		assert.Equal(t, 0.0, b.BudgetRemaining)
+		assert.Empty(t, b.TimeToExhaustion)
+	})
+
+	t.Run("Fast burn over a short period", func(t *testing.T) {
+		// 1% errors against a 0.1% budget is a 10x burn; one day of it spends a third of a 30 day budget
+		b := Budget(99.9, window, 24*time.Hour, 99_000, 100_000)
+		assert.Equal(t, 10.0, b.BurnRate)
+		assert.InDelta(t, 1.0/3, b.BudgetConsumed, 1e-9)
+		assert.InDelta(t, 2.0/3, b.BudgetRemaining, 1e-9)
+		assert.Equal(t, "48h0m0s", b.TimeToExhaustion)
+		assert.Equal(t, "1d", b.Period)
+	})
+
+	t.Run("Exhausted budget is negative", func(t *testing.T) {
+		b := Budget(99, window, window, 900, 1000)
+		assert.Equal(t, 10.0, b.BurnRate)
+		assert.Equal(t, -9.0, b.BudgetRemaining)
+	})
+
+	t.Run("No events", func(t *testing.T) {
+		b := Budget(99.9, window, window, 0, 0)
+		assert.Equal(t, 0.0, b.BurnRate)
+		assert.Equal(t, 1.0, b.BudgetRemaining)
+	})
+}
+
+func TestRules(t *testing.T) {
+	file := Rules([]Spec{
+		{Service: "payments", Name: "availability", SLI: SLIAvailability, Objective: 99.9, Window: "30d"},
+		{Service: "payments", Name: "checkout-latency", SLI: SLILatency, Objective: 99, Window: "28d", ThresholdMS: 300},
+	})
+	require.Len(t, file.Groups, 2)
+
+	avail := file.Groups[0]
+	assert.Equal(t, "slo:payments:availability", avail.Name)
+	require.Len(t, avail.Rules, len(recordWindows)+len(burnAlerts))
+	assert.Equal(t, "slo:sli_error:ratio_rate5m", avail.Rules[0].Record)
+	assert.Contains(t, avail.Rules[0].Expr, `http_requests_total{catalog_service="payments",code=~"5.."}[5m]`)
+	assert.Equal(t, map[string]string{"catalog_service": "payments", "slo": "availability"}, avail.Rules[0].Labels)
+
+	page := avail.Rules[len(recordWindows)]
+	assert.Equal(t, "SLOErrorBudgetBurn", page.Alert)
+	assert.Contains(t, page.Expr, `slo:sli_error:ratio_rate1h{catalog_service="payments",slo="availability"} > (14.4 * 0.001)`)
+	assert.Contains(t, page.Expr, `slo:sli_error:ratio_rate5m{`)
+	assert.Equal(t, "critical", page.Labels["severity"])
+
+	latency := file.Groups[1]
+	assert.Contains(t, latency.Rules[0].Expr, `http_request_duration_seconds_bucket{catalog_service="payments",le="0.3"}[5m]`)
+	assert.Contains(t, latency.Rules[0].Expr, `http_request_duration_seconds_count{catalog_service="payments"}[5m]`)
+
+	// The rule file round trips through YAML
+	out, err := yaml.Marshal(file)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(out), "groups:\n"))
+	var parsed RuleFile
+	require.NoError(t, yaml.Unmarshal(out, &parsed))
+	assert.Equal(t, file, parsed)
+}
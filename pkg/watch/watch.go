@@ -0,0 +1,143 @@
+// Package watch wakes up change stream watchers when change events are committed. Every replica
+// LISTENs on the Postgres changes channel, so watchers see writes made through any replica.
+// Hubs can listen on other channels too, e.g. models.AuditChannel for every audited write.
+package watch
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+
+	"kong/pkg/models"
+)
+
+// reconnectDelay is how long the hub waits before listening again after losing its connection
+const reconnectDelay = time.Second
+
+// Hub fans out change notifications to subscribers. Notifications carry no data: subscribers
+// read the change log from their last seen event ID, so coalescing notifications loses nothing.
+type Hub struct {
+	pool    *pgxpool.Pool
+	channel string
+	mu      sync.Mutex
+	subs    map[chan struct{}]struct{}
+}
+
+// NewHub creates a hub listening on channel through a connection taken from pool
+func NewHub(pool *pgxpool.Pool, channel string) *Hub {
+	return &Hub{pool: pool, channel: channel, subs: map[chan struct{}]struct{}{}}
+}
+
+// Subscribe returns a channel that receives a value after changes were committed, and a
+// function to unsubscribe. Notifications arriving while one is pending are merged.
+func (h *Hub) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs, ch)
+		h.mu.Unlock()
+	}
+}
+
+// broadcast wakes every subscriber without blocking on slow ones
+func (h *Hub) broadcast() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// ChangeLog reads change events; *models.Store implements it
+type ChangeLog interface {
+	// ListOutboxEvents reads events after f.AfterID and returns those matching f, along with
+	// the ID of the last event read (f.AfterID when there were none)
+	ListOutboxEvents(ctx context.Context, f models.ChangeFilter) ([]models.OutboxEvent, int64, error)
+}
+
+// Follow passes the events matching f after f.AfterID to send, reading up to f.Limit events at
+// a time (a page when 0), then waits for new ones until ctx is cancelled or send fails. idle is
+// called every interval without changes, e.g. to send a heartbeat, and the log is read again in
+// case a notification was lost while the hub reconnected.
+func (h *Hub) Follow(ctx context.Context, changes ChangeLog, f models.ChangeFilter, interval time.Duration,
+	send func([]models.OutboxEvent) error, idle func() error) error {
+	// Subscribe before the first read so no commit between a read and the wait is missed
+	wake, unsubscribe := h.Subscribe()
+	defer unsubscribe()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		events, last, err := changes.ListOutboxEvents(ctx, f)
+		if err != nil {
+			return err
+		}
+		if len(events) > 0 {
+			if err := send(events); err != nil {
+				return err
+			}
+		}
+		// Move past every event read, matching or not, and read on until the log is exhausted
+		if last > f.AfterID {
+			f.AfterID = last
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+		case <-ticker.C:
+			if err := idle(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Run listens for change notifications until ctx is cancelled, reconnecting when the connection is lost
+func (h *Hub) Run(ctx context.Context) {
+	for {
+		if err := h.listen(ctx); err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Msg("Lost change notification listener, reconnecting")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (h *Hub) listen(ctx context.Context) error {
+	pooled, err := h.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// A listening connection must not go back to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{h.channel}.Sanitize()); err != nil {
+		return err
+	}
+	// Changes may have been committed while not listening
+	h.broadcast()
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+		h.broadcast()
+	}
+}
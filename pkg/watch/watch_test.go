@@ -0,0 +1,120 @@
+package watch
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kong/pkg/models"
+)
+
+func TestHub_Broadcast(t *testing.T) {
+	h := NewHub(nil, models.ChangesChannel)
+	a, unsubscribeA := h.Subscribe()
+	b, unsubscribeB := h.Subscribe()
+	defer unsubscribeB()
+
+	// Notifications are merged while one is pending
+	h.broadcast()
+	h.broadcast()
+	assert.Len(t, a, 1)
+	assert.Len(t, b, 1)
+	<-a
+	<-b
+
+	unsubscribeA()
+	h.broadcast()
+	assert.Len(t, a, 0)
+	assert.Len(t, b, 1)
+}
+
+// changeLog is an in-memory change log capping reads at maxPage events, like the store
+type changeLog struct {
+	events  []models.OutboxEvent
+	maxPage int
+	reads   int
+}
+
+func (l *changeLog) ListOutboxEvents(ctx context.Context, f models.ChangeFilter) ([]models.OutboxEvent, int64, error) {
+	l.reads++
+	limit := f.Limit
+	if limit <= 0 || limit > l.maxPage {
+		limit = l.maxPage
+	}
+	var events []models.OutboxEvent
+	last := f.AfterID
+	for _, e := range l.events {
+		if e.ID <= f.AfterID || limit == 0 {
+			continue
+		}
+		limit--
+		last = e.ID
+		if len(f.ServiceIDs) == 0 || slices.Contains(f.ServiceIDs, e.ServiceID) {
+			events = append(events, e)
+		}
+	}
+	return events, last, nil
+}
+
+func TestFollow_ReadsPastCappedBatches(t *testing.T) {
+	log := &changeLog{maxPage: 50}
+	for id := int64(1); id <= 120; id++ {
+		log.events = append(log.events, models.OutboxEvent{ID: id})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// A batch limit past the log's page size must not be mistaken for the end of the log:
+	// every event arrives without waiting for a notification or the idle interval
+	var got []int64
+	err := NewHub(nil, models.ChangesChannel).Follow(ctx, log, models.ChangeFilter{Limit: 100}, time.Hour, func(events []models.OutboxEvent) error {
+		for _, e := range events {
+			got = append(got, e.ID)
+		}
+		if len(got) == len(log.events) {
+			cancel()
+		}
+		return nil
+	}, func() error { return nil })
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, got, 120)
+	assert.Equal(t, int64(120), got[len(got)-1])
+}
+
+func TestFollow_MovesPastUnmatchedEvents(t *testing.T) {
+	watched, other := uuid.New(), uuid.New()
+	log := &changeLog{maxPage: 50}
+	for id := int64(1); id <= 120; id++ {
+		log.events = append(log.events, models.OutboxEvent{ID: id, ServiceID: other})
+	}
+	log.events[len(log.events)-1].ServiceID = watched
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Pages without a matching event don't stop the read, and a wakeup doesn't rescan them
+	h := NewHub(nil, models.ChangesChannel)
+	var got []int64
+	idle := 0
+	err := h.Follow(ctx, log, models.ChangeFilter{ServiceIDs: []uuid.UUID{watched}}, time.Millisecond, func(events []models.OutboxEvent) error {
+		for _, e := range events {
+			got = append(got, e.ID)
+		}
+		return nil
+	}, func() error {
+		if idle++; idle == 2 {
+			cancel()
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []int64{120}, got)
+	// Three pages, one read finding the log exhausted and one after each idle interval
+	assert.Equal(t, 6, log.reads)
+}
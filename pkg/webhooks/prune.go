@@ -0,0 +1,43 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"kong/pkg/models"
+)
+
+// pruneInterval is how often outbox events are pruned
+const pruneInterval = time.Hour
+
+// RunPruner deletes outbox events older than retention once they are dispatched and published,
+// hourly until ctx is cancelled. Change stream clients resuming from a deleted event continue
+// with the oldest one left.
+func RunPruner(ctx context.Context, store *models.Store, retention time.Duration) {
+	prune := func() {
+		n, err := store.PruneOutboxEvents(ctx, time.Now().Add(-retention))
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Error().Err(err).Msg("Failed to prune outbox events")
+			}
+			return
+		}
+		if n > 0 {
+			log.Info().Int64("deleted", n).Msg("Outbox events pruned")
+		}
+	}
+
+	prune()
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			prune()
+		}
+	}
+}
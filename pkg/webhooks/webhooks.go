@@ -0,0 +1,212 @@
+// Package webhooks delivers catalog change events from the transactional outbox to webhook
+// subscriptions, as plain JSON or as CloudEvents. Each request is signed with the subscription's
+// secret, failed deliveries are retried with exponential backoff and end up dead after a maximum
+// number of attempts.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"kong/pkg/cloudevents"
+	"kong/pkg/models"
+	"kong/pkg/worker"
+)
+
+// Delivery request headers
+const (
+	HeaderEvent     = "X-Catalog-Event"
+	HeaderDelivery  = "X-Catalog-Delivery"
+	HeaderTimestamp = "X-Catalog-Timestamp"
+	HeaderSignature = "X-Catalog-Signature"
+)
+
+const (
+	// fanOutBatch is the number of outbox events fanned out per poll
+	fanOutBatch = 100
+	// baseBackoff and maxBackoff bound the delay between attempts
+	baseBackoff = 10 * time.Second
+	maxBackoff  = time.Hour
+)
+
+// Store is the persistence the dispatcher needs; *models.Store implements it
+type Store interface {
+	FanOutOutbox(ctx context.Context, limit int) (int64, error)
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.PendingDelivery, error)
+	RecordDeliveryAttempt(ctx context.Context, d *models.WebhookDelivery) error
+}
+
+// Sign returns the signature of a delivery: the hex HMAC-SHA256 of "<timestamp>.<body>" keyed
+// with the subscription secret, prefixed with "sha256=". Receivers should recompute it and
+// reject old timestamps to prevent replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is valid for the timestamp and body
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Backoff returns the delay after the given failed attempt (1-based): 10s doubled per attempt, at most an hour
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := baseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
+
+// Dispatcher fans out outbox events and sends due deliveries with bounded concurrency
+type Dispatcher struct {
+	store       Store
+	client      *http.Client
+	maxAttempts int
+	source      string
+	timeout     time.Duration
+	pool        *worker.Pool[models.PendingDelivery]
+}
+
+// NewDispatcher creates a dispatcher sending at most concurrency deliveries at a time and
+// giving up on a delivery after maxAttempts failed attempts. source is the CloudEvents source
+// of events sent to CloudEvents subscriptions.
+func NewDispatcher(store Store, client *http.Client, concurrency, maxAttempts int, source string) *Dispatcher {
+	if client == nil {
+		client = &http.Client{}
+	}
+	d := &Dispatcher{
+		store:       store,
+		client:      client,
+		maxAttempts: maxAttempts,
+		source:      source,
+		timeout:     10 * time.Second,
+	}
+	d.pool = worker.New(concurrency, time.Second, d.claim, d.deliver)
+	return d
+}
+
+// Run fans out events and sends deliveries until ctx is cancelled, then waits for in-flight deliveries
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.pool.Run(ctx, "Failed to dispatch webhook deliveries")
+}
+
+// RunOnce fans out pending events, sends the deliveries that are currently due and waits for the results to be recorded
+func (d *Dispatcher) RunOnce(ctx context.Context) error {
+	return d.pool.RunOnce(ctx)
+}
+
+// claim fans out new events, then claims up to limit due deliveries
+func (d *Dispatcher) claim(ctx context.Context, limit int) ([]models.PendingDelivery, error) {
+	for {
+		n, err := d.store.FanOutOutbox(ctx, fanOutBatch)
+		if err != nil {
+			return nil, err
+		}
+		if n < fanOutBatch {
+			break
+		}
+	}
+
+	// The lease outlives the request timeout, so a delivery is only reclaimed if this replica died
+	return d.store.ClaimDueDeliveries(ctx, limit, 2*d.timeout)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, p *models.PendingDelivery) {
+	status, err := d.send(ctx, p)
+	if ctx.Err() != nil {
+		return
+	}
+
+	now := time.Now()
+	result := p.WebhookDelivery
+	result.Attempts++
+	result.LastStatusCode = status
+	if err == nil {
+		result.Status = models.DeliveryDelivered
+		result.LastError = ""
+		result.DeliveredAt = &now
+	} else {
+		result.LastError = worker.Truncate(err.Error())
+		if result.Attempts >= d.maxAttempts {
+			result.Status = models.DeliveryDead
+			log.Warn().Str("delivery_id", result.ID.String()).Str("url", p.URL).Int("attempts", result.Attempts).
+				Msg("Webhook delivery failed permanently")
+		} else {
+			result.NextAttemptAt = now.Add(Backoff(result.Attempts))
+		}
+	}
+
+	if err := d.store.RecordDeliveryAttempt(ctx, &result); err != nil {
+		log.Error().Err(err).Str("delivery_id", result.ID.String()).Msg("Failed to record webhook delivery attempt")
+	}
+}
+
+// encode returns the request body in the subscription's format and sets its content headers
+func (d *Dispatcher) encode(h http.Header, p *models.PendingDelivery) ([]byte, error) {
+	switch p.Format {
+	case models.WebhookFormatCloudEvents:
+		h.Set("Content-Type", cloudevents.ContentTypeStructured)
+		return cloudevents.MarshalStructured(cloudevents.FromOutbox(p.Event, d.source))
+	case models.WebhookFormatCloudEventsBinary:
+		return cloudevents.WriteBinary(h, cloudevents.FromOutbox(p.Event, d.source)), nil
+	default:
+		h.Set("Content-Type", "application/json")
+		return models.MarshalEvent(p.Event)
+	}
+}
+
+// send posts the event to the subscription URL. Any 2xx response is a success.
+func (d *Dispatcher) send(ctx context.Context, p *models.PendingDelivery) (int, error) {
+	header := http.Header{}
+	body, err := d.encode(header, p)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header = header
+	ts := time.Now().Unix()
+	req.Header.Set("User-Agent", "kong-catalog-webhooks")
+	req.Header.Set(HeaderEvent, p.Event.Type)
+	req.Header.Set(HeaderDelivery, p.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(p.Secret, ts, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
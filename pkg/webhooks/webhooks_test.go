@@ -0,0 +1,243 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kong/pkg/cloudevents"
+	"kong/pkg/models"
+)
+
+// fakeStore hands out every pending delivery that is due on each claim and records attempts in memory
+type fakeStore struct {
+	mu         sync.Mutex
+	deliveries []models.PendingDelivery
+	fanOuts    int
+}
+
+func (f *fakeStore) FanOutOutbox(ctx context.Context, limit int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fanOuts++
+	return 0, nil
+}
+
+func (f *fakeStore) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.PendingDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var due []models.PendingDelivery
+	for _, d := range f.deliveries {
+		if d.Status == models.DeliveryPending && !d.NextAttemptAt.After(time.Now()) && len(due) < limit {
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakeStore) RecordDeliveryAttempt(ctx context.Context, d *models.WebhookDelivery) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.deliveries {
+		if f.deliveries[i].ID == d.ID {
+			f.deliveries[i].WebhookDelivery = *d
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) get(i int) models.WebhookDelivery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.deliveries[i].WebhookDelivery
+}
+
+// due makes every delivery due again, skipping the backoff
+func (f *fakeStore) due() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.deliveries {
+		f.deliveries[i].NextAttemptAt = time.Time{}
+	}
+}
+
+func newDelivery(url, secret string) models.PendingDelivery {
+	serviceID := uuid.New()
+	return models.PendingDelivery{
+		WebhookDelivery: models.WebhookDelivery{
+			ID:             uuid.New(),
+			SubscriptionID: uuid.New(),
+			EventID:        42,
+			EventType:      models.EventServiceCreated,
+			Status:         models.DeliveryPending,
+		},
+		URL:    url,
+		Secret: secret,
+		Event: models.OutboxEvent{
+			ID:        42,
+			Type:      models.EventServiceCreated,
+			ServiceID: serviceID,
+			Payload:   json.RawMessage(`{"id":"` + serviceID.String() + `","name":"billing"}`),
+			CreatedAt: time.Now(),
+		},
+	}
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	sig := Sign("s3cret", 1700000000, body)
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, sig)
+	assert.True(t, Verify("s3cret", 1700000000, body, sig))
+	assert.False(t, Verify("other", 1700000000, body, sig))
+	assert.False(t, Verify("s3cret", 1700000001, body, sig))
+	assert.False(t, Verify("s3cret", 1700000000, []byte(`{"id":2}`), sig))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, Backoff(1))
+	assert.Equal(t, 20*time.Second, Backoff(2))
+	assert.Equal(t, 80*time.Second, Backoff(4))
+	assert.Equal(t, time.Hour, Backoff(10))
+	assert.Equal(t, time.Hour, Backoff(100))
+}
+
+func TestDispatcher_DeliversSignedEvent(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan received, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{header: r.Header.Clone(), body: body}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer receiver.Close()
+
+	store := &fakeStore{deliveries: []models.PendingDelivery{newDelivery(receiver.URL, "s3cret")}}
+	d := NewDispatcher(store, nil, 2, 3, "urn:test")
+	require.NoError(t, d.RunOnce(context.Background()))
+
+	r := <-got
+	assert.Equal(t, models.EventServiceCreated, r.header.Get(HeaderEvent))
+	assert.Equal(t, store.deliveries[0].ID.String(), r.header.Get(HeaderDelivery))
+	ts, err := strconv.ParseInt(r.header.Get(HeaderTimestamp), 10, 64)
+	require.NoError(t, err)
+	assert.True(t, Verify("s3cret", ts, r.body, r.header.Get(HeaderSignature)))
+
+	var event map[string]any
+	require.NoError(t, json.Unmarshal(r.body, &event))
+	assert.Equal(t, float64(42), event["id"])
+	assert.Equal(t, models.EventServiceCreated, event["type"])
+	assert.Equal(t, "billing", event["data"].(map[string]any)["name"])
+
+	result := store.get(0)
+	assert.Equal(t, models.DeliveryDelivered, result.Status)
+	assert.Equal(t, 1, result.Attempts)
+	assert.Equal(t, http.StatusAccepted, result.LastStatusCode)
+	assert.NotNil(t, result.DeliveredAt)
+	assert.Equal(t, 1, store.fanOuts)
+}
+
+func TestDispatcher_RetriesThenDeadLetters(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	store := &fakeStore{deliveries: []models.PendingDelivery{newDelivery(receiver.URL, "s3cret")}}
+	d := NewDispatcher(store, nil, 1, 3, "urn:test")
+	ctx := context.Background()
+
+	before := time.Now()
+	require.NoError(t, d.RunOnce(ctx))
+	result := store.get(0)
+	assert.Equal(t, models.DeliveryPending, result.Status)
+	assert.Equal(t, 1, result.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, result.LastStatusCode)
+	assert.Equal(t, "unexpected status 500", result.LastError)
+	assert.WithinDuration(t, before.Add(Backoff(1)), result.NextAttemptAt, time.Second)
+
+	// Not due yet: nothing is sent
+	require.NoError(t, d.RunOnce(ctx))
+	assert.Equal(t, int32(1), calls.Load())
+
+	store.due()
+	require.NoError(t, d.RunOnce(ctx))
+	assert.Equal(t, 2, store.get(0).Attempts)
+	assert.WithinDuration(t, time.Now().Add(Backoff(2)), store.get(0).NextAttemptAt, time.Second)
+
+	store.due()
+	require.NoError(t, d.RunOnce(ctx))
+	result = store.get(0)
+	assert.Equal(t, models.DeliveryDead, result.Status)
+	assert.Equal(t, 3, result.Attempts)
+
+	// Dead deliveries are never retried
+	store.due()
+	require.NoError(t, d.RunOnce(ctx))
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestDispatcher_UnreachableReceiver(t *testing.T) {
+	receiver := httptest.NewServer(http.NotFoundHandler())
+	url := receiver.URL
+	receiver.Close()
+
+	store := &fakeStore{deliveries: []models.PendingDelivery{newDelivery(url, "s3cret")}}
+	require.NoError(t, NewDispatcher(store, nil, 1, 5, "urn:test").RunOnce(context.Background()))
+
+	result := store.get(0)
+	assert.Equal(t, models.DeliveryPending, result.Status)
+	assert.Equal(t, 1, result.Attempts)
+	assert.Equal(t, 0, result.LastStatusCode)
+	assert.NotEmpty(t, result.LastError)
+}
+
+func TestDispatcher_CloudEventsFormats(t *testing.T) {
+	got := make(chan []cloudevents.Event, 2)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		events, err := cloudevents.Parse(r.Header, r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		got <- events
+	}))
+	defer receiver.Close()
+
+	structured := newDelivery(receiver.URL, "s3cret")
+	structured.Format = models.WebhookFormatCloudEvents
+	binary := newDelivery(receiver.URL, "s3cret")
+	binary.Format = models.WebhookFormatCloudEventsBinary
+	store := &fakeStore{deliveries: []models.PendingDelivery{structured, binary}}
+	require.NoError(t, NewDispatcher(store, nil, 2, 3, "https://catalog.example.com").RunOnce(context.Background()))
+
+	for i, p := range []models.PendingDelivery{structured, binary} {
+		assert.Equal(t, models.DeliveryDelivered, store.get(i).Status, p.Format)
+	}
+	close(got)
+	for events := range got {
+		require.Len(t, events, 1)
+		e := events[0]
+		assert.Equal(t, "42", e.ID)
+		assert.Equal(t, "https://catalog.example.com", e.Source)
+		assert.Equal(t, "com.kong.catalog.service.created.v1", e.Type)
+		assert.Contains(t, []string{structured.Event.ServiceID.String(), binary.Event.ServiceID.String()}, e.Subject)
+		assert.Equal(t, "https://catalog.example.com/v1/events/schemas/com.kong.catalog.service.created.v1", e.DataSchema)
+		assert.Contains(t, string(e.Data), `"name":"billing"`)
+	}
+}
@@ -0,0 +1,105 @@
+// Package worker runs jobs claimed from the database in the background with bounded
+// concurrency. Health probes and webhook deliveries share it: each poll claims at most as many
+// due jobs as there are free slots, so jobs claimed by one replica aren't left waiting behind
+// busy slots while other replicas could take them.
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+)
+
+// MaxErrorLength bounds the job errors stored with results
+const MaxErrorLength = 500
+
+// Truncate shortens an error message to at most MaxErrorLength bytes, without splitting a character
+func Truncate(s string) string {
+	if len(s) <= MaxErrorLength {
+		return s
+	}
+	n := MaxErrorLength
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// Pool claims jobs and runs each in its own goroutine, at most concurrency at a time
+type Pool[T any] struct {
+	concurrency int
+	poll        time.Duration
+	slots       chan struct{}
+	claim       func(ctx context.Context, limit int) ([]T, error)
+	run         func(ctx context.Context, job *T)
+}
+
+// New creates a pool running at most concurrency jobs at a time. claim is called every poll
+// interval with the number of free slots, run for every job it returns.
+func New[T any](concurrency int, poll time.Duration, claim func(ctx context.Context, limit int) ([]T, error), run func(ctx context.Context, job *T)) *Pool[T] {
+	return &Pool[T]{
+		concurrency: concurrency,
+		poll:        poll,
+		slots:       make(chan struct{}, concurrency),
+		claim:       claim,
+		run:         run,
+	}
+}
+
+// Run claims and runs jobs until ctx is cancelled, then waits for running jobs. Claim errors
+// are logged with message.
+func (p *Pool[T]) Run(ctx context.Context, message string) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(p.poll)
+	defer ticker.Stop()
+	for {
+		if err := p.dispatch(ctx, &wg); err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Msg(message)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce runs the jobs that are currently due and waits for them to finish
+func (p *Pool[T]) RunOnce(ctx context.Context) error {
+	var wg sync.WaitGroup
+	err := p.dispatch(ctx, &wg)
+	wg.Wait()
+	return err
+}
+
+// dispatch claims as many jobs as there are free slots and runs them in the background
+func (p *Pool[T]) dispatch(ctx context.Context, wg *sync.WaitGroup) error {
+	free := p.concurrency - len(p.slots)
+	if free <= 0 {
+		return nil
+	}
+
+	jobs, err := p.claim(ctx, free)
+	if err != nil {
+		return err
+	}
+
+	for i := range jobs {
+		job := jobs[i]
+		p.slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-p.slots
+				wg.Done()
+			}()
+			p.run(ctx, &job)
+		}()
+	}
+	return nil
+}
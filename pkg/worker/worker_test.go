@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", Truncate("short"))
+	assert.Len(t, Truncate(strings.Repeat("a", 600)), MaxErrorLength)
+
+	// Multi-byte characters aren't split
+	s := strings.Repeat("a", MaxErrorLength-1) + "é"
+	assert.Equal(t, strings.Repeat("a", MaxErrorLength-1), Truncate(s))
+}
+
+func TestPool_BoundsConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	var limits []int
+	release := make(chan struct{})
+	p := New(2, time.Hour, func(ctx context.Context, limit int) ([]int, error) {
+		mu.Lock()
+		limits = append(limits, limit)
+		mu.Unlock()
+		return make([]int, limit), nil
+	}, func(ctx context.Context, job *int) {
+		<-release
+	})
+
+	var wg sync.WaitGroup
+	require.NoError(t, p.dispatch(context.Background(), &wg))
+	// Every slot is busy, so nothing is claimed
+	require.NoError(t, p.dispatch(context.Background(), &wg))
+	close(release)
+	wg.Wait()
+	require.NoError(t, p.RunOnce(context.Background()))
+
+	assert.Equal(t, []int{2, 2}, limits)
+}